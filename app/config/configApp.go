@@ -1,19 +1,63 @@
 package config
 
 import (
+	"fmt"
 	viper "github.com/spf13/viper"
+	"time"
 )
 
 type App struct {
 	Port int `json:"port"`
+
+	// client ip read from proxy header only when request come from trusted proxy
+	ProxyHeader    string   `json:"proxy_header,omitempty"`
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
 }
 
 type Database struct {
+	// mysql, postgres or sqlite. for sqlite, name is path of database file
+	Driver    string `json:"driver,omitempty"`
+	Host      string `json:"host,omitempty"`
+	User      string `json:"user,omitempty"`
+	Password  string `json:"password,omitempty"`
+	Port      int    `json:"port,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Tls       string `json:"tls,omitempty"`
+	Collation string `json:"collation,omitempty"`
+
+	// dsn timeout
+	Timeout      time.Duration `json:"timeout,omitempty"`
+	ReadTimeout  time.Duration `json:"read_timeout,omitempty"`
+	WriteTimeout time.Duration `json:"write_timeout,omitempty"`
+
+	// connection pool
+	MaxOpenConns    int           `json:"max_open_conns,omitempty"`
+	MaxIdleConns    int           `json:"max_idle_conns,omitempty"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime,omitempty"`
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time,omitempty"`
+
+	// ping with exponential backoff on startup
+	PingTimeout         time.Duration `json:"ping_timeout,omitempty"`
+	RetryInitialWait    time.Duration `json:"retry_initial_wait,omitempty"`
+	RetryMaxWait        time.Duration `json:"retry_max_wait,omitempty"`
+	RetryMaxElapsedTime time.Duration `json:"retry_max_elapsed_time,omitempty"`
+
+	// run pending migration when app start
+	AutoMigrate bool `json:"auto_migrate,omitempty"`
+
+	// read replica, empty user and password use value of primary
+	Replicas                   []*DatabaseReplica `json:"replicas,omitempty"`
+	ReplicaPolicy              string             `json:"replica_policy,omitempty"`
+	ReplicaHealthCheckInterval time.Duration      `json:"replica_health_check_interval,omitempty"`
+	ReplicaMaxFailures         int                `json:"replica_max_failures,omitempty"`
+	ReadYourWritesWindow       time.Duration      `json:"read_your_writes_window,omitempty"`
+}
+
+type DatabaseReplica struct {
 	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
 	User     string `json:"user,omitempty"`
 	Password string `json:"password,omitempty"`
-	Port     int    `json:"port,omitempty"`
-	Name     string `json:"name,omitempty"`
 }
 
 type Jaeger struct {
@@ -29,27 +73,240 @@ type JWT struct {
 	Expired   int    `json:"expired,omitempty"`
 }
 
+type Log struct {
+	// debug, info, warn or error
+	Level string `json:"level,omitempty"`
+	// json or text
+	Format    string `json:"format,omitempty"`
+	AddSource bool   `json:"add_source,omitempty"`
+}
+
+type AccessLog struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// fraction of successful request written, error request always written
+	SuccessSampleRate float64 `json:"success_sample_rate,omitempty"`
+}
+
+type RateLimit struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// rule of every route name, route without rule not limited
+	Routes map[string][]*RateLimitRule `json:"routes,omitempty"`
+}
+
+type RateLimitRule struct {
+	// ip, email or subject
+	Key    string        `json:"key,omitempty"`
+	Limit  int           `json:"limit,omitempty"`
+	Period time.Duration `json:"period,omitempty"`
+	Burst  int           `json:"burst,omitempty"`
+}
+
+// Validate return error of first rule that can not be used by token bucket.
+// limit and period must be positive, burst 0 mean same as limit
+func (r *RateLimit) Validate() error {
+	for route, rules := range r.Routes {
+		for i, rule := range rules {
+			if rule == nil || rule.Limit <= 0 || rule.Period <= 0 || rule.Burst < 0 {
+				return fmt.Errorf("rate_limit.routes.%v[%v] must have positive limit and period, and burst not negative", route, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+type Lockout struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// account locked every Threshold failed login, lock duration doubled every lock until MaxDuration
+	Threshold   int           `json:"threshold,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+	MaxDuration time.Duration `json:"max_duration,omitempty"`
+	// same error for unknown email and wrong password, so login not reveal registered email
+	UniformError bool `json:"uniform_error,omitempty"`
+}
+
+type Password struct {
+	// argon2id or bcrypt, algorithm of new hash. hash with other algorithm or parameter rehashed on login
+	Algorithm string         `json:"algorithm,omitempty"`
+	Argon2id  *Argon2idParam `json:"argon2id,omitempty"`
+	Bcrypt    *BcryptParam   `json:"bcrypt,omitempty"`
+	// rule of new password, checked with validator tag password
+	Policy *PasswordPolicy `json:"policy,omitempty"`
+}
+
+type PasswordPolicy struct {
+	MinLength int `json:"min_length,omitempty"`
+	// max length in byte, keep 72 or less when using bcrypt
+	MaxLength        int  `json:"max_length,omitempty"`
+	RequireUpper     bool `json:"require_upper,omitempty"`
+	RequireLower     bool `json:"require_lower,omitempty"`
+	RequireDigit     bool `json:"require_digit,omitempty"`
+	RequireSymbol    bool `json:"require_symbol,omitempty"`
+	DisallowIdentity bool `json:"disallow_identity,omitempty"`
+	// file of sha1 hash of breached password, empty to disable check
+	BreachedFile string `json:"breached_file,omitempty"`
+}
+
+type Argon2idParam struct {
+	// memory in KiB
+	Memory      uint32 `json:"memory,omitempty"`
+	Iterations  uint32 `json:"iterations,omitempty"`
+	Parallelism uint8  `json:"parallelism,omitempty"`
+	SaltLength  uint32 `json:"salt_length,omitempty"`
+	KeyLength   uint32 `json:"key_length,omitempty"`
+}
+
+type BcryptParam struct {
+	Cost int `json:"cost,omitempty"`
+}
+
+type Mail struct {
+	// smtp or log. log driver write email into File, or stdout when File empty
+	Driver   string `json:"driver,omitempty"`
+	From     string `json:"from,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	File     string `json:"file,omitempty"`
+}
+
+type PasswordReset struct {
+	TokenTTL time.Duration `json:"token_ttl,omitempty"`
+	// url of reset page, token appended as query parameter token
+	URL string `json:"url,omitempty"`
+}
+
+type EmailVerification struct {
+	TokenTTL time.Duration `json:"token_ttl,omitempty"`
+	// url of verify endpoint, token appended as query parameter token
+	URL string `json:"url,omitempty"`
+	// true refuse login of unverified account, false only flag it in jwt claims
+	RequireVerified bool `json:"require_verified,omitempty"`
+}
+
+type TwoFactor struct {
+	// issuer shown in authenticator app
+	Issuer string `json:"issuer,omitempty"`
+	// number of time step before and after current step still accepted, for clock drift
+	Skew int `json:"skew,omitempty"`
+	// lifetime of mfa_pending token returned by login of account with two factor
+	PendingTTL    time.Duration `json:"pending_ttl,omitempty"`
+	RecoveryCodes int           `json:"recovery_codes,omitempty"`
+	// mfa_pending token rejected after this many code failed, password must be entered again. 0 is unlimited
+	MaxAttempts int `json:"max_attempts,omitempty"`
+}
+
+type Session struct {
+	// last seen of session updated at most once every interval, so not every request write to database
+	TouchInterval time.Duration `json:"touch_interval,omitempty"`
+}
+
+type ApiKey struct {
+	// last used of api key updated at most once every interval
+	TouchInterval time.Duration `json:"touch_interval,omitempty"`
+}
+
+type OAuth struct {
+	// lifetime of access token issued by client_credentials grant
+	TokenTTL time.Duration `json:"token_ttl,omitempty"`
+}
+
+type Pagination struct {
+	// limit of list endpoint when query limit not given
+	DefaultLimit int `json:"default_limit,omitempty"`
+	// query limit above max rejected
+	MaxLimit int `json:"max_limit,omitempty"`
+}
+
+type AccountTransfer struct {
+	// row of import inserted in one transaction
+	ImportBatchSize int `json:"import_batch_size,omitempty"`
+	// row after max not read, report of import marked truncated
+	ImportMaxRows int `json:"import_max_rows,omitempty"`
+	// body of import streamed, byte after max not read and report marked truncated
+	ImportMaxBytes int64 `json:"import_max_bytes,omitempty"`
+	// goroutine hash password of one batch in parallel
+	HashWorkers int `json:"hash_workers,omitempty"`
+	// account read from database in one query while export
+	ExportBatchSize int `json:"export_batch_size,omitempty"`
+}
+
+type Admin struct {
+	// account with this email can access admin endpoint
+	Emails []string `json:"emails,omitempty"`
+}
+
 type ConfigApp struct {
-	App      *App      `json:"app"`
-	Database *Database `json:"database"`
-	Jaeger   *Jaeger   `json:"jaeger"`
-	Jwt      *JWT      `json:"jwt"`
+	App       *App       `json:"app"`
+	Database  *Database  `json:"database"`
+	Jaeger    *Jaeger    `json:"jaeger"`
+	Jwt       *JWT       `json:"jwt"`
+	Log       *Log       `json:"log"`
+	AccessLog *AccessLog `json:"access_log"`
+	RateLimit *RateLimit `json:"rate_limit"`
+	Lockout   *Lockout   `json:"lockout"`
+	Admin     *Admin     `json:"admin"`
+	Password  *Password  `json:"password"`
+	Mail      *Mail      `json:"mail"`
+	// reset password
+	PasswordReset *PasswordReset `json:"password_reset"`
+	// verify email after signup
+	EmailVerification *EmailVerification `json:"email_verification"`
+	TwoFactor         *TwoFactor         `json:"two_factor"`
+	Session           *Session           `json:"session"`
+	ApiKey            *ApiKey            `json:"api_key"`
+	OAuth             *OAuth             `json:"oauth"`
+	Pagination        *Pagination        `json:"pagination"`
+	// bulk import and export of account
+	AccountTransfer *AccountTransfer `json:"account_transfer"`
 }
 
 func NewConfigApp() IConfig {
 	viper := viper.New()
 	viper.SetConfigFile("config.json")
 	viper.AddConfigPath("./")
+	setDefault(viper)
 	viper.ReadInConfig()
 
+	var replicas []*DatabaseReplica
+	viper.UnmarshalKey("database.replicas", &replicas)
+
+	var rateLimitRoutes map[string][]*RateLimitRule
+	viper.UnmarshalKey("rate_limit.routes", &rateLimitRoutes)
+
 	cfg := ConfigApp{
-		App: &App{Port: viper.GetInt("app.port")},
+		App: &App{
+			Port:           viper.GetInt("app.port"),
+			ProxyHeader:    viper.GetString("app.proxy_header"),
+			TrustedProxies: viper.GetStringSlice("app.trusted_proxies"),
+		},
 		Database: &Database{
-			Host:     viper.GetString("database.host"),
-			User:     viper.GetString("database.user"),
-			Password: viper.GetString("database.password"),
-			Port:     viper.GetInt("database.port"),
-			Name:     viper.GetString("database.name"),
+			Driver:                     viper.GetString("database.driver"),
+			Host:                       viper.GetString("database.host"),
+			User:                       viper.GetString("database.user"),
+			Password:                   viper.GetString("database.password"),
+			Port:                       viper.GetInt("database.port"),
+			Name:                       viper.GetString("database.name"),
+			Tls:                        viper.GetString("database.tls"),
+			Collation:                  viper.GetString("database.collation"),
+			Timeout:                    viper.GetDuration("database.timeout"),
+			ReadTimeout:                viper.GetDuration("database.read_timeout"),
+			WriteTimeout:               viper.GetDuration("database.write_timeout"),
+			MaxOpenConns:               viper.GetInt("database.max_open_conns"),
+			MaxIdleConns:               viper.GetInt("database.max_idle_conns"),
+			ConnMaxLifetime:            viper.GetDuration("database.conn_max_lifetime"),
+			ConnMaxIdleTime:            viper.GetDuration("database.conn_max_idle_time"),
+			PingTimeout:                viper.GetDuration("database.ping_timeout"),
+			RetryInitialWait:           viper.GetDuration("database.retry_initial_wait"),
+			RetryMaxWait:               viper.GetDuration("database.retry_max_wait"),
+			RetryMaxElapsedTime:        viper.GetDuration("database.retry_max_elapsed_time"),
+			AutoMigrate:                viper.GetBool("database.auto_migrate"),
+			Replicas:                   replicas,
+			ReplicaPolicy:              viper.GetString("database.replica_policy"),
+			ReplicaHealthCheckInterval: viper.GetDuration("database.replica_health_check_interval"),
+			ReplicaMaxFailures:         viper.GetInt("database.replica_max_failures"),
+			ReadYourWritesWindow:       viper.GetDuration("database.read_your_writes_window"),
 		},
 		Jaeger: &Jaeger{
 			ServiceName: viper.GetString("jaeger.service_name"),
@@ -62,11 +319,194 @@ func NewConfigApp() IConfig {
 			Subject:   viper.GetString("jwt.subject"),
 			Expired:   viper.GetInt("jwt.expired"),
 		},
+		Log: &Log{
+			Level:     viper.GetString("log.level"),
+			Format:    viper.GetString("log.format"),
+			AddSource: viper.GetBool("log.add_source"),
+		},
+		AccessLog: &AccessLog{
+			Enabled:           viper.GetBool("access_log.enabled"),
+			SuccessSampleRate: viper.GetFloat64("access_log.success_sample_rate"),
+		},
+		RateLimit: &RateLimit{
+			Enabled: viper.GetBool("rate_limit.enabled"),
+			Routes:  rateLimitRoutes,
+		},
+		Lockout: &Lockout{
+			Enabled:      viper.GetBool("lockout.enabled"),
+			Threshold:    viper.GetInt("lockout.threshold"),
+			Duration:     viper.GetDuration("lockout.duration"),
+			MaxDuration:  viper.GetDuration("lockout.max_duration"),
+			UniformError: viper.GetBool("lockout.uniform_error"),
+		},
+		Admin: &Admin{
+			Emails: viper.GetStringSlice("admin.emails"),
+		},
+		Password: &Password{
+			Algorithm: viper.GetString("password.algorithm"),
+			Argon2id: &Argon2idParam{
+				Memory:      viper.GetUint32("password.argon2id.memory"),
+				Iterations:  viper.GetUint32("password.argon2id.iterations"),
+				Parallelism: uint8(viper.GetUint("password.argon2id.parallelism")),
+				SaltLength:  viper.GetUint32("password.argon2id.salt_length"),
+				KeyLength:   viper.GetUint32("password.argon2id.key_length"),
+			},
+			Bcrypt: &BcryptParam{
+				Cost: viper.GetInt("password.bcrypt.cost"),
+			},
+			Policy: &PasswordPolicy{
+				MinLength:        viper.GetInt("password.policy.min_length"),
+				MaxLength:        viper.GetInt("password.policy.max_length"),
+				RequireUpper:     viper.GetBool("password.policy.require_upper"),
+				RequireLower:     viper.GetBool("password.policy.require_lower"),
+				RequireDigit:     viper.GetBool("password.policy.require_digit"),
+				RequireSymbol:    viper.GetBool("password.policy.require_symbol"),
+				DisallowIdentity: viper.GetBool("password.policy.disallow_identity"),
+				BreachedFile:     viper.GetString("password.policy.breached_file"),
+			},
+		},
+		Mail: &Mail{
+			Driver:   viper.GetString("mail.driver"),
+			From:     viper.GetString("mail.from"),
+			Host:     viper.GetString("mail.host"),
+			Port:     viper.GetInt("mail.port"),
+			Username: viper.GetString("mail.username"),
+			Password: viper.GetString("mail.password"),
+			File:     viper.GetString("mail.file"),
+		},
+		PasswordReset: &PasswordReset{
+			TokenTTL: viper.GetDuration("password_reset.token_ttl"),
+			URL:      viper.GetString("password_reset.url"),
+		},
+		EmailVerification: &EmailVerification{
+			TokenTTL:        viper.GetDuration("email_verification.token_ttl"),
+			URL:             viper.GetString("email_verification.url"),
+			RequireVerified: viper.GetBool("email_verification.require_verified"),
+		},
+		TwoFactor: &TwoFactor{
+			Issuer:        viper.GetString("two_factor.issuer"),
+			Skew:          viper.GetInt("two_factor.skew"),
+			PendingTTL:    viper.GetDuration("two_factor.pending_ttl"),
+			RecoveryCodes: viper.GetInt("two_factor.recovery_codes"),
+			MaxAttempts:   viper.GetInt("two_factor.max_attempts"),
+		},
+		Session: &Session{
+			TouchInterval: viper.GetDuration("session.touch_interval"),
+		},
+		ApiKey: &ApiKey{
+			TouchInterval: viper.GetDuration("api_key.touch_interval"),
+		},
+		OAuth: &OAuth{
+			TokenTTL: viper.GetDuration("oauth.token_ttl"),
+		},
+		Pagination: &Pagination{
+			DefaultLimit: viper.GetInt("pagination.default_limit"),
+			MaxLimit:     viper.GetInt("pagination.max_limit"),
+		},
+		AccountTransfer: &AccountTransfer{
+			ImportBatchSize: viper.GetInt("account_transfer.import_batch_size"),
+			ImportMaxRows:   viper.GetInt("account_transfer.import_max_rows"),
+			ImportMaxBytes:  viper.GetInt64("account_transfer.import_max_bytes"),
+			HashWorkers:     viper.GetInt("account_transfer.hash_workers"),
+			ExportBatchSize: viper.GetInt("account_transfer.export_batch_size"),
+		},
 	}
 
 	return &cfg
 }
 
+// setDefault fill value that not exist in config.json
+func setDefault(v *viper.Viper) {
+	// database
+	v.SetDefault("database.driver", "mysql")
+	v.SetDefault("database.tls", "false")
+	v.SetDefault("database.collation", "utf8mb4_general_ci")
+	v.SetDefault("database.timeout", "5s")
+	v.SetDefault("database.read_timeout", "30s")
+	v.SetDefault("database.write_timeout", "30s")
+	v.SetDefault("database.max_open_conns", 50)
+	v.SetDefault("database.max_idle_conns", 30)
+	v.SetDefault("database.conn_max_lifetime", "1h")
+	v.SetDefault("database.conn_max_idle_time", "30m")
+	v.SetDefault("database.ping_timeout", "3s")
+	v.SetDefault("database.retry_initial_wait", "500ms")
+	v.SetDefault("database.retry_max_wait", "10s")
+	v.SetDefault("database.retry_max_elapsed_time", "1m")
+	v.SetDefault("database.replica_policy", "round_robin")
+	v.SetDefault("database.replica_health_check_interval", "10s")
+	v.SetDefault("database.replica_max_failures", 3)
+	v.SetDefault("database.read_your_writes_window", "5s")
+
+	// log
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "json")
+
+	// access log
+	v.SetDefault("access_log.enabled", true)
+	v.SetDefault("access_log.success_sample_rate", 1)
+
+	// rate limit
+	v.SetDefault("rate_limit.enabled", true)
+
+	// lockout
+	v.SetDefault("lockout.enabled", true)
+	v.SetDefault("lockout.threshold", 5)
+	v.SetDefault("lockout.duration", "1m")
+	v.SetDefault("lockout.max_duration", "1h")
+
+	// password, default argon2id parameter from owasp password storage cheat sheet
+	v.SetDefault("password.algorithm", "argon2id")
+	v.SetDefault("password.argon2id.memory", 19456)
+	v.SetDefault("password.argon2id.iterations", 2)
+	v.SetDefault("password.argon2id.parallelism", 1)
+	v.SetDefault("password.argon2id.salt_length", 16)
+	v.SetDefault("password.argon2id.key_length", 32)
+	v.SetDefault("password.bcrypt.cost", 12)
+	v.SetDefault("password.policy.min_length", 8)
+	v.SetDefault("password.policy.max_length", 72)
+	v.SetDefault("password.policy.require_upper", true)
+	v.SetDefault("password.policy.require_lower", true)
+	v.SetDefault("password.policy.require_digit", true)
+	v.SetDefault("password.policy.disallow_identity", true)
+
+	// mail
+	v.SetDefault("mail.driver", "log")
+	v.SetDefault("mail.port", 587)
+
+	// password reset
+	v.SetDefault("password_reset.token_ttl", "30m")
+
+	// email verification
+	v.SetDefault("email_verification.token_ttl", "24h")
+
+	// two factor
+	v.SetDefault("two_factor.issuer", "cobaMetrics")
+	v.SetDefault("two_factor.skew", 1)
+	v.SetDefault("two_factor.pending_ttl", "5m")
+	v.SetDefault("two_factor.recovery_codes", 10)
+	v.SetDefault("two_factor.max_attempts", 5)
+
+	// session
+	v.SetDefault("session.touch_interval", "1m")
+
+	// api key
+	v.SetDefault("api_key.touch_interval", "1m")
+
+	// oauth
+	v.SetDefault("oauth.token_ttl", "1h")
+
+	// pagination
+	v.SetDefault("pagination.default_limit", 20)
+	v.SetDefault("pagination.max_limit", 100)
+
+	// account transfer
+	v.SetDefault("account_transfer.import_batch_size", 500)
+	v.SetDefault("account_transfer.import_max_rows", 10000)
+	v.SetDefault("account_transfer.import_max_bytes", 16*1024*1024)
+	v.SetDefault("account_transfer.hash_workers", 4)
+	v.SetDefault("account_transfer.export_batch_size", 1000)
+}
+
 func (c *ConfigApp) Config() *ConfigApp {
 	return c
 }
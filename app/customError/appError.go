@@ -0,0 +1,154 @@
+package customError
+
+import (
+	"cobaMetrics/app/i18n"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"strings"
+)
+
+// AppError is error that know how to be shown to client.
+// Message is safe to show, Cause is internal error that only logged.
+// MessageKey is key of Message in i18n catalog, empty when Message can not be localized
+type AppError struct {
+	Code       string `json:"code"`
+	HttpStatus int    `json:"status_code"`
+	Message    string `json:"message"`
+	MessageKey string `json:"-"`
+	Cause      error  `json:"-"`
+	Details    any    `json:"details,omitempty"`
+}
+
+func NewAppError(code string, httpStatus int, message string) *AppError {
+	return &AppError{
+		Code:       code,
+		HttpStatus: httpStatus,
+		Message:    message,
+	}
+}
+
+func (a *AppError) Error() string {
+	return a.Message
+}
+
+func (a *AppError) Unwrap() error {
+	return a.Cause
+}
+
+// Is make errors.Is(err, target) true when both has same code
+func (a *AppError) Is(target error) bool {
+	appError, ok := target.(*AppError)
+	return ok && appError.Code == a.Code
+}
+
+// Wrap return copy of error with internal cause
+func (a *AppError) Wrap(cause error) *AppError {
+	wrapped := *a
+	wrapped.Cause = cause
+	return &wrapped
+}
+
+// WithDetails return copy of error with details shown to client
+func (a *AppError) WithDetails(details any) *AppError {
+	detailed := *a
+	detailed.Details = details
+	return &detailed
+}
+
+// FromError convert any error into AppError.
+// unknown error become internal server error and the original error kept as cause, not shown to client
+func FromError(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+
+	var appError *AppError
+	if errors.As(err, &appError) {
+		return appError
+	}
+
+	var badRequestError *BadRequestError
+	if errors.As(err, &badRequestError) {
+		return withKey(NewAppError(codeOrDefault(badRequestError.Code, CodeBadRequest), http.StatusBadRequest, badRequestError.Error()), badRequestError.Key).Wrap(err)
+	}
+
+	var notFoundError *NotFoundError
+	if errors.As(err, &notFoundError) {
+		return withKey(NewAppError(codeOrDefault(notFoundError.Code, CodeNotFound), http.StatusNotFound, notFoundError.Error()), notFoundError.Key).Wrap(err)
+	}
+
+	var internalServerError *InternalServerError
+	if errors.As(err, &internalServerError) {
+		return withKey(NewAppError(codeOrDefault(internalServerError.Code, CodeInternal), http.StatusInternalServerError, internalServerError.Error()), internalServerError.Key).Wrap(err)
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		details := ValidationDetails{Errors: FieldErrors(validationErrors, i18n.DefaultLocale)}
+		return newKeyedAppError(CodeValidation, http.StatusBadRequest).WithDetails(&details).Wrap(err)
+	}
+
+	// error from fiber, like route not found or body too large
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		return NewAppError(codeFromStatus(fiberError.Code), fiberError.Code, fiberError.Message).Wrap(err)
+	}
+
+	return newKeyedAppError(CodeInternal, http.StatusInternalServerError).Wrap(err)
+}
+
+// Localize return copy of error with message and field errors in locale
+func (a *AppError) Localize(locale string) *AppError {
+	localized := *a
+	if a.MessageKey != "" {
+		localized.Message = i18n.Translate(locale, a.MessageKey)
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(a.Cause, &validationErrors) {
+		localized.Details = &ValidationDetails{Errors: FieldErrors(validationErrors, locale)}
+	}
+
+	return &localized
+}
+
+// newKeyedAppError create AppError with message of code in default locale
+func newKeyedAppError(code string, httpStatus int) *AppError {
+	return withKey(NewAppError(code, httpStatus, i18n.Translate(i18n.DefaultLocale, code)), code)
+}
+
+func withKey(appError *AppError, key string) *AppError {
+	appError.MessageKey = key
+	return appError
+}
+
+func codeOrDefault(code string, defaultCode string) string {
+	if code == "" {
+		return defaultCode
+	}
+
+	return code
+}
+
+func codeFromStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	}
+
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+
+	return strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
@@ -4,12 +4,15 @@ import "net/http"
 
 type BadRequestError struct {
 	StatusCode int    `json:"status_code"`
+	Code       string `json:"code"`
 	S          string `json:"error"`
+	Key        string `json:"-"`
 }
 
 func NewBadRequestError(s string) error {
 	return &BadRequestError{
 		StatusCode: http.StatusBadRequest,
+		Code:       CodeBadRequest,
 		S:          s,
 	}
 }
@@ -0,0 +1,202 @@
+package customError
+
+import (
+	"cobaMetrics/app/i18n"
+	"net/http"
+	"strings"
+)
+
+// error code is part of api contract, client depend on it. never rename existing code
+const (
+	// generic
+	CodeBadRequest   = "BAD_REQUEST"
+	CodeValidation   = "VALIDATION_FAILED"
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+	CodeNotFound     = "NOT_FOUND"
+	CodeConflict     = "CONFLICT"
+	CodeInternal     = "INTERNAL_ERROR"
+	CodeRateLimited  = "RATE_LIMITED"
+
+	// request
+	CodeRequestBodyInvalid  = "REQUEST_BODY_INVALID"
+	CodeRequestBodyTooLarge = "REQUEST_BODY_TOO_LARGE"
+	CodeRequestQueryInvalid = "REQUEST_QUERY_INVALID"
+
+	// auth
+	CodeAuthTokenRequired = "AUTH_TOKEN_REQUIRED"
+	CodeAuthTokenInvalid  = "AUTH_TOKEN_INVALID"
+	CodeAuthTokenRevoked  = "AUTH_TOKEN_REVOKED"
+
+	// account
+	CodeAccountEmailTaken       = "ACCOUNT_EMAIL_TAKEN"
+	CodeAccountNotFound         = "ACCOUNT_NOT_FOUND"
+	CodeAccountPasswordMismatch = "ACCOUNT_PASSWORD_MISMATCH"
+	CodeAccountInsertFailed     = "ACCOUNT_INSERT_FAILED"
+	CodeAccountUpdateFailed     = "ACCOUNT_UPDATE_FAILED"
+	CodeAccountLocked           = "ACCOUNT_LOCKED"
+	CodeAccountInvalidLogin     = "ACCOUNT_INVALID_LOGIN"
+	CodeAccountNotVerified      = "ACCOUNT_NOT_VERIFIED"
+	CodeAccountUsernameTaken    = "ACCOUNT_USERNAME_TAKEN"
+
+	// bulk import
+	CodeImportFormatUnsupported = "IMPORT_FORMAT_UNSUPPORTED"
+	CodeImportRowInvalid        = "IMPORT_ROW_INVALID"
+	CodeImportRowDuplicate      = "IMPORT_ROW_DUPLICATE"
+
+	// password
+	CodePasswordResetTokenInvalid = "PASSWORD_RESET_TOKEN_INVALID"
+
+	// email verification
+	CodeVerificationTokenInvalid = "VERIFICATION_TOKEN_INVALID"
+
+	// two factor
+	CodeTwoFactorCodeInvalid    = "TWO_FACTOR_CODE_INVALID"
+	CodeTwoFactorAlreadyEnabled = "TWO_FACTOR_ALREADY_ENABLED"
+	CodeTwoFactorNotEnabled     = "TWO_FACTOR_NOT_ENABLED"
+	CodeTwoFactorNotEnrolled    = "TWO_FACTOR_NOT_ENROLLED"
+
+	// session
+	CodeSessionNotFound = "SESSION_NOT_FOUND"
+
+	// api key
+	CodeApiKeyInvalid      = "API_KEY_INVALID"
+	CodeApiKeyNotFound     = "API_KEY_NOT_FOUND"
+	CodeApiKeyScopeMissing = "API_KEY_SCOPE_MISSING"
+	CodeApiKeyNotAllowed   = "API_KEY_NOT_ALLOWED"
+
+	// oauth, code after prefix OAUTH_ is error code of RFC 6749 section 5.2
+	CodeOAuthInvalidRequest       = "OAUTH_INVALID_REQUEST"
+	CodeOAuthInvalidClient        = "OAUTH_INVALID_CLIENT"
+	CodeOAuthUnsupportedGrantType = "OAUTH_UNSUPPORTED_GRANT_TYPE"
+	CodeOAuthInvalidScope         = "OAUTH_INVALID_SCOPE"
+	CodeOAuthClientNotFound       = "OAUTH_CLIENT_NOT_FOUND"
+)
+
+// ProblemTypeBase is prefix of problem type uri
+const ProblemTypeBase = "/problems/"
+
+type Definition struct {
+	Code       string
+	HttpStatus int
+	Title      string
+}
+
+var catalog = map[string]Definition{
+	CodeBadRequest:   {CodeBadRequest, http.StatusBadRequest, "Bad request"},
+	CodeValidation:   {CodeValidation, http.StatusBadRequest, "Validation failed"},
+	CodeUnauthorized: {CodeUnauthorized, http.StatusUnauthorized, "Unauthorized"},
+	CodeForbidden:    {CodeForbidden, http.StatusForbidden, "Forbidden"},
+	CodeNotFound:     {CodeNotFound, http.StatusNotFound, "Not found"},
+	CodeConflict:     {CodeConflict, http.StatusConflict, "Conflict"},
+	CodeInternal:     {CodeInternal, http.StatusInternalServerError, "Internal server error"},
+	CodeRateLimited:  {CodeRateLimited, http.StatusTooManyRequests, "Too many requests"},
+
+	CodeRequestBodyInvalid:  {CodeRequestBodyInvalid, http.StatusBadRequest, "Invalid request body"},
+	CodeRequestBodyTooLarge: {CodeRequestBodyTooLarge, http.StatusRequestEntityTooLarge, "Request body too large"},
+	CodeRequestQueryInvalid: {CodeRequestQueryInvalid, http.StatusBadRequest, "Invalid query parameter"},
+
+	CodeAuthTokenRequired: {CodeAuthTokenRequired, http.StatusUnauthorized, "Token required"},
+	CodeAuthTokenInvalid:  {CodeAuthTokenInvalid, http.StatusUnauthorized, "Token not valid"},
+	CodeAuthTokenRevoked:  {CodeAuthTokenRevoked, http.StatusUnauthorized, "Token revoked"},
+
+	CodeAccountEmailTaken:       {CodeAccountEmailTaken, http.StatusBadRequest, "Email already taken"},
+	CodeAccountNotFound:         {CodeAccountNotFound, http.StatusNotFound, "Account not found"},
+	CodeAccountPasswordMismatch: {CodeAccountPasswordMismatch, http.StatusBadRequest, "Password not match"},
+	CodeAccountInsertFailed:     {CodeAccountInsertFailed, http.StatusInternalServerError, "Failed to create account"},
+	CodeAccountUpdateFailed:     {CodeAccountUpdateFailed, http.StatusInternalServerError, "Failed to update account"},
+	CodeAccountLocked:           {CodeAccountLocked, http.StatusLocked, "Account locked"},
+	CodeAccountInvalidLogin:     {CodeAccountInvalidLogin, http.StatusUnauthorized, "Invalid email or password"},
+	CodeAccountNotVerified:      {CodeAccountNotVerified, http.StatusForbidden, "Email not verified"},
+	CodeAccountUsernameTaken:    {CodeAccountUsernameTaken, http.StatusBadRequest, "Username already taken"},
+
+	CodeImportFormatUnsupported: {CodeImportFormatUnsupported, http.StatusUnsupportedMediaType, "Unsupported import format"},
+	CodeImportRowInvalid:        {CodeImportRowInvalid, http.StatusBadRequest, "Import row not readable"},
+	CodeImportRowDuplicate:      {CodeImportRowDuplicate, http.StatusBadRequest, "Duplicate import row"},
+
+	CodePasswordResetTokenInvalid: {CodePasswordResetTokenInvalid, http.StatusBadRequest, "Invalid reset token"},
+
+	CodeVerificationTokenInvalid: {CodeVerificationTokenInvalid, http.StatusBadRequest, "Invalid verification token"},
+
+	CodeTwoFactorCodeInvalid:    {CodeTwoFactorCodeInvalid, http.StatusUnauthorized, "Invalid two factor code"},
+	CodeTwoFactorAlreadyEnabled: {CodeTwoFactorAlreadyEnabled, http.StatusConflict, "Two factor already enabled"},
+	CodeTwoFactorNotEnabled:     {CodeTwoFactorNotEnabled, http.StatusBadRequest, "Two factor not enabled"},
+	CodeTwoFactorNotEnrolled:    {CodeTwoFactorNotEnrolled, http.StatusBadRequest, "Two factor not enrolled"},
+
+	CodeSessionNotFound: {CodeSessionNotFound, http.StatusNotFound, "Session not found"},
+
+	CodeApiKeyInvalid:      {CodeApiKeyInvalid, http.StatusUnauthorized, "API key not valid"},
+	CodeApiKeyNotFound:     {CodeApiKeyNotFound, http.StatusNotFound, "API key not found"},
+	CodeApiKeyScopeMissing: {CodeApiKeyScopeMissing, http.StatusForbidden, "API key scope missing"},
+	CodeApiKeyNotAllowed:   {CodeApiKeyNotAllowed, http.StatusForbidden, "API key not allowed"},
+
+	CodeOAuthInvalidRequest:       {CodeOAuthInvalidRequest, http.StatusBadRequest, "Invalid OAuth request"},
+	CodeOAuthInvalidClient:        {CodeOAuthInvalidClient, http.StatusUnauthorized, "Invalid OAuth client"},
+	CodeOAuthUnsupportedGrantType: {CodeOAuthUnsupportedGrantType, http.StatusBadRequest, "Unsupported grant type"},
+	CodeOAuthInvalidScope:         {CodeOAuthInvalidScope, http.StatusBadRequest, "Invalid scope"},
+	CodeOAuthClientNotFound:       {CodeOAuthClientNotFound, http.StatusNotFound, "OAuth client not found"},
+}
+
+// Lookup return definition of code, false when code not in catalog
+func Lookup(code string) (Definition, bool) {
+	definition, ok := catalog[code]
+	return definition, ok
+}
+
+// Codes return every code in catalog
+func Codes() []string {
+	codes := make([]string, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+
+	return codes
+}
+
+// New create error of code with message of code in default locale, localized later by ErrorHandler
+func New(code string) error {
+	return NewWithKey(code, code)
+}
+
+// NewWithKey create error of code with message of key, used when one code has more than one message
+func NewWithKey(code string, key string) error {
+	return newError(code, key, i18n.Translate(i18n.DefaultLocale, key))
+}
+
+// NewWithMessage create error of code with custom message that not localized, like message from parser
+func NewWithMessage(code string, message string) error {
+	return newError(code, "", message)
+}
+
+// error with status 400, 404 and 500 use the old error type, so existing type check still work
+func newError(code string, key string, message string) error {
+	definition, ok := catalog[code]
+	if !ok {
+		definition = catalog[CodeInternal]
+	}
+
+	switch definition.HttpStatus {
+	case http.StatusBadRequest:
+		return &BadRequestError{StatusCode: definition.HttpStatus, Code: code, S: message, Key: key}
+	case http.StatusNotFound:
+		return &NotFoundError{StatusCode: definition.HttpStatus, Code: code, S: message, Key: key}
+	case http.StatusInternalServerError:
+		return &InternalServerError{StatusCode: definition.HttpStatus, Code: code, S: message, Key: key}
+	default:
+		return withKey(NewAppError(code, definition.HttpStatus, message), key)
+	}
+}
+
+// ProblemType return uri of problem type of code, used in problem+json response
+func ProblemType(code string) string {
+	return ProblemTypeBase + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
+
+// Title return short human readable summary of code
+func Title(code string, httpStatus int) string {
+	if definition, ok := catalog[code]; ok {
+		return definition.Title
+	}
+
+	return http.StatusText(httpStatus)
+}
@@ -4,12 +4,15 @@ import "net/http"
 
 type InternalServerError struct {
 	StatusCode int    `json:"status_code"`
+	Code       string `json:"code"`
 	S          string `json:"error"`
+	Key        string `json:"-"`
 }
 
 func NewInternalServerError(s string) error {
 	return &InternalServerError{
 		StatusCode: http.StatusInternalServerError,
+		Code:       CodeInternal,
 		S:          s,
 	}
 }
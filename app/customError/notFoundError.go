@@ -4,12 +4,15 @@ import "net/http"
 
 type NotFoundError struct {
 	StatusCode int    `json:"status_code"`
+	Code       string `json:"code"`
 	S          string `json:"error"`
+	Key        string `json:"-"`
 }
 
 func NewNotFoundError(s string) error {
 	return &NotFoundError{
 		StatusCode: http.StatusNotFound,
+		Code:       CodeNotFound,
 		S:          s,
 	}
 }
@@ -0,0 +1,36 @@
+package customError
+
+import (
+	"cobaMetrics/app/i18n"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is one failed validation rule of one field, shown to client under data.errors
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+// ValidationDetails is details of validation error, same shape for every endpoint
+type ValidationDetails struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// FieldErrors convert validator errors into list of FieldError with message in locale
+func FieldErrors(validationErrors validator.ValidationErrors, locale string) []FieldError {
+	translator := i18n.Translator(locale)
+
+	fieldErrors := make([]FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   fieldError.Field(),
+			Rule:    fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Message: fieldError.Translate(translator),
+		})
+	}
+
+	return fieldErrors
+}
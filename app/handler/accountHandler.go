@@ -3,18 +3,16 @@ package handler
 import (
 	"cobaMetrics/app/customError"
 	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
 	"cobaMetrics/app/model/dto"
 	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
 	"encoding/json"
-	"fmt"
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 type AccountHandler struct {
@@ -28,28 +26,15 @@ func NewAccountHandler(accService IService.IAccountService) *AccountHandler {
 // handler insert
 func (a *AccountHandler) Add(ctx *fiber.Ctx) error {
 	// start span tracing
-	span, ctxTracing := opentracing.StartSpanFromContext(ctx.Context(), "AccountHandler Add")
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "AccountHandler Add")
 	defer span.Finish()
 
 	// decode request body
 	var request dto.AddUserRequest
 	if err := ctx.BodyParser(&request); err != nil {
 		ext.Error.Set(span, true)
-
-		statusCode := http.StatusBadRequest
-		ctx.Status(statusCode)
-		response := dto.ApiResponse{
-			StatusCode: statusCode,
-			Status:     helper.CodeToStatus(statusCode),
-			Message:    err.Error(),
-		}
-
-		// log response with tracing
-		responseJson, _ := json.Marshal(&response)
-		span.LogFields(
-			log.String("response", string(responseJson)))
-
-		return ctx.JSON(&response)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
 	}
 
 	// log request with tracing
@@ -62,50 +47,8 @@ func (a *AccountHandler) Add(ctx *fiber.Ctx) error {
 	account, err := a.AccountService.Add(ctxTracing, &request)
 	if err != nil {
 		ext.Error.Set(span, true)
-
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			errMessage := []string{}
-			for _, fieldError := range validationErrors {
-				errMessage = append(errMessage, fieldError.Error())
-			}
-
-			statusCode := http.StatusBadRequest
-			ctx.Status(statusCode)
-			response := dto.ApiResponse{
-				StatusCode: statusCode,
-				Status:     helper.CodeToStatus(statusCode),
-				Message:    strings.Join(errMessage, ". "),
-			}
-
-			// log with tracing
-			responseJson, _ := json.Marshal(&response)
-			span.LogFields(log.String("response", string(responseJson)))
-			return ctx.JSON(&response)
-		}
-
-		var statusCode int
-		switch err.(type) {
-		case *customError.InternalServerError:
-			statusCode = http.StatusInternalServerError
-		case *customError.BadRequestError:
-			statusCode = http.StatusBadRequest
-		case *customError.NotFoundError:
-			statusCode = http.StatusNotFound
-		}
-
-		ctx.Status(statusCode)
-
-		response := dto.ApiResponse{
-			StatusCode: statusCode,
-			Status:     helper.CodeToStatus(statusCode),
-			Message:    err.Error(),
-		}
-
-		// log response with tracing
-		responseJson, _ := json.Marshal(&response)
-		span.LogFields(log.String("response", string(responseJson)))
-
-		return ctx.JSON(&response)
+		span.LogFields(log.String("response", err.Error()))
+		return err
 	}
 
 	// sucess
@@ -113,7 +56,7 @@ func (a *AccountHandler) Add(ctx *fiber.Ctx) error {
 	response := dto.ApiResponse{
 		StatusCode: statusCode,
 		Status:     helper.CodeToStatus(statusCode),
-		Message:    "success add new account",
+		Message:    helper.Message(ctx, i18n.MessageAccountAdded),
 		Data:       account,
 	}
 
@@ -127,7 +70,7 @@ func (a *AccountHandler) Add(ctx *fiber.Ctx) error {
 // handler get data accounts by email
 func (a *AccountHandler) GetByEmail(ctx *fiber.Ctx) error {
 	// start span tracing
-	span, ctxTracing := opentracing.StartSpanFromContext(ctx.Context(), "AccountHandler GetByEmail")
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "AccountHandler GetByEmail")
 	defer span.Finish()
 
 	// get from params
@@ -137,47 +80,8 @@ func (a *AccountHandler) GetByEmail(ctx *fiber.Ctx) error {
 	account, err := a.AccountService.GetByEmail(ctxTracing, email)
 	if err != nil {
 		ext.Error.Set(span, true)
-
-		// error validation
-		if _, ok := err.(validator.ValidationErrors); ok {
-			statusCode := http.StatusBadRequest
-			response := dto.ApiResponse{
-				StatusCode: statusCode,
-				Status:     helper.CodeToStatus(statusCode),
-				Message:    err.Error(),
-			}
-
-			// log with tracing
-			resJson, _ := json.Marshal(&response)
-			span.LogFields(log.String("response", string(resJson)))
-
-			ctx.Status(statusCode)
-			return ctx.JSON(&response)
-		}
-
-		var statusCode int
-		switch err.(type) {
-		case *customError.BadRequestError:
-			statusCode = http.StatusBadRequest
-		case *customError.NotFoundError:
-			statusCode = http.StatusNotFound
-		case *customError.InternalServerError:
-			statusCode = http.StatusInternalServerError
-		}
-
-		// create response object
-		response := dto.ApiResponse{
-			StatusCode: statusCode,
-			Status:     helper.CodeToStatus(statusCode),
-			Message:    err.Error(),
-		}
-
-		// log with tracing
-		responseJson, _ := json.Marshal(&response)
-		span.LogFields(log.String("response", string(responseJson)))
-
-		ctx.Status(statusCode)
-		return ctx.JSON(&response)
+		span.LogFields(log.String("response", err.Error()))
+		return err
 	}
 
 	// success
@@ -187,7 +91,7 @@ func (a *AccountHandler) GetByEmail(ctx *fiber.Ctx) error {
 	response := dto.ApiResponse{
 		StatusCode: statusCode,
 		Status:     helper.CodeToStatus(statusCode),
-		Message:    "success get data account",
+		Message:    helper.Message(ctx, i18n.MessageAccountFound),
 		Data:       account,
 	}
 
@@ -202,28 +106,19 @@ func (a *AccountHandler) GetByEmail(ctx *fiber.Ctx) error {
 // handler login
 func (a *AccountHandler) Login(ctx *fiber.Ctx) error {
 	// start span tracing
-	span, ctxTracing := opentracing.StartSpanFromContext(ctx.Context(), "AccountHandler Login")
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "AccountHandler Login")
 	defer span.Finish()
 
 	// decode request_body
 	var request dto.LoginRequest
 	if err := ctx.BodyParser(&request); err != nil {
-		statusCode := http.StatusBadRequest
-		ctx.Status(statusCode)
-		response := dto.ApiResponse{
-			StatusCode: statusCode,
-			Status:     helper.CodeToStatus(statusCode),
-			Message:    err.Error(),
-		}
-
-		// log with tracing
 		ext.Error.Set(span, true)
-		responseJson, _ := json.Marshal(&response)
-		span.LogFields(log.String("response", string(responseJson)))
-
-		return ctx.JSON(&response)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
 	}
 
+	request.Client = sessionClient(ctx)
+
 	// log request with tracing
 	requestJson, _ := json.Marshal(&request)
 	span.LogFields(log.String("request", string(requestJson)))
@@ -232,60 +127,22 @@ func (a *AccountHandler) Login(ctx *fiber.Ctx) error {
 	login, err := a.AccountService.Login(ctxTracing, &request)
 	if err != nil {
 		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
 
-		// error validation
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			var errMessage []string
-			for _, errorField := range validationErrors {
-				errMessage = append(errMessage, fmt.Sprintf("error on field [%v] with tag [%v]", errorField.Field(), errorField.Tag()))
-			}
-
-			statusCode := http.StatusBadRequest
-			ctx.Status(statusCode)
-			response := dto.ApiResponse{
-				StatusCode: statusCode,
-				Status:     helper.CodeToStatus(statusCode),
-				Message:    strings.Join(errMessage, ". "),
-			}
-
-			// log with tracing
-			responseJson, _ := json.Marshal(&response)
-			span.LogFields(log.String("response", string(responseJson)))
-
-			return ctx.JSON(&response)
-		}
-
-		var statusCode int
-		switch err.(type) {
-		case *customError.BadRequestError:
-			statusCode = http.StatusBadRequest
-		case *customError.NotFoundError:
-			statusCode = http.StatusNotFound
-		case *customError.InternalServerError:
-			statusCode = http.StatusInternalServerError
-		}
-
-		ctx.Status(statusCode)
-		response := dto.ApiResponse{
-			StatusCode: statusCode,
-			Status:     helper.CodeToStatus(statusCode),
-			Message:    err.Error(),
-		}
-
-		// log with tracing
-		responseJson, _ := json.Marshal(&response)
-		span.LogFields(log.String("response", string(responseJson)))
-
-		return ctx.JSON(&response)
+	// success login, or password valid but still need two factor code
+	message := i18n.MessageAccountLogin
+	if login.MfaRequired {
+		message = i18n.MessageTwoFactorRequired
 	}
 
-	// success login
 	statusCode := http.StatusOK
 	ctx.Status(statusCode)
 	response := dto.ApiResponse{
 		StatusCode: statusCode,
 		Status:     helper.CodeToStatus(statusCode),
-		Message:    "success login",
+		Message:    helper.Message(ctx, message),
 		Data:       login,
 	}
 
@@ -299,79 +156,38 @@ func (a *AccountHandler) Login(ctx *fiber.Ctx) error {
 // handler GetAll accounts
 func (a *AccountHandler) GetAll(ctx *fiber.Ctx) error {
 	// start tracing
-	span, ctxTracing := opentracing.StartSpanFromContext(ctx.Context(), "AccountHandler GetAll")
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "AccountHandler GetAll")
 	defer span.Finish()
 
-	// get query page and limit
-	pageString := ctx.Query("page", "1")
-	limitString := ctx.Query("limit", "10")
-
-	page, err := strconv.Atoi(pageString)
-	if err != nil {
-		ext.Error.Set(span, true)
-		statusCode := http.StatusBadRequest
-		response := dto.ApiResponse{
-			StatusCode: statusCode,
-			Status:     helper.CodeToStatus(statusCode),
-			Message:    "query page must be numeric",
-		}
-
-		resJson, _ := json.Marshal(&response)
-		span.LogFields(log.String("response", string(resJson)))
-
-		ctx.Status(statusCode)
-		return ctx.JSON(&response)
+	// get query of list, limit 0 mean default limit
+	request := dto.GetAllAccountRequest{
+		Cursor:       ctx.Query("cursor"),
+		Sort:         ctx.Query("sort"),
+		EmailPrefix:  ctx.Query("email_prefix"),
+		CreatedFrom:  ctx.Query("created_from"),
+		CreatedTo:    ctx.Query("created_to"),
+		IncludeTotal: ctx.QueryBool("include_total"),
 	}
 
-	limit, err := strconv.Atoi(limitString)
-	if err != nil {
-		ext.Error.Set(span, true)
-		statusCode := http.StatusBadRequest
-		response := dto.ApiResponse{
-			StatusCode: statusCode,
-			Status:     helper.CodeToStatus(statusCode),
-			Message:    "query limit must be numeric",
+	if limitString := ctx.Query("limit"); limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil {
+			ext.Error.Set(span, true)
+			return customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryLimitNumeric)
 		}
-
-		resJson, _ := json.Marshal(&response)
-		span.LogFields(log.String("response", string(resJson)))
-
-		ctx.Status(statusCode)
-		return ctx.JSON(&response)
+		request.Limit = limit
 	}
 
 	// log request with span
-	req := map[string]any{
-		"page":  page,
-		"limit": limit,
-	}
-	reqJson, _ := json.Marshal(&req)
+	reqJson, _ := json.Marshal(&request)
 	span.LogFields(log.String("request", string(reqJson)))
 
 	// call procedure GetAll in service
-	accounts, err := a.AccountService.GetAll(ctxTracing, limit, page)
+	page, err := a.AccountService.GetAll(ctxTracing, &request)
 	if err != nil {
-		var statusCode int
-		switch err.(type) {
-		case *customError.NotFoundError:
-			statusCode = http.StatusNotFound
-		case *customError.BadRequestError:
-			statusCode = http.StatusBadRequest
-		default:
-			statusCode = http.StatusInternalServerError
-		}
-
 		ext.Error.Set(span, true)
-		response := dto.ApiResponse{
-			StatusCode: statusCode,
-			Status:     helper.CodeToStatus(statusCode),
-			Message:    err.Error(),
-		}
-		resJson, _ := json.Marshal(&response)
-		span.LogFields(log.String("response", string(resJson)))
-
-		ctx.Status(statusCode)
-		return ctx.JSON(&response)
+		span.LogFields(log.String("response", err.Error()))
+		return err
 	}
 
 	// success get data accounts
@@ -379,12 +195,8 @@ func (a *AccountHandler) GetAll(ctx *fiber.Ctx) error {
 	response := dto.ApiResponse{
 		StatusCode: statusCode,
 		Status:     helper.CodeToStatus(statusCode),
-		Message:    "success get data",
-		Data: map[string]any{
-			"page":  page,
-			"count": len(accounts),
-			"data":  accounts,
-		},
+		Message:    helper.Message(ctx, i18n.MessageAccountListed),
+		Data:       page,
 	}
 
 	resJson, _ := json.Marshal(&response)
@@ -393,3 +205,38 @@ func (a *AccountHandler) GetAll(ctx *fiber.Ctx) error {
 	ctx.Status(statusCode)
 	return ctx.JSON(&response)
 }
+
+// handler unlock account, only for admin
+func (a *AccountHandler) Unlock(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "AccountHandler Unlock")
+	defer span.Finish()
+
+	// decode request body
+	var request dto.UnlockAccountRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	span.LogFields(log.String("email", request.Email))
+
+	// call procedure in service
+	if err := a.AccountService.Unlock(ctxTracing, &request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageAccountUnlocked),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
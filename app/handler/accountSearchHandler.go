@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"net/http"
+	"strconv"
+)
+
+// AccountSearchHandler serve search account at /accounts/search, admin only
+type AccountSearchHandler struct {
+	AccountSearchService IService.IAccountSearchService
+}
+
+func NewAccountSearchHandler(accountSearchService IService.IAccountSearchService) *AccountSearchHandler {
+	return &AccountSearchHandler{accountSearchService}
+}
+
+// handler search account by part of username or email
+func (a *AccountSearchHandler) Search(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "AccountSearchHandler Search")
+	defer span.Finish()
+
+	request := dto.SearchAccountRequest{
+		Q:      ctx.Query("q"),
+		Cursor: ctx.Query("cursor"),
+	}
+
+	if limitString := ctx.Query("limit"); limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil {
+			ext.Error.Set(span, true)
+			return customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryLimitNumeric)
+		}
+		request.Limit = limit
+	}
+
+	// call procedure in service
+	page, err := a.AccountSearchService.Search(ctxTracing, &request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageAccountSearched),
+		Data:       page,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/model/dto"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// content type of export
+const (
+	contentTypeCSV    = "text/csv; charset=utf-8"
+	contentTypeNDJSON = "application/x-ndjson"
+)
+
+// AccountTransferHandler serve bulk import and export of account at /accounts/import and /accounts/export, admin only
+type AccountTransferHandler struct {
+	AccountTransferService IService.IAccountTransferService
+}
+
+func NewAccountTransferHandler(accountTransferService IService.IAccountTransferService) *AccountTransferHandler {
+	return &AccountTransferHandler{accountTransferService}
+}
+
+// handler import account from csv or ndjson body, format from query format or Content-Type
+func (a *AccountTransferHandler) Import(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "AccountTransferHandler Import")
+	defer span.Finish()
+
+	format := ctx.Query("format")
+	if format == "" {
+		format = importFormat(ctx.Get(fiber.HeaderContentType))
+	}
+
+	// body streamed by server and limited by service, in memory only when request not streamed like in test
+	var body io.Reader = ctx.Request().BodyStream()
+	if body == nil {
+		body = bytes.NewReader(ctx.Body())
+	}
+
+	request := dto.ImportAccountRequest{
+		Format: format,
+		DryRun: ctx.QueryBool("dry_run"),
+		Body:   body,
+	}
+
+	// call procedure in service
+	report, err := a.AccountTransferService.Import(ctxTracing, &request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	message := i18n.MessageAccountImported
+	if report.DryRun {
+		message = i18n.MessageAccountChecked
+	}
+
+	// success, row that failed reported in data.rows
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, message),
+		Data:       report,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler export every account as csv or ndjson, response streamed while account read from database
+func (a *AccountTransferHandler) Export(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, _ := tracing.StartSpanFromRequest(ctx, "AccountTransferHandler Export")
+	defer span.Finish()
+
+	format := ctx.Query("format", dto.FormatCSV)
+
+	var contentType string
+	switch format {
+	case dto.FormatCSV:
+		contentType = contentTypeCSV
+	case dto.FormatNDJSON:
+		contentType = contentTypeNDJSON
+	default:
+		ext.Error.Set(span, true)
+		return customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryFormat)
+	}
+
+	ctx.Attachment("accounts." + format)
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Status(http.StatusOK)
+
+	// writer run after handler returned and request span finished, so it get own span and context not tied to request.
+	// status already sent when writer run, error in the middle logged by service and end the body early
+	exportCtx := logging.DetachedContext(ctx.Context())
+	parent := span.Context()
+	ctx.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		span := opentracing.StartSpan("AccountTransferHandler Export stream", opentracing.FollowsFrom(parent))
+		defer span.Finish()
+
+		if err := a.AccountTransferService.Export(opentracing.ContextWithSpan(exportCtx, span), format, writer); err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(log.String("response", err.Error()))
+		}
+	})
+
+	return nil
+}
+
+// importFormat return format of Content-Type, empty when not csv or ndjson
+func importFormat(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case "text/csv":
+		return dto.FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return dto.FormatNDJSON
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"net/http"
+)
+
+// ApiKeyHandler serve api key of account at /api-keys and api key of service at /admin/api-keys
+type ApiKeyHandler struct {
+	ApiKeyService IService.IApiKeyService
+}
+
+func NewApiKeyHandler(apiKeyService IService.IApiKeyService) *ApiKeyHandler {
+	return &ApiKeyHandler{apiKeyService}
+}
+
+// handler create api key of account of token
+func (a *ApiKeyHandler) Create(ctx *fiber.Ctx) error {
+	accountId, err := claimsAccountId(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.create(ctx, &accountId)
+}
+
+// handler get api key of account of token
+func (a *ApiKeyHandler) GetAll(ctx *fiber.Ctx) error {
+	accountId, err := claimsAccountId(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.getAll(ctx, &accountId)
+}
+
+// handler revoke api key of account of token
+func (a *ApiKeyHandler) Revoke(ctx *fiber.Ctx) error {
+	accountId, err := claimsAccountId(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.revoke(ctx, &accountId)
+}
+
+// handler create api key of service, admin only
+func (a *ApiKeyHandler) CreateService(ctx *fiber.Ctx) error {
+	return a.create(ctx, nil)
+}
+
+// handler get api key of every service, admin only
+func (a *ApiKeyHandler) GetAllService(ctx *fiber.Ctx) error {
+	return a.getAll(ctx, nil)
+}
+
+// handler revoke api key of service, admin only
+func (a *ApiKeyHandler) RevokeService(ctx *fiber.Ctx) error {
+	return a.revoke(ctx, nil)
+}
+
+// accountId nil mean api key owned by service named in request body
+func (a *ApiKeyHandler) create(ctx *fiber.Ctx, accountId *int) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "ApiKeyHandler Create")
+	defer span.Finish()
+
+	// decode request body
+	var request dto.CreateApiKeyRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	request.AccountId = accountId
+
+	// call procedure in service, api key not logged
+	apiKey, err := a.ApiKeyService.Create(ctxTracing, &request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageApiKeyCreated),
+		Data:       apiKey,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+func (a *ApiKeyHandler) getAll(ctx *fiber.Ctx, accountId *int) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "ApiKeyHandler GetAll")
+	defer span.Finish()
+
+	// call procedure in service
+	apiKeys, err := a.ApiKeyService.GetAll(ctxTracing, accountId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageApiKeyListed),
+		Data:       apiKeys,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+func (a *ApiKeyHandler) revoke(ctx *fiber.Ctx, accountId *int) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "ApiKeyHandler Revoke")
+	defer span.Finish()
+
+	id := ctx.Params("id")
+	span.LogFields(log.String("api_key_id", id))
+
+	// call procedure in service
+	if err := a.ApiKeyService.Revoke(ctxTracing, accountId, id); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageApiKeyRevoked),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
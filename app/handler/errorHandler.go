@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/model/dto"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"net/http"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ErrorHandler is fiber error handler that log with default slog logger, use NewErrorHandler to inject logger
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	return NewErrorHandler(slog.Default())(ctx, err)
+}
+
+// NewErrorHandler create fiber error handler, convert every error returned by handler into response.
+// client that ask application/problem+json get RFC 7807 body, other client get ApiResponse
+func NewErrorHandler(logger *slog.Logger) fiber.ErrorHandler {
+	return func(ctx *fiber.Ctx, err error) error {
+		appError := customError.FromError(err).Localize(helper.Locale(ctx))
+
+		// internal cause only logged, not shown to client
+		if appError.HttpStatus >= http.StatusInternalServerError {
+			logger.ErrorContext(ctx.Context(), "request failed",
+				slog.String("method", ctx.Method()),
+				slog.String("path", ctx.Path()),
+				slog.String("code", appError.Code),
+				slog.String("error", err.Error()))
+		}
+
+		ctx.Status(appError.HttpStatus)
+		ctx.Vary(fiber.HeaderAccept, fiber.HeaderAcceptLanguage)
+
+		// ApiResponse stay the default, problem+json only when client prefer it
+		if ctx.Accepts(fiber.MIMEApplicationJSON, MIMEApplicationProblemJSON) == MIMEApplicationProblemJSON {
+			problem := dto.ProblemDetails{
+				Type:     customError.ProblemType(appError.Code),
+				Title:    customError.Title(appError.Code, appError.HttpStatus),
+				Status:   appError.HttpStatus,
+				Detail:   appError.Message,
+				Instance: ctx.OriginalURL(),
+				Code:     appError.Code,
+				Details:  appError.Details,
+			}
+
+			return ctx.JSON(&problem, MIMEApplicationProblemJSON)
+		}
+
+		response := dto.ApiResponse{
+			StatusCode: appError.HttpStatus,
+			Status:     helper.CodeToStatus(appError.HttpStatus),
+			Message:    appError.Message,
+			Data:       appError.Details,
+		}
+
+		return ctx.JSON(&response)
+	}
+}
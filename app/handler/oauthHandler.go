@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
+	"encoding/base64"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// oauthCodePrefix is prefix of error code that returned in oauth error format, see customError.CodeOAuthInvalidRequest
+const oauthCodePrefix = "OAUTH_"
+
+// OAuthHandler serve token and introspection endpoint in format of RFC 6749 and RFC 7662 so standard oauth tooling work,
+// and client registration at /admin/oauth/clients in ApiResponse format
+type OAuthHandler struct {
+	OAuthService IService.IOAuthService
+}
+
+func NewOAuthHandler(oauthService IService.IOAuthService) *OAuthHandler {
+	return &OAuthHandler{oauthService}
+}
+
+// handler token endpoint, form body with grant_type client_credentials
+func (o *OAuthHandler) Token(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "OAuthHandler Token")
+	defer span.Finish()
+
+	var request dto.OAuthTokenRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return oauthError(ctx, customError.New(customError.CodeOAuthInvalidRequest))
+	}
+
+	if err := clientCredentials(ctx, &request.ClientId, &request.ClientSecret); err != nil {
+		ext.Error.Set(span, true)
+		return oauthError(ctx, err)
+	}
+
+	// call procedure in service, token not logged
+	token, err := o.OAuthService.Token(ctxTracing, &request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return oauthError(ctx, err)
+	}
+
+	// token response must not be cached, RFC 6749 section 5.1
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set(fiber.HeaderPragma, "no-cache")
+	ctx.Status(http.StatusOK)
+	return ctx.JSON(token)
+}
+
+// handler introspection endpoint, caller authenticate as registered client
+func (o *OAuthHandler) Introspect(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "OAuthHandler Introspect")
+	defer span.Finish()
+
+	var request dto.OAuthIntrospectRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return oauthError(ctx, customError.New(customError.CodeOAuthInvalidRequest))
+	}
+
+	if err := clientCredentials(ctx, &request.ClientId, &request.ClientSecret); err != nil {
+		ext.Error.Set(span, true)
+		return oauthError(ctx, err)
+	}
+
+	// call procedure in service
+	introspection, err := o.OAuthService.Introspect(ctxTracing, &request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return oauthError(ctx, err)
+	}
+
+	span.LogFields(log.Bool("active", introspection.Active))
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Status(http.StatusOK)
+	return ctx.JSON(introspection)
+}
+
+// handler register oauth client, admin only
+func (o *OAuthHandler) CreateClient(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "OAuthHandler CreateClient")
+	defer span.Finish()
+
+	// decode request body
+	var request dto.CreateOAuthClientRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	// call procedure in service, secret not logged
+	client, err := o.OAuthService.CreateClient(ctxTracing, &request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageOAuthClientCreated),
+		Data:       client,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler get registered oauth client, admin only
+func (o *OAuthHandler) GetAllClients(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "OAuthHandler GetAllClients")
+	defer span.Finish()
+
+	// call procedure in service
+	clients, err := o.OAuthService.GetAllClients(ctxTracing)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageOAuthClientListed),
+		Data:       clients,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler revoke oauth client, admin only
+func (o *OAuthHandler) RevokeClient(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "OAuthHandler RevokeClient")
+	defer span.Finish()
+
+	clientId := ctx.Params("id")
+	span.LogFields(log.String("client_id", clientId))
+
+	// call procedure in service
+	if err := o.OAuthService.RevokeClient(ctxTracing, clientId); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageOAuthClientRevoked),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// clientCredentials fill credential of client from basic authorization, RFC 6749 section 2.3.1.
+// client must use only one method, credential in both header and form body rejected
+func clientCredentials(ctx *fiber.Ctx, clientId *string, clientSecret *string) error {
+	header := ctx.Get(fiber.HeaderAuthorization)
+	if header == "" {
+		return nil
+	}
+
+	scheme, encoded, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "basic") {
+		return customError.New(customError.CodeOAuthInvalidClient)
+	}
+
+	if *clientId != "" || *clientSecret != "" {
+		return customError.New(customError.CodeOAuthInvalidRequest)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return customError.New(customError.CodeOAuthInvalidClient)
+	}
+
+	// id and secret form encoded before joined, RFC 6749 appendix B
+	id, secret, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return customError.New(customError.CodeOAuthInvalidClient)
+	}
+
+	if *clientId, err = url.QueryUnescape(id); err != nil {
+		return customError.New(customError.CodeOAuthInvalidClient)
+	}
+	if *clientSecret, err = url.QueryUnescape(secret); err != nil {
+		return customError.New(customError.CodeOAuthInvalidClient)
+	}
+
+	return nil
+}
+
+// oauthError write error of oauth code in format of RFC 6749 section 5.2, other error handled by ErrorHandler
+func oauthError(ctx *fiber.Ctx, err error) error {
+	appError := customError.FromError(err)
+	if !strings.HasPrefix(appError.Code, oauthCodePrefix) || appError.Code == customError.CodeOAuthClientNotFound {
+		return err
+	}
+
+	appError = appError.Localize(helper.Locale(ctx))
+	if appError.HttpStatus == http.StatusUnauthorized {
+		ctx.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Status(appError.HttpStatus)
+	return ctx.JSON(&dto.OAuthErrorResponse{
+		Error:            strings.ToLower(strings.TrimPrefix(appError.Code, oauthCodePrefix)),
+		ErrorDescription: appError.Message,
+	})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"net/http"
+)
+
+type PasswordHandler struct {
+	PasswordService IService.IPasswordService
+}
+
+func NewPasswordHandler(passwordService IService.IPasswordService) *PasswordHandler {
+	return &PasswordHandler{passwordService}
+}
+
+// handler forgot password, response same for registered and unknown email
+func (p *PasswordHandler) Forgot(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "PasswordHandler Forgot")
+	defer span.Finish()
+
+	// decode request body
+	var request dto.ForgotPasswordRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	span.LogFields(log.String("email", request.Email))
+
+	// call procedure in service
+	if err := p.PasswordService.Forgot(ctxTracing, &request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusAccepted
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessagePasswordForgot),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler reset password with token from email
+func (p *PasswordHandler) Reset(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "PasswordHandler Reset")
+	defer span.Finish()
+
+	// decode request body, token and password not logged
+	var request dto.ResetPasswordRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	// call procedure in service
+	if err := p.PasswordService.Reset(ctxTracing, &request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessagePasswordReset),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
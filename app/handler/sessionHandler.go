@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	jwtModel "cobaMetrics/app/model/jwt"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"net/http"
+)
+
+type SessionHandler struct {
+	SessionService IService.ISessionService
+}
+
+func NewSessionHandler(sessionService IService.ISessionService) *SessionHandler {
+	return &SessionHandler{sessionService}
+}
+
+// sessionClient return device info of request recorded in session
+func sessionClient(ctx *fiber.Ctx) dto.SessionClient {
+	return dto.SessionClient{
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+		IP:        ctx.IP(),
+	}
+}
+
+// handler get active sessions of account
+func (s *SessionHandler) GetAll(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "SessionHandler GetAll")
+	defer span.Finish()
+
+	claims, ok := ctx.Locals(middleware.ClaimsKey).(*jwtModel.Claims)
+	if !ok {
+		ext.Error.Set(span, true)
+		return customError.New(customError.CodeAuthTokenRequired)
+	}
+
+	// call procedure in service
+	sessions, err := s.SessionService.GetAll(ctxTracing, claims.Id, claims.SessionId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageSessionListed),
+		Data:       sessions,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler end one session of account, can be current session for log out
+func (s *SessionHandler) Revoke(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "SessionHandler Revoke")
+	defer span.Finish()
+
+	accountId, err := claimsAccountId(ctx)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return err
+	}
+
+	sessionId := ctx.Params("id")
+	span.LogFields(log.String("session_id", sessionId))
+
+	// call procedure in service
+	if err := s.SessionService.Revoke(ctxTracing, accountId, sessionId); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageSessionRevoked),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler log out everywhere, end every session of account
+func (s *SessionHandler) RevokeAll(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "SessionHandler RevokeAll")
+	defer span.Finish()
+
+	accountId, err := claimsAccountId(ctx)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return err
+	}
+
+	// call procedure in service
+	if err := s.SessionService.RevokeAll(ctxTracing, accountId); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageSessionRevokedAll),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
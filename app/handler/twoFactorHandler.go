@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	jwtModel "cobaMetrics/app/model/jwt"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"net/http"
+)
+
+type TwoFactorHandler struct {
+	TwoFactorService IService.ITwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService IService.ITwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService}
+}
+
+// claimsAccountId return id of account of verified token, route must be placed after AuthMiddleware
+func claimsAccountId(ctx *fiber.Ctx) (int, error) {
+	claims, ok := ctx.Locals(middleware.ClaimsKey).(*jwtModel.Claims)
+	if !ok {
+		return 0, customError.New(customError.CodeAuthTokenRequired)
+	}
+
+	return claims.Id, nil
+}
+
+// handler enroll two factor, return secret and otpauth uri
+func (t *TwoFactorHandler) Enroll(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "TwoFactorHandler Enroll")
+	defer span.Finish()
+
+	accountId, err := claimsAccountId(ctx)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return err
+	}
+
+	// call procedure in service, secret not logged
+	enroll, err := t.TwoFactorService.Enroll(ctxTracing, accountId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageTwoFactorEnrolled),
+		Data:       enroll,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler confirm two factor with first code, return recovery codes
+func (t *TwoFactorHandler) Confirm(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "TwoFactorHandler Confirm")
+	defer span.Finish()
+
+	accountId, err := claimsAccountId(ctx)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return err
+	}
+
+	// decode request body
+	var request dto.TwoFactorCodeRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	// call procedure in service, recovery code not logged
+	recoveryCodes, err := t.TwoFactorService.Confirm(ctxTracing, accountId, &request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageTwoFactorEnabled),
+		Data:       recoveryCodes,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler disable two factor, need password and code
+func (t *TwoFactorHandler) Disable(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "TwoFactorHandler Disable")
+	defer span.Finish()
+
+	accountId, err := claimsAccountId(ctx)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return err
+	}
+
+	// decode request body
+	var request dto.TwoFactorDisableRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	// call procedure in service
+	if err := t.TwoFactorService.Disable(ctxTracing, accountId, &request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageTwoFactorDisabled),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler second step of login, exchange mfa token and code with access token
+func (t *TwoFactorHandler) Login(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "TwoFactorHandler Login")
+	defer span.Finish()
+
+	// decode request body
+	var request dto.TwoFactorLoginRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	request.Client = sessionClient(ctx)
+
+	// call procedure in service
+	login, err := t.TwoFactorService.Login(ctxTracing, &request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success login
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageAccountLogin),
+		Data:       login,
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
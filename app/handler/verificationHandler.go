@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"net/http"
+)
+
+type VerificationHandler struct {
+	VerificationService IService.IVerificationService
+}
+
+func NewVerificationHandler(verificationService IService.IVerificationService) *VerificationHandler {
+	return &VerificationHandler{verificationService}
+}
+
+// handler verify email with token from link in email
+func (v *VerificationHandler) Verify(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "VerificationHandler Verify")
+	defer span.Finish()
+
+	// call procedure in service, token not logged
+	if err := v.VerificationService.Verify(ctxTracing, ctx.Query("token")); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusOK
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageAccountVerified),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
+
+// handler resend verification email, response same for unknown and verified email
+func (v *VerificationHandler) Resend(ctx *fiber.Ctx) error {
+	// start span tracing
+	span, ctxTracing := tracing.StartSpanFromRequest(ctx, "VerificationHandler Resend")
+	defer span.Finish()
+
+	// decode request body
+	var request dto.ResendVerificationRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return customError.NewWithMessage(customError.CodeRequestBodyInvalid, err.Error())
+	}
+
+	span.LogFields(log.String("email", request.Email))
+
+	// call procedure in service
+	if err := v.VerificationService.Resend(ctxTracing, &request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// success
+	statusCode := http.StatusAccepted
+	response := dto.ApiResponse{
+		StatusCode: statusCode,
+		Status:     helper.CodeToStatus(statusCode),
+		Message:    helper.Message(ctx, i18n.MessageAccountResent),
+	}
+
+	ctx.Status(statusCode)
+	return ctx.JSON(&response)
+}
@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// ApiKeyPrefix is start of every api key, so leaked key easy to detect by secret scanner
+const ApiKeyPrefix = "cm"
+
+// apiKeyIdLength is number of random byte of visible prefix of api key
+const apiKeyIdLength = 6
+
+// NewApiKey return api key sent once to owner, its visible prefix and hash of its secret stored in database.
+// format of api key is cm_<prefix>_<secret>
+func NewApiKey() (string, string, string, error) {
+	bytes := make([]byte, apiKeyIdLength)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", "", err
+	}
+
+	secret, secretHash, err := NewToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix := hex.EncodeToString(bytes)
+	return ApiKeyPrefix + "_" + prefix + "_" + secret, prefix, secretHash, nil
+}
+
+// ParseApiKey split api key into its visible prefix and secret, false when format not valid
+func ParseApiKey(key string) (string, string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != ApiKeyPrefix || len(parts[1]) != 2*apiKeyIdLength || parts[2] == "" {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
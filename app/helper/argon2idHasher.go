@@ -0,0 +1,102 @@
+package helper
+
+import (
+	"cobaMetrics/app/config"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idHasher hash password with argon2id, encoded as $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+type Argon2idHasher struct {
+	Param config.Argon2idParam
+}
+
+// function provider
+func NewArgon2idHasher(param *config.Argon2idParam) (*Argon2idHasher, error) {
+	if param == nil {
+		return nil, errors.New("argon2id parameter required")
+	}
+
+	if param.Iterations < 1 || param.Parallelism < 1 || param.Memory < 8*uint32(param.Parallelism) {
+		return nil, fmt.Errorf("invalid argon2id parameter m=%v,t=%v,p=%v", param.Memory, param.Iterations, param.Parallelism)
+	}
+
+	if param.SaltLength < 8 || param.KeyLength < 16 {
+		return nil, fmt.Errorf("argon2id salt length must be at least 8 and key length at least 16")
+	}
+
+	return &Argon2idHasher{Param: *param}, nil
+}
+
+func (a *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.Param.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Param.Iterations, a.Param.Memory, a.Param.Parallelism, a.Param.KeyLength)
+
+	return fmt.Sprintf("%vv=%d$m=%d,t=%d,p=%d$%v$%v", argon2idPrefix, argon2.Version,
+		a.Param.Memory, a.Param.Iterations, a.Param.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2idHasher) Verify(password, hash string) bool {
+	param, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, param.Iterations, param.Memory, param.Parallelism, param.KeyLength)
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func (a *Argon2idHasher) Identify(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (a *Argon2idHasher) Outdated(hash string) bool {
+	param, _, _, err := decodeArgon2id(hash)
+	return err != nil || param != a.Param
+}
+
+// decodeArgon2id return parameter, salt and key of hash
+func decodeArgon2id(hash string) (config.Argon2idParam, []byte, []byte, error) {
+	var param config.Argon2idParam
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordArgon2id {
+		return param, nil, nil, errors.New("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return param, nil, nil, errors.New("unsupported argon2id version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &param.Memory, &param.Iterations, &param.Parallelism); err != nil || param.Iterations < 1 || param.Parallelism < 1 {
+		return param, nil, nil, errors.New("invalid argon2id parameter")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return param, nil, nil, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return param, nil, nil, err
+	}
+
+	param.SaltLength = uint32(len(salt))
+	param.KeyLength = uint32(len(key))
+	return param, salt, key, nil
+}
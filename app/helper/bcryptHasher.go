@@ -0,0 +1,52 @@
+package helper
+
+import (
+	"cobaMetrics/app/config"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// BcryptHasher hash password with bcrypt, encoded as $2a$<cost>$<salt and key>
+type BcryptHasher struct {
+	Cost int
+}
+
+// function provider
+func NewBcryptHasher(param *config.BcryptParam) (*BcryptHasher, error) {
+	if param == nil {
+		return nil, errors.New("bcrypt parameter required")
+	}
+
+	if param.Cost < bcrypt.MinCost || param.Cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %v and %v", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return &BcryptHasher{Cost: param.Cost}, nil
+}
+
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(bytes), err
+}
+
+func (b *BcryptHasher) Verify(password, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+func (b *BcryptHasher) Identify(hash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *BcryptHasher) Outdated(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.Cost
+}
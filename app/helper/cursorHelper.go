@@ -0,0 +1,22 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// EncodeCursor return opaque url safe cursor of value, client only send it back to get next page
+func EncodeCursor(value any) string {
+	bytes, _ := json.Marshal(value)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+// DecodeCursor decode cursor made by EncodeCursor into value, false when cursor not valid
+func DecodeCursor(cursor string, value any) bool {
+	bytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(bytes, value) == nil
+}
@@ -1,6 +1,9 @@
 package helper
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
 
 func CodeToStatus(code int) string {
 	switch code {
@@ -12,7 +15,11 @@ func CodeToStatus(code int) string {
 		return "not found"
 	case http.StatusUnauthorized:
 		return "unauthorized"
-	default:
-		return "internal server error"
 	}
+
+	if text := http.StatusText(code); text != "" && code < http.StatusInternalServerError {
+		return strings.ToLower(text)
+	}
+
+	return "internal server error"
 }
@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"cobaMetrics/app/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocaleParam is name of query parameter and cookie holding user preferred language
+const LocaleParam = "lang"
+
+// Locale return locale of request. user preference in query or cookie win over Accept-Language
+func Locale(ctx *fiber.Ctx) string {
+	if locale, ok := ctx.Locals(i18n.LocaleKey).(string); ok && locale != "" {
+		return locale
+	}
+
+	var candidates []string
+	if preference := ctx.Query(LocaleParam); preference != "" {
+		candidates = append(candidates, preference)
+	}
+	if preference := ctx.Cookies(LocaleParam); preference != "" {
+		candidates = append(candidates, preference)
+	}
+	candidates = append(candidates, i18n.ParseAcceptLanguage(ctx.Get(fiber.HeaderAcceptLanguage))...)
+
+	return i18n.Match(candidates...)
+}
+
+// Message return message of key in locale of request
+func Message(ctx *fiber.Ctx, key string) string {
+	return i18n.Translate(Locale(ctx), key)
+}
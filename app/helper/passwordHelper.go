@@ -1,25 +1,78 @@
 package helper
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"cobaMetrics/app/config"
+	"fmt"
+)
+
+// algorithm of password hash
+const (
+	PasswordArgon2id = "argon2id"
+	PasswordBcrypt   = "bcrypt"
+)
 
 type IHelperPassword interface {
 	HashPassword(password string) (string, error)
 	CheckPasswordHash(password, hash string) bool
+	// NeedsRehash return true when hash not made with current algorithm and parameter
+	NeedsRehash(hash string) bool
+}
+
+// PasswordHasher is one algorithm of password hash, hash encoded in phc string format
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, hash string) bool
+	// Identify return true when hash made with this algorithm
+	Identify(hash string) bool
+	// Outdated return true when hash made with parameter other than current parameter
+	Outdated(hash string) bool
 }
 
+// HelperPassword hash new password with Hasher, and verify hash of every algorithm in Hashers
 type HelperPassword struct {
+	Hasher  PasswordHasher
+	Hashers []PasswordHasher
 }
 
-func NewHelperPassword() IHelperPassword {
-	return &HelperPassword{}
+// function provider
+func NewHelperPassword(passwordConfig *config.Password) (IHelperPassword, error) {
+	argon2idHasher, err := NewArgon2idHasher(passwordConfig.Argon2id)
+	if err != nil {
+		return nil, err
+	}
+
+	bcryptHasher, err := NewBcryptHasher(passwordConfig.Bcrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	helperPassword := &HelperPassword{Hashers: []PasswordHasher{argon2idHasher, bcryptHasher}}
+	switch passwordConfig.Algorithm {
+	case PasswordArgon2id:
+		helperPassword.Hasher = argon2idHasher
+	case PasswordBcrypt:
+		helperPassword.Hasher = bcryptHasher
+	default:
+		return nil, fmt.Errorf("unknown password algorithm %v", passwordConfig.Algorithm)
+	}
+
+	return helperPassword, nil
 }
 
 func (h *HelperPassword) HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+	return h.Hasher.Hash(password)
 }
 
 func (h *HelperPassword) CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	for _, hasher := range h.Hashers {
+		if hasher.Identify(hash) {
+			return hasher.Verify(password, hash)
+		}
+	}
+
+	return false
+}
+
+func (h *HelperPassword) NeedsRehash(hash string) bool {
+	return !h.Hasher.Identify(hash) || h.Hasher.Outdated(hash)
 }
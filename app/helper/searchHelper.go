@@ -0,0 +1,58 @@
+package helper
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// SearchWords split term into word of letter and digit, same as word of full text index
+func SearchWords(term string) []string {
+	return strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Highlight return html escaped text with every case insensitive match of lower case terms, and word of terms, wrapped in <mark>
+func Highlight(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// byte offset of lower case not same as text, text not highlighted
+		return html.EscapeString(text)
+	}
+
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		for _, needle := range append([]string{term}, SearchWords(term)...) {
+			for start := 0; needle != ""; {
+				index := strings.Index(lower[start:], needle)
+				if index < 0 {
+					break
+				}
+
+				start += index
+				for i := start; i < start+len(needle); i++ {
+					marked[i] = true
+				}
+				start += len(needle)
+			}
+		}
+	}
+
+	var builder strings.Builder
+	for start := 0; start < len(text); {
+		end := start
+		for end < len(text) && marked[end] == marked[start] {
+			end++
+		}
+
+		if marked[start] {
+			builder.WriteString("<mark>" + html.EscapeString(text[start:end]) + "</mark>")
+		} else {
+			builder.WriteString(html.EscapeString(text[start:end]))
+		}
+		start = end
+	}
+
+	return builder.String()
+}
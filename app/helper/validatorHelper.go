@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/passwordpolicy"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+)
+
+// PasswordTag is validator tag of password policy
+const PasswordTag = "password"
+
+// NewValidator create validator that report field with json name, so client can match error to its field.
+// message of every supported locale registered too. password tag use default policy until RegisterPasswordPolicy called
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(JsonFieldName)
+	if err := i18n.RegisterValidatorTranslations(validate); err != nil {
+		panic(err)
+	}
+
+	if err := RegisterPasswordPolicy(validate, passwordpolicy.Default()); err != nil {
+		panic(err)
+	}
+
+	return validate
+}
+
+// JsonFieldName return json name of struct field, go field name when field has no json tag
+func JsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// RegisterPasswordPolicy register password tag checked with policy.
+// Email and Username field of struct that own the password not allowed inside password
+func RegisterPasswordPolicy(validate *validator.Validate, policy *passwordpolicy.Policy) error {
+	err := validate.RegisterValidation(PasswordTag, func(fl validator.FieldLevel) bool {
+		return policy.Check(fl.Field().String(), passwordIdentities(fl.Parent())...) == ""
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, locale := range i18n.Locales() {
+		err = validate.RegisterTranslation(PasswordTag, i18n.Translator(locale), func(ut.Translator) error {
+			return nil
+		}, func(_ ut.Translator, fieldError validator.FieldError) string {
+			return passwordMessage(locale, policy, fieldError)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// passwordMessage explain the rule violated. identity not known here, so password that pass other rule violate identity rule
+func passwordMessage(locale string, policy *passwordpolicy.Policy, fieldError validator.FieldError) string {
+	password, _ := fieldError.Value().(string)
+
+	rule := policy.Check(password)
+	switch rule {
+	case "":
+		rule = passwordpolicy.RuleIdentity
+	case passwordpolicy.RuleMinLength:
+		return i18n.Translate(locale, i18n.MessagePasswordRule+rule, fieldError.Field(), policy.MinLength)
+	case passwordpolicy.RuleMaxLength:
+		return i18n.Translate(locale, i18n.MessagePasswordRule+rule, fieldError.Field(), policy.MaxLength)
+	}
+
+	return i18n.Translate(locale, i18n.MessagePasswordRule+rule, fieldError.Field())
+}
+
+// passwordIdentities return Email and Username of struct that own the password
+func passwordIdentities(parent reflect.Value) []string {
+	parent = reflect.Indirect(parent)
+	if parent.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var identities []string
+	for _, name := range []string{"Email", "Username"} {
+		if field := parent.FieldByName(name); field.IsValid() && field.Kind() == reflect.String {
+			identities = append(identities, field.String())
+		}
+	}
+
+	return identities
+}
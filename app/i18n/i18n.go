@@ -0,0 +1,242 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	EN = "en"
+	ID = "id"
+
+	// DefaultLocale is last locale in fallback chain
+	DefaultLocale = EN
+)
+
+// message key other than error code. message key of error is the error code itself
+const (
+	MessageQueryLimitNumeric = "request.query.limit_numeric"
+	MessageQueryLimitRange   = "request.query.limit_range"
+	MessageQueryCursor       = "request.query.cursor_invalid"
+	MessageQuerySort         = "request.query.sort_invalid"
+	MessageQueryCreatedRange = "request.query.created_invalid"
+	MessageQuerySearch       = "request.query.search_invalid"
+	MessageQueryFormat       = "request.query.format_invalid"
+	MessageImportHeader      = "request.body.import_header"
+
+	MessageAccountAdded    = "account.added"
+	MessageAccountFound    = "account.found"
+	MessageAccountLogin    = "account.login"
+	MessageAccountListed   = "account.listed"
+	MessageAccountUnlocked = "account.unlocked"
+	MessageAccountVerified = "account.verified"
+	MessageAccountResent   = "account.verification_resent"
+	MessageAccountSearched = "account.searched"
+	MessageAccountImported = "account.imported"
+	MessageAccountChecked  = "account.import_checked"
+
+	MessageTwoFactorRequired = "two_factor.required"
+	MessageTwoFactorEnrolled = "two_factor.enrolled"
+	MessageTwoFactorEnabled  = "two_factor.enabled"
+	MessageTwoFactorDisabled = "two_factor.disabled"
+
+	MessageSessionListed     = "session.listed"
+	MessageSessionRevoked    = "session.revoked"
+	MessageSessionRevokedAll = "session.revoked_all"
+
+	MessageApiKeyCreated = "api_key.created"
+	MessageApiKeyListed  = "api_key.listed"
+	MessageApiKeyRevoked = "api_key.revoked"
+
+	MessageOAuthClientCreated = "oauth_client.created"
+	MessageOAuthClientListed  = "oauth_client.listed"
+	MessageOAuthClientRevoked = "oauth_client.revoked"
+
+	MessagePasswordForgot = "password.forgot"
+	MessagePasswordReset  = "password.reset"
+
+	MessageMailPasswordResetSubject = "mail.password_reset.subject"
+	MessageMailPasswordResetBody    = "mail.password_reset.body"
+	MessageMailVerifyEmailSubject   = "mail.verify_email.subject"
+	MessageMailVerifyEmailBody      = "mail.verify_email.body"
+
+	// prefix of password policy message, followed by rule name
+	MessagePasswordRule = "validation.password."
+)
+
+type contextKey string
+
+// LocaleKey is key of request locale in context
+const LocaleKey contextKey = "locale"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	result := map[string]map[string]string{}
+	for _, locale := range []string{EN, ID} {
+		content, err := localeFiles.ReadFile(path.Join("locales", locale+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("cant read message catalog %v : %v", locale, err))
+		}
+
+		messages := map[string]string{}
+		if err := json.Unmarshal(content, &messages); err != nil {
+			panic(fmt.Sprintf("cant parse message catalog %v : %v", locale, err))
+		}
+
+		result[locale] = messages
+	}
+
+	return result
+}
+
+// Locales return every supported locale
+func Locales() []string {
+	return []string{EN, ID}
+}
+
+// Keys return every message key in catalog of locale
+func Keys(locale string) []string {
+	keys := make([]string, 0, len(catalogs[locale]))
+	for key := range catalogs[locale] {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Has return true when catalog of locale has the key, without fallback
+func Has(locale string, key string) bool {
+	_, ok := catalogs[locale][key]
+	return ok
+}
+
+// Fallbacks return chain of locale tried when translating, example id-ID -> id -> en
+func Fallbacks(locale string) []string {
+	locale = normalize(locale)
+
+	var chain []string
+	for _, candidate := range []string{locale, base(locale), DefaultLocale} {
+		if candidate == "" || contains(chain, candidate) {
+			continue
+		}
+		chain = append(chain, candidate)
+	}
+
+	return chain
+}
+
+// Translate return message of key in locale following fallback chain.
+// key itself returned when no catalog has it, args formatted with fmt when given
+func Translate(locale string, key string, args ...any) string {
+	for _, candidate := range Fallbacks(locale) {
+		if message, ok := catalogs[candidate][key]; ok {
+			if len(args) > 0 {
+				return fmt.Sprintf(message, args...)
+			}
+			return message
+		}
+	}
+
+	return key
+}
+
+// Match return first supported locale of candidates, DefaultLocale when nothing supported.
+// region is ignored when only the language supported, example id-ID match id
+func Match(candidates ...string) string {
+	for _, candidate := range candidates {
+		candidate = normalize(candidate)
+		for _, locale := range []string{candidate, base(candidate)} {
+			if _, ok := catalogs[locale]; ok {
+				return locale
+			}
+		}
+	}
+
+	return DefaultLocale
+}
+
+// ParseAcceptLanguage return language tag in Accept-Language header ordered by quality
+func ParseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if value, ok := strings.CutPrefix(param, "q="); ok {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > 0 {
+			languages = append(languages, language{tag, quality})
+		}
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	tags := make([]string, 0, len(languages))
+	for _, language := range languages {
+		tags = append(tags, language.tag)
+	}
+
+	return tags
+}
+
+// WithLocale return context carrying locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, LocaleKey, locale)
+}
+
+// FromContext return locale in context, DefaultLocale when not set
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(LocaleKey).(string); ok && locale != "" {
+		return locale
+	}
+
+	return DefaultLocale
+}
+
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+func base(locale string) string {
+	before, _, _ := strings.Cut(locale, "-")
+	return before
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
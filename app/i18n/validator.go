@@ -0,0 +1,66 @@
+package i18n
+
+import (
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+)
+
+// sharedTranslator always override existing text, so default translations can be registered to more than one validator
+type sharedTranslator struct {
+	ut.Translator
+}
+
+func (s *sharedTranslator) Add(key interface{}, text string, _ bool) error {
+	return s.Translator.Add(key, text, true)
+}
+
+func (s *sharedTranslator) AddCardinal(key interface{}, text string, rule locales.PluralRule, _ bool) error {
+	return s.Translator.AddCardinal(key, text, rule, true)
+}
+
+func (s *sharedTranslator) AddOrdinal(key interface{}, text string, rule locales.PluralRule, _ bool) error {
+	return s.Translator.AddOrdinal(key, text, rule, true)
+}
+
+func (s *sharedTranslator) AddRange(key interface{}, text string, rule locales.PluralRule, _ bool) error {
+	return s.Translator.AddRange(key, text, rule, true)
+}
+
+var translators = newTranslators()
+
+func newTranslators() map[string]ut.Translator {
+	universal := ut.New(en.New(), en.New(), id.New())
+
+	result := map[string]ut.Translator{}
+	for _, locale := range Locales() {
+		translator, _ := universal.GetTranslator(locale)
+		result[locale] = &sharedTranslator{translator}
+	}
+
+	return result
+}
+
+// Translator return validator translator of locale following fallback chain
+func Translator(locale string) ut.Translator {
+	for _, candidate := range Fallbacks(locale) {
+		if translator, ok := translators[candidate]; ok {
+			return translator
+		}
+	}
+
+	return translators[DefaultLocale]
+}
+
+// RegisterValidatorTranslations register validator message of every supported locale
+func RegisterValidatorTranslations(validate *validator.Validate) error {
+	if err := enTranslations.RegisterDefaultTranslations(validate, translators[EN]); err != nil {
+		return err
+	}
+
+	return idTranslations.RegisterDefaultTranslations(validate, translators[ID])
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/tracing"
+	"cobaMetrics/database"
+	"context"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"log/slog"
+)
+
+type contextKey string
+
+// key of request scoped value in context, set in fiber Locals by middleware
+const (
+	RequestIDKey contextKey = "request_id"
+	RouteKey     contextKey = "route"
+	AccountIDKey contextKey = "account_id"
+)
+
+// Attrs return request scoped attribute found in context.
+// route can be string or func() string, because route of request only known after routing
+func Attrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+
+	if requestID, ok := ctx.Value(RequestIDKey).(string); ok && requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		// log written outside handler span, like in middleware, use root span of request
+		span, _ = ctx.Value(tracing.SpanKey).(opentracing.Span)
+	}
+
+	if span != nil {
+		if spanContext, ok := span.Context().(jaeger.SpanContext); ok && spanContext.IsValid() {
+			attrs = append(attrs,
+				slog.String("trace_id", spanContext.TraceID().String()),
+				slog.String("span_id", spanContext.SpanID().String()),
+			)
+		}
+	}
+
+	switch route := ctx.Value(RouteKey).(type) {
+	case string:
+		attrs = append(attrs, slog.String("route", route))
+	case func() string:
+		attrs = append(attrs, slog.String("route", route()))
+	}
+
+	if accountID := ctx.Value(AccountIDKey); accountID != nil {
+		attrs = append(attrs, slog.Any("account_id", accountID))
+	}
+
+	return attrs
+}
+
+// DetachedContext copy request scoped value of ctx into new context, for work that still run after request returned.
+// context of request pooled and reused by next request, so its value must not be read after handler returned
+func DetachedContext(ctx context.Context) context.Context {
+	detached := context.Background()
+	for _, key := range []any{RequestIDKey, AccountIDKey, database.CallerKey, i18n.LocaleKey} {
+		if value := ctx.Value(key); value != nil {
+			detached = context.WithValue(detached, key, value)
+		}
+	}
+
+	// route resolved now, func of route read the request
+	switch route := ctx.Value(RouteKey).(type) {
+	case string:
+		detached = context.WithValue(detached, RouteKey, route)
+	case func() string:
+		detached = context.WithValue(detached, RouteKey, route())
+	}
+
+	return detached
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// LogMailer write email into writer instead of sending it, for local development and test
+type LogMailer struct {
+	Writer io.Writer
+	From   string
+	Logger *slog.Logger
+	mutex  sync.Mutex
+}
+
+// function provider
+func NewLogMailer(writer io.Writer, from string, logger *slog.Logger) *LogMailer {
+	return &LogMailer{
+		Writer: writer,
+		From:   from,
+		Logger: logger,
+	}
+}
+
+func (l *LogMailer) Send(ctx context.Context, message Message) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, err := l.Writer.Write(append(Format(l.From, message, time.Now()), "\r\n"...)); err != nil {
+		return err
+	}
+
+	// address not logged, it is personal data
+	l.Logger.InfoContext(ctx, "mail written", slog.String("subject", message.Subject))
+	return nil
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"cobaMetrics/app/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// driver of mailer
+const (
+	DriverSMTP = "smtp"
+	DriverLog  = "log"
+)
+
+// Message is plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer deliver email to user
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewMailer create mailer of driver in config. log driver write email into file, or stdout when file empty.
+// file kept open until app stopped, same as stdout
+func NewMailer(mailConfig *config.Mail, logger *slog.Logger) (Mailer, error) {
+	switch mailConfig.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(mailConfig), nil
+	case DriverLog:
+		if mailConfig.File == "" {
+			return NewLogMailer(os.Stdout, mailConfig.From, logger), nil
+		}
+
+		file, err := os.OpenFile(mailConfig.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewLogMailer(file, mailConfig.From, logger), nil
+	}
+
+	return nil, fmt.Errorf("unknown mail driver %v", mailConfig.Driver)
+}
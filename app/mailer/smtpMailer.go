@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"bytes"
+	"cobaMetrics/app/config"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPMailer send email through smtp server, with plain auth when username set
+type SMTPMailer struct {
+	Addr     string
+	From     string
+	Auth     smtp.Auth
+	SendMail func(addr string, auth smtp.Auth, from string, to []string, message []byte) error
+}
+
+// function provider
+func NewSMTPMailer(mailConfig *config.Mail) *SMTPMailer {
+	var auth smtp.Auth
+	if mailConfig.Username != "" {
+		auth = smtp.PlainAuth("", mailConfig.Username, mailConfig.Password, mailConfig.Host)
+	}
+
+	return &SMTPMailer{
+		Addr:     net.JoinHostPort(mailConfig.Host, strconv.Itoa(mailConfig.Port)),
+		From:     mailConfig.From,
+		Auth:     auth,
+		SendMail: smtp.SendMail,
+	}
+}
+
+func (s *SMTPMailer) Send(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.SendMail(s.Addr, s.Auth, s.From, []string{message.To}, Format(s.From, message, time.Now())); err != nil {
+		return fmt.Errorf("send mail to %v : %w", s.Addr, err)
+	}
+
+	return nil
+}
+
+// Format return message in rfc 5322 format with utf-8 plain text body
+func Format(from string, message Message, date time.Time) []byte {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintf(buffer, "From: %v\r\n", from)
+	fmt.Fprintf(buffer, "To: %v\r\n", message.To)
+	fmt.Fprintf(buffer, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(buffer, "Date: %v\r\n", date.Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("\r\n")
+	buffer.WriteString(message.Body)
+	buffer.WriteString("\r\n")
+
+	return buffer.Bytes()
+}
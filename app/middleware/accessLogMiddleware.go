@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/logging"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// AccessLogMiddleware write one log line per request after response ready.
+// successful request sampled with success_sample_rate, request with status >= 400 always written
+func AccessLogMiddleware(accessLogConfig *config.AccessLog, logger *slog.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !accessLogConfig.Enabled {
+			return ctx.Next()
+		}
+
+		start := time.Now()
+		err := handleChainError(ctx, ctx.Next())
+		latency := time.Since(start)
+
+		status := ctx.Response().StatusCode()
+		if status < fiber.StatusBadRequest && rand.Float64() >= accessLogConfig.SuccessSampleRate {
+			return err
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Method()),
+			slog.String("route_template", ctx.Route().Path),
+			slog.String("path", ctx.Path()),
+			slog.Int("status", status),
+			slog.Duration("latency", latency),
+			slog.Int("bytes_in", requestBytes(ctx)),
+			slog.Int("bytes_out", responseBytes(ctx)),
+			slog.String("client_ip", ctx.IP()),
+		}
+		if accountID := ctx.Locals(logging.AccountIDKey); accountID != nil {
+			attrs = append(attrs, slog.Any("user_id", accountID))
+		}
+
+		// request_id and trace_id added by logger from context
+
+		logger.LogAttrs(ctx.Context(), level, "access", attrs...)
+
+		return err
+	}
+}
+
+// requestBytes return size of request body, streamed body not read again because it can be bigger than body limit
+func requestBytes(ctx *fiber.Ctx) int {
+	if ctx.Request().IsBodyStream() {
+		return max(ctx.Request().Header.ContentLength(), 0)
+	}
+
+	return len(ctx.Request().Body())
+}
+
+// responseBytes return size of response body, streamed body written after this log so only its Content-Length known
+func responseBytes(ctx *fiber.Ctx) int {
+	if ctx.Response().IsBodyStream() {
+		return max(ctx.Response().Header.ContentLength(), 0)
+	}
+
+	return len(ctx.Response().Body())
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	jwtModel "cobaMetrics/app/model/jwt"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"strings"
+)
+
+// AdminMiddleware allow only account with verified email listed in admin config and logged in with token, must be placed after AuthMiddleware
+func AdminMiddleware(config config.IConfig, logger *slog.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		claims, ok := ctx.Locals(ClaimsKey).(*jwtModel.Claims)
+		if !ok {
+			return customError.New(customError.CodeAuthTokenRequired)
+		}
+
+		// api key of admin account not act as admin
+		if !claims.IsUser() {
+			return customError.New(customError.CodeApiKeyNotAllowed)
+		}
+
+		// email must be verified, otherwise anyone who register admin email before its owner become admin
+		if adminConfig := config.Config().Admin; adminConfig != nil && claims.EmailVerified {
+			for _, email := range adminConfig.Emails {
+				if strings.EqualFold(email, claims.Email) {
+					return ctx.Next()
+				}
+			}
+		}
+
+		logger.WarnContext(ctx.Context(), "admin access denied")
+		return customError.New(customError.CodeForbidden)
+	}
+}
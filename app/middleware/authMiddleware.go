@@ -2,102 +2,274 @@ package middleware
 
 import (
 	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
 	"cobaMetrics/app/helper"
-	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/logging"
 	jwtModel "cobaMetrics/app/model/jwt"
+	IRepo "cobaMetrics/app/repository/interface"
+	"cobaMetrics/app/tracing"
+	"cobaMetrics/database"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"github.com/gofiber/fiber/v2"
-	logFiber "github.com/gofiber/fiber/v2/log"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
-	"net/http"
+	"log/slog"
 	"strings"
+	"time"
 )
 
-func AuthMiddleware(config config.IConfig) fiber.Handler {
+type contextKey string
+
+// ClaimsKey is key of *jwtModel.Claims of verified token or api key in Locals
+const ClaimsKey contextKey = "claims"
+
+// HeaderApiKey is header of api key, checked before authorization header
+const HeaderApiKey = "X-API-Key"
+
+// accountRepository dipakai untuk cek token_version, token lama tidak berlaku setelah reset password.
+// sessionRepository dipakai untuk cek session dari token belum diakhiri.
+// apiKeyRepository dan oauthClientRepository nil berarti endpoint tidak menerima api key dan token oauth client, lihat UserAuthMiddleware
+func AuthMiddleware(config config.IConfig, accountRepository IRepo.IAccountRepository, sessionRepository IRepo.ISessionRepository, apiKeyRepository IRepo.IApiKeyRepository, oauthClientRepository IRepo.IOAuthClientRepository, logger *slog.Logger) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		// create span tracing
-		span, _ := opentracing.StartSpanFromContext(ctx.Context(), "Middleware Auth")
+		span, ctxTracing := tracing.StartSpanFromRequest(ctx, "Middleware Auth")
 		defer span.Finish()
 
-		// log
-		request := ctx.Request().Body()
-		span.LogFields(log.String("request", string(request)))
-
-		jwtConfig := config.Config().Jwt
-		logFiber.Info("masuk middleware auth")
-
-		// get token
-		tokenHeader := ctx.Get("authorization")
+		// body not logged, it can contain password and streamed body of import must not be read here
 
-		if tokenHeader == "" {
-			statusCode := http.StatusUnauthorized
-			response := dto.ApiResponse{
-				StatusCode: statusCode,
-				Status:     helper.CodeToStatus(statusCode),
-				Message:    "token required",
+		var claims *jwtModel.Claims
+		var err error
+		if key := ctx.Get(HeaderApiKey); key != "" {
+			if apiKeyRepository == nil {
+				ext.Error.Set(span, true)
+				span.LogFields(log.String("response", "api key not allowed"))
+				return customError.New(customError.CodeApiKeyNotAllowed)
 			}
 
-			responseJson, _ := json.Marshal(&response)
-			span.LogFields(log.String("response", string(responseJson)))
-			ext.Error.Set(span, true)
+			claims, err = verifyApiKey(ctxTracing, span, config, accountRepository, apiKeyRepository, key, logger)
+		} else {
+			claims, err = verifyToken(ctxTracing, span, ctx.Get("authorization"), config, accountRepository, sessionRepository, oauthClientRepository, logger)
+		}
 
-			ctx.Status(statusCode)
-			return ctx.JSON(&response)
+		if err != nil {
+			return err
 		}
 
-		tokenString := strings.Split(tokenHeader, " ")
-		var token string = tokenString[1]
+		// lolos semua validasi auth
+		ctx.Locals(logging.AccountIDKey, principalId(claims))
+		ctx.Locals(database.CallerKey, principalCaller(claims))
+		ctx.Locals(ClaimsKey, claims)
+		logger.DebugContext(ctx.Context(), "principal verified", slog.Any("principal", principalId(claims)))
 
-		// decode claims
-		var claims jwtModel.Claims
-		tokenWithClaims, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtConfig.SecretKey), nil
-		})
+		return ctx.Next()
+	}
+}
 
-		claimsJson, _ := json.Marshal(&claims)
-		span.LogFields(log.String("claims", string(claimsJson)))
+// UserAuthMiddleware only accept login token, for endpoint that act as user like session, two factor and api key management
+func UserAuthMiddleware(config config.IConfig, accountRepository IRepo.IAccountRepository, sessionRepository IRepo.ISessionRepository, logger *slog.Logger) fiber.Handler {
+	return AuthMiddleware(config, accountRepository, sessionRepository, nil, nil, logger)
+}
 
-		// if token not valid
-		if err != nil {
-			ext.Error.Set(span, true)
+// principalId is account id, service name for api key owned by service or client id for token of oauth client,
+// used as rate limit subject and in log
+func principalId(claims *jwtModel.Claims) any {
+	if claims.Service != "" {
+		return "service:" + claims.Service
+	}
+	if claims.ClientId != "" {
+		return "client:" + claims.ClientId
+	}
 
-			statuscCode := http.StatusUnauthorized
+	return claims.Id
+}
 
-			response := dto.ApiResponse{
-				StatusCode: statuscCode,
-				Status:     helper.CodeToStatus(statuscCode),
-				Message:    err.Error(),
-			}
+// verifyToken verify jwt of authorization header, its account and its session, or its client for token of oauth client
+func verifyToken(ctx context.Context, span opentracing.Span, tokenHeader string, config config.IConfig, accountRepository IRepo.IAccountRepository, sessionRepository IRepo.ISessionRepository, oauthClientRepository IRepo.IOAuthClientRepository, logger *slog.Logger) (*jwtModel.Claims, error) {
+	jwtConfig := config.Config().Jwt
 
-			responseJson, _ := json.Marshal(&response)
-			span.LogFields(log.String("response", string(responseJson)))
+	if tokenHeader == "" {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "token required"))
+		logger.DebugContext(ctx, "request without token")
+		return nil, customError.New(customError.CodeAuthTokenRequired)
+	}
+
+	tokenString := strings.Split(tokenHeader, " ")
+	if len(tokenString) != 2 {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "token not valid"))
+		return nil, customError.New(customError.CodeAuthTokenInvalid)
+	}
+
+	var token string = tokenString[1]
+
+	// decode claims
+	var claims jwtModel.Claims
+	tokenWithClaims, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(jwtConfig.SecretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
-			ctx.Status(statuscCode)
-			return ctx.JSON(&response)
+	claimsJson, _ := json.Marshal(&claims)
+	span.LogFields(log.String("claims", string(claimsJson)))
+
+	// if token not valid
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		logger.WarnContext(ctx, "token rejected", slog.String("error", err.Error()))
+		return nil, customError.NewWithMessage(customError.CodeAuthTokenInvalid, err.Error())
+	}
+
+	// if not valid at all
+	if !tokenWithClaims.Valid {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "token not valid"))
+		return nil, customError.New(customError.CodeAuthTokenInvalid)
+	}
+
+	// mfa_pending token only valid for login two factor
+	if claims.RegisteredClaims.Subject == jwtModel.SubjectMfaPending {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "mfa pending token"))
+		return nil, customError.New(customError.CodeAuthTokenInvalid)
+	}
+
+	if claims.RegisteredClaims.Subject == jwtModel.SubjectClient {
+		return verifyClientToken(ctx, span, &claims, oauthClientRepository, logger)
+	}
+
+	// token revoked when account removed or token_version changed
+	account, err := accountRepository.GetById(ctx, claims.Id)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		if customError.FromError(err).Code == customError.CodeAccountNotFound {
+			return nil, customError.New(customError.CodeAuthTokenRevoked)
 		}
 
-		// if not valid at all
-		if !tokenWithClaims.Valid {
-			statusCode := http.StatusUnauthorized
-			response := dto.ApiResponse{
-				StatusCode: statusCode,
-				Status:     helper.CodeToStatus(statusCode),
-				Message:    "token not valid",
-			}
+		return nil, err
+	}
 
-			responseJson, _ := json.Marshal(&response)
-			span.LogFields(log.String("response", string(responseJson)))
+	if account.TokenVersion != claims.TokenVersion {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "token revoked"))
+		logger.InfoContext(ctx, "token revoked", slog.Int("token_version", claims.TokenVersion))
+		return nil, customError.New(customError.CodeAuthTokenRevoked)
+	}
+
+	// token without session or with ended session rejected
+	session, err := sessionRepository.GetById(ctx, claims.SessionId)
+	if err != nil && customError.FromError(err).Code != customError.CodeSessionNotFound {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	now := time.Now()
+	if session == nil || session.AccountId != claims.Id || !session.IsActive(now) {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "session ended"))
+		logger.InfoContext(ctx, "token rejected", slog.String("reason", "session ended"))
+		return nil, customError.New(customError.CodeAuthTokenRevoked)
+	}
+
+	// failure only logged, request still valid
+	if sessionConfig := config.Config().Session; sessionConfig == nil || now.Sub(session.LastSeenAt) >= sessionConfig.TouchInterval {
+		if err := sessionRepository.Touch(ctx, session.Id, now); err != nil {
+			logger.WarnContext(ctx, "failed to update session last seen", slog.String("error", err.Error()))
+		}
+	}
+
+	return &claims, nil
+}
+
+// verifyClientToken verify client of token issued by client_credentials grant still active
+func verifyClientToken(ctx context.Context, span opentracing.Span, claims *jwtModel.Claims, oauthClientRepository IRepo.IOAuthClientRepository, logger *slog.Logger) (*jwtModel.Claims, error) {
+	if oauthClientRepository == nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "client token not allowed"))
+		return nil, customError.New(customError.CodeApiKeyNotAllowed)
+	}
+
+	client, err := oauthClientRepository.GetById(ctx, claims.ClientId)
+	if err != nil && customError.FromError(err).Code != customError.CodeOAuthClientNotFound {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	if client == nil || !client.IsActive() {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "client revoked"))
+		logger.InfoContext(ctx, "token rejected", slog.String("reason", "client revoked"), slog.String("client_id", claims.ClientId))
+		return nil, customError.New(customError.CodeAuthTokenRevoked)
+	}
+
+	claims.Scopes = strings.Fields(claims.Scope)
+	return claims, nil
+}
+
+// verifyApiKey verify api key of header X-API-Key and build principal of its owner
+func verifyApiKey(ctx context.Context, span opentracing.Span, config config.IConfig, accountRepository IRepo.IAccountRepository, apiKeyRepository IRepo.IApiKeyRepository, key string, logger *slog.Logger) (*jwtModel.Claims, error) {
+	prefix, secret, ok := helper.ParseApiKey(key)
+	if !ok {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "api key format not valid"))
+		return nil, customError.New(customError.CodeApiKeyInvalid)
+	}
+
+	span.LogFields(log.String("api_key_prefix", prefix))
+
+	apiKey, err := apiKeyRepository.GetByPrefix(ctx, prefix)
+	if err != nil && customError.FromError(err).Code != customError.CodeApiKeyNotFound {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	now := time.Now()
+	if apiKey == nil || subtle.ConstantTimeCompare([]byte(helper.HashToken(secret)), []byte(apiKey.SecretHash)) != 1 || !apiKey.IsActive(now) {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "api key not valid"))
+		logger.WarnContext(ctx, "api key rejected", slog.String("api_key_prefix", prefix))
+		return nil, customError.New(customError.CodeApiKeyInvalid)
+	}
+
+	claims := jwtModel.Claims{
+		ApiKeyId: apiKey.Id,
+		Service:  apiKey.Service,
+		Scopes:   apiKey.Scopes,
+	}
+
+	// api key of account act as the account, rejected when account removed
+	if apiKey.AccountId != nil {
+		account, err := accountRepository.GetById(ctx, *apiKey.AccountId)
+		if err != nil {
 			ext.Error.Set(span, true)
+			span.LogFields(log.String("response", err.Error()))
+			if customError.FromError(err).Code == customError.CodeAccountNotFound {
+				return nil, customError.New(customError.CodeApiKeyInvalid)
+			}
 
-			ctx.Status(statusCode)
-			return ctx.JSON(&response)
+			return nil, err
 		}
 
-		// lolos semua validasi auth
-		ctx.Next()
-		return nil
+		claims.Id = account.Id
+		claims.Email = account.Email
+		claims.TokenVersion = account.TokenVersion
+		claims.EmailVerified = account.IsVerified()
 	}
+
+	// failure only logged, request still valid
+	if apiKeyConfig := config.Config().ApiKey; apiKey.LastUsedAt == nil || apiKeyConfig == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyConfig.TouchInterval {
+		if err := apiKeyRepository.Touch(ctx, apiKey.Id, now); err != nil {
+			logger.WarnContext(ctx, "failed to update api key last used", slog.String("error", err.Error()))
+		}
+	}
+
+	return &claims, nil
 }
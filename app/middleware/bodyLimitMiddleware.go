@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"cobaMetrics/app/customError"
+	"github.com/gofiber/fiber/v2"
+	"io"
+)
+
+// BodyLimitMiddleware read streamed request body into memory up to BodyLimit of app, bigger body rejected.
+// server stream request body so route in stream can read body bigger than BodyLimit with its own limit
+func BodyLimitMiddleware(stream func(ctx *fiber.Ctx) bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		body := ctx.Request().BodyStream()
+		if body == nil || stream(ctx) {
+			return ctx.Next()
+		}
+
+		limit := ctx.App().Config().BodyLimit
+		content, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+		if err != nil {
+			return customError.New(customError.CodeRequestBodyInvalid)
+		}
+		if len(content) > limit {
+			// rest of body not read, connection can not be used by next request
+			ctx.Context().SetConnectionClose()
+			return customError.New(customError.CodeRequestBodyTooLarge)
+		}
+
+		ctx.Request().SetBody(content)
+		return ctx.Next()
+	}
+}
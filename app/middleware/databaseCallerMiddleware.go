@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	jwtModel "cobaMetrics/app/model/jwt"
+	"cobaMetrics/database"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// DatabaseCallerMiddleware set client ip as database caller of anonymous request, so read after write from same client go to primary.
+// AuthMiddleware replace it with principal, user behind same ip not pinned by write of each other
+func DatabaseCallerMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ctx.Locals(database.CallerKey, "ip:"+ctx.IP())
+		return ctx.Next()
+	}
+}
+
+// principalCaller return database caller of authenticated principal
+func principalCaller(claims *jwtModel.Claims) string {
+	if id, ok := principalId(claims).(int); ok {
+		return "account:" + strconv.Itoa(id)
+	}
+
+	return principalId(claims).(string)
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocaleMiddleware resolve locale of request once, so handler and service read the same locale
+func LocaleMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		locale := helper.Locale(ctx)
+		ctx.Locals(i18n.LocaleKey, locale)
+		ctx.Set(fiber.HeaderContentLanguage, locale)
+		ctx.Vary(fiber.HeaderAcceptLanguage)
+
+		return ctx.Next()
+	}
+}
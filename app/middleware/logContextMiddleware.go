@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"cobaMetrics/app/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LogContextMiddleware put route of request in context, so every log of the request has it
+func LogContextMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// route of handler only known after this middleware, resolve it when record written
+		ctx.Locals(logging.RouteKey, func() string {
+			return ctx.Route().Path
+		})
+
+		return ctx.Next()
+	}
+}
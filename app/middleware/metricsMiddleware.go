@@ -4,22 +4,20 @@ import (
 	"cobaMetrics/app/config"
 	"cobaMetrics/metrics"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"log/slog"
 )
 
-func MetricsMiddleware(config config.IConfig, metrics *metrics.MetricsApp) fiber.Handler {
+func MetricsMiddleware(config config.IConfig, metrics *metrics.MetricsApp, logger *slog.Logger) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		path := string(ctx.Request().URI().Path())
 		method := ctx.Method()
 		timer := prometheus.NewTimer(metrics.DurationReq.WithLabelValues(path, method))
 		defer timer.ObserveDuration()
-		log.Infof("masuk middleware : %v %v", method, path)
+		logger.DebugContext(ctx.Context(), "request received", slog.String("method", method), slog.String("path", path))
 
 		metrics.CounterReq.WithLabelValues(path, method).Inc()
 
-		ctx.Next()
-
-		return nil
+		return ctx.Next()
 	}
 }
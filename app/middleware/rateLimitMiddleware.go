@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/ratelimit"
+	"cobaMetrics/metrics"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// key of rate limit rule
+const (
+	RateLimitKeyIP      = "ip"
+	RateLimitKeyEmail   = "email"
+	RateLimitKeySubject = "subject"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// RateLimiter create rate limit middleware of route from rule in config
+type RateLimiter struct {
+	Config  *config.RateLimit
+	Store   ratelimit.Store
+	Metrics *metrics.MetricsApp
+	Logger  *slog.Logger
+}
+
+// function provider
+func NewRateLimiter(rateLimitConfig *config.RateLimit, store ratelimit.Store, metrics *metrics.MetricsApp, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{
+		Config:  rateLimitConfig,
+		Store:   store,
+		Metrics: metrics,
+		Logger:  logger,
+	}
+}
+
+// For return middleware that apply every rule of route name. subject rule must be placed after auth middleware
+func (r *RateLimiter) For(route string) fiber.Handler {
+	rules := r.Config.Routes[route]
+
+	return func(ctx *fiber.Ctx) error {
+		if !r.Config.Enabled || len(rules) == 0 {
+			return ctx.Next()
+		}
+
+		// header show the strictest rule
+		var strictest *ratelimit.Result
+		for _, rule := range rules {
+			key, ok := rateLimitKey(ctx, rule.Key)
+			if !ok {
+				continue
+			}
+
+			result, err := r.Store.Take(ctx.Context(), fmt.Sprintf("%v:%v:%v", route, rule.Key, key), ratelimit.Rule{
+				Limit:  rule.Limit,
+				Period: rule.Period,
+				Burst:  rule.Burst,
+			})
+			if err != nil {
+				// store not available, not block request
+				r.Logger.ErrorContext(ctx.Context(), "rate limit store failed", slog.String("error", err.Error()))
+				continue
+			}
+
+			if !result.Allowed {
+				r.Metrics.RateLimitHits.WithLabelValues(route, rule.Key).Inc()
+				r.Logger.WarnContext(ctx.Context(), "rate limit exceeded", slog.String("route_name", route), slog.String("key", rule.Key))
+
+				setRateLimitHeader(ctx, result)
+				ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				return customError.New(customError.CodeRateLimited)
+			}
+
+			if strictest == nil || result.Remaining < strictest.Remaining {
+				strictest = &result
+			}
+		}
+
+		if strictest != nil {
+			setRateLimitHeader(ctx, *strictest)
+		}
+
+		return ctx.Next()
+	}
+}
+
+// rateLimitKey return identity of client for key type, false when identity not found
+func rateLimitKey(ctx *fiber.Ctx, keyType string) (string, bool) {
+	switch keyType {
+	case RateLimitKeyIP:
+		return ctx.IP(), true
+	case RateLimitKeyEmail:
+		var request struct {
+			Email string `json:"email"`
+		}
+		if err := ctx.BodyParser(&request); err != nil || request.Email == "" {
+			return "", false
+		}
+
+		return strings.ToLower(strings.TrimSpace(request.Email)), true
+	case RateLimitKeySubject:
+		accountID := ctx.Locals(logging.AccountIDKey)
+		if accountID == nil {
+			return "", false
+		}
+
+		return fmt.Sprint(accountID), true
+	}
+
+	return "", false
+}
+
+func setRateLimitHeader(ctx *fiber.Ctx, result ratelimit.Result) {
+	ctx.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+	ctx.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	ctx.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"regexp"
+)
+
+const HeaderRequestID = fiber.HeaderXRequestID
+
+// request id from client only accepted when safe to put in log and header
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDMiddleware accept X-Request-ID from client or generate new one, echo it in response,
+// and start root span of request tagged with the request id
+func RequestIDMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		requestID := ctx.Get(HeaderRequestID)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Set(HeaderRequestID, requestID)
+		ctx.Locals(logging.RequestIDKey, requestID)
+
+		span := opentracing.StartSpan(ctx.Method() + " " + ctx.Path())
+		defer span.Finish()
+
+		ext.SpanKindRPCServer.Set(span)
+		ext.HTTPMethod.Set(span, ctx.Method())
+		ext.HTTPUrl.Set(span, ctx.OriginalURL())
+		span.SetTag("request_id", requestID)
+		ctx.Locals(tracing.SpanKey, span)
+
+		err := handleChainError(ctx, ctx.Next())
+
+		// route template and status only known after handler
+		status := ctx.Response().StatusCode()
+		span.SetOperationName(ctx.Method() + " " + ctx.Route().Path)
+		ext.HTTPStatusCode.Set(span, uint16(status))
+		if status >= fiber.StatusInternalServerError {
+			ext.Error.Set(span, true)
+		}
+
+		return err
+	}
+}
+
+// handleChainError write error response right away, so middleware can read final status of request.
+// error only returned when error handler itself failed
+func handleChainError(ctx *fiber.Ctx, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return ctx.App().ErrorHandler(ctx, err)
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"cobaMetrics/app/customError"
+	jwtModel "cobaMetrics/app/model/jwt"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"slices"
+)
+
+// ScopeMiddleware require api key or token of oauth client granted scope, login token always allowed. must be placed after AuthMiddleware
+func ScopeMiddleware(logger *slog.Logger) func(scope string) fiber.Handler {
+	return func(scope string) fiber.Handler {
+		return func(ctx *fiber.Ctx) error {
+			claims, ok := ctx.Locals(ClaimsKey).(*jwtModel.Claims)
+			if !ok {
+				return customError.New(customError.CodeAuthTokenRequired)
+			}
+
+			if !claims.IsUser() && !slices.Contains(claims.Scopes, scope) {
+				logger.WarnContext(ctx.Context(), "api key scope missing", slog.String("scope", scope))
+				return customError.New(customError.CodeApiKeyScopeMissing)
+			}
+
+			return ctx.Next()
+		}
+	}
+}
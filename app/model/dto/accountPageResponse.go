@@ -0,0 +1,12 @@
+package dto
+
+// AccountPageResponse is one page of list account
+type AccountPageResponse struct {
+	Count int                     `json:"count"`
+	Data  []AccountDetailResponse `json:"data"`
+	// NextCursor sent as query cursor to get next page, empty when HasMore false
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	// Total is count of every account match filter, only when include_total asked
+	Total *int `json:"total,omitempty"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+// AccountSearchResult is account found by search, without password
+type AccountSearchResult struct {
+	Id        int     `json:"id"`
+	Email     string  `json:"email"`
+	Username  string  `json:"username"`
+	CreatedAt string  `json:"created_at,omitempty"`
+	Rank      float64 `json:"rank"`
+	// Highlight is html escaped username and email with matched part wrapped in <mark>
+	Highlight AccountSearchHighlight `json:"highlight"`
+}
+
+type AccountSearchHighlight struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+// AccountSearchResponse is one page of search account, most relevant first
+type AccountSearchResponse struct {
+	Count      int                   `json:"count"`
+	Data       []AccountSearchResult `json:"data"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+	HasMore    bool                  `json:"has_more"`
+}
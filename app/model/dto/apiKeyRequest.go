@@ -0,0 +1,13 @@
+package dto
+
+type CreateApiKeyRequest struct {
+	Name   string   `json:"name,omitempty" validate:"required,max=100"`
+	Scopes []string `json:"scopes,omitempty" validate:"required,min=1,dive,oneof=accounts:read"`
+	// 0 mean api key never expired
+	ExpiresInDays int `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=365"`
+	// name of service owning api key, only for api key created by admin
+	Service string `json:"service,omitempty" validate:"required_without=AccountId,excluded_with=AccountId,max=100"`
+
+	// owner of api key, filled from token by handler
+	AccountId *int `json:"-"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+type ApiKeyResponse struct {
+	Id         string   `json:"id"`
+	Prefix     string   `json:"prefix"`
+	Name       string   `json:"name"`
+	Service    string   `json:"service,omitempty"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+}
+
+type CreateApiKeyResponse struct {
+	ApiKeyResponse
+	// full api key, only returned once when created
+	Key string `json:"key"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+// ExportAccountRow is one account of export, password hash never exported
+type ExportAccountRow struct {
+	Id        int    `json:"id"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Verified  bool   `json:"verified"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email,omitempty" validate:"required,email"`
+}
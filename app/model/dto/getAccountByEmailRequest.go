@@ -0,0 +1,5 @@
+package dto
+
+type GetAccountByEmailRequest struct {
+	Email string `json:"email" validate:"email"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+// GetAllAccountRequest is query of list account
+type GetAllAccountRequest struct {
+	// 0 mean default limit of config pagination
+	Limit int `json:"limit,omitempty"`
+	// next_cursor of previous page, empty for first page
+	Cursor string `json:"cursor,omitempty"`
+	// id, created_at, username or email, prefixed with - for descending. empty mean id
+	Sort string `json:"sort,omitempty"`
+
+	EmailPrefix string `json:"email_prefix,omitempty"`
+	// RFC 3339, created_from inclusive and created_to exclusive
+	CreatedFrom string `json:"created_from,omitempty"`
+	CreatedTo   string `json:"created_to,omitempty"`
+
+	// total count need extra query, only counted when asked
+	IncludeTotal bool `json:"include_total,omitempty"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "io"
+
+// format of bulk import and export
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ImportAccountRequest is body of bulk import, read row by row so file never fully decoded in memory
+type ImportAccountRequest struct {
+	// FormatCSV need header with column email, username and password, FormatNDJSON is one AddUserRequest per line
+	Format string
+	// DryRun validate and check conflict of every row without hash password and insert
+	DryRun bool
+	Body   io.Reader
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "cobaMetrics/app/customError"
+
+// status of one row of import
+const (
+	ImportStatusCreated = "created"
+	// row pass every check in dry run
+	ImportStatusValid  = "valid"
+	ImportStatusFailed = "failed"
+)
+
+// ImportAccountRowResult is result of one row, Code is error code of catalog when row failed
+type ImportAccountRowResult struct {
+	// Line is line number in file, header of csv is line 1
+	Line     int                      `json:"line"`
+	Email    string                   `json:"email,omitempty"`
+	Username string                   `json:"username,omitempty"`
+	Status   string                   `json:"status"`
+	Id       int                      `json:"id,omitempty"`
+	Code     string                   `json:"code,omitempty"`
+	Message  string                   `json:"message,omitempty"`
+	Errors   []customError.FieldError `json:"errors,omitempty"`
+}
+
+// ImportAccountResponse is report of import, rows in same order as file
+type ImportAccountResponse struct {
+	DryRun  bool `json:"dry_run"`
+	Total   int  `json:"total"`
+	Created int  `json:"created"`
+	Valid   int  `json:"valid"`
+	Failed  int  `json:"failed"`
+	// Truncated true when file has more row than import_max_rows, row after limit not read
+	Truncated bool                     `json:"truncated"`
+	Rows      []ImportAccountRowResult `json:"rows"`
+}
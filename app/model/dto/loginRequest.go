@@ -3,4 +3,6 @@ package dto
 type LoginRequest struct {
 	Email    string `json:"email,omitempty" validate:"required,email"`
 	Password string `json:"password,omitempty" validate:"required,min=6"`
+
+	Client SessionClient `json:"-"`
 }
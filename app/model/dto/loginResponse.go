@@ -3,4 +3,8 @@ package dto
 type LoginResponse struct {
 	Token     string `json:"token,omitempty"`
 	CreatedAt string `json:"created_at,omitempty"`
+
+	// when two factor enabled Token empty, MfaToken exchanged with two factor code for Token
+	MfaRequired bool   `json:"mfa_required,omitempty"`
+	MfaToken    string `json:"mfa_token,omitempty"`
 }
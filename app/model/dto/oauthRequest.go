@@ -0,0 +1,27 @@
+package dto
+
+// OAuthTokenRequest is form body of token endpoint, RFC 6749 section 4.4.2
+type OAuthTokenRequest struct {
+	GrantType string `form:"grant_type"`
+	// space separated, empty mean every scope allowed for client
+	Scope string `form:"scope"`
+
+	// credential of client from form body, or from basic authorization filled by handler
+	ClientId     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// OAuthIntrospectRequest is form body of introspection endpoint, RFC 7662 section 2.1
+type OAuthIntrospectRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+
+	// credential of client calling introspection, from form body or basic authorization
+	ClientId     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type CreateOAuthClientRequest struct {
+	Name   string   `json:"name,omitempty" validate:"required,max=100"`
+	Scopes []string `json:"scopes,omitempty" validate:"required,min=1,dive,oneof=accounts:read"`
+}
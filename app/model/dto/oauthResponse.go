@@ -0,0 +1,43 @@
+package dto
+
+// OAuthTokenResponse is response of token endpoint, RFC 6749 section 5.1
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// lifetime of token in second
+	ExpiresIn int    `json:"expires_in"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+// OAuthErrorResponse is error response of oauth endpoint, RFC 6749 section 5.2
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// OAuthIntrospectResponse is response of introspection endpoint, RFC 7662 section 2.2.
+// inactive token only return active false
+type OAuthIntrospectResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientId  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+}
+
+type OAuthClientResponse struct {
+	ClientId  string   `json:"client_id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type CreateOAuthClientResponse struct {
+	OAuthClientResponse
+	// only returned once when client registered
+	ClientSecret string `json:"client_secret"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// ProblemDetails is error response body following RFC 7807 (application/problem+json)
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Details  any    `json:"details,omitempty"`
+}
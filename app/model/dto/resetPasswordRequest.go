@@ -0,0 +1,11 @@
+package dto
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token,omitempty" validate:"required"`
+	Password        string `json:"password,omitempty" validate:"required,password"`
+	ConfirmPassword string `json:"confirm_password,omitempty" validate:"required,eqfield=Password"`
+
+	// filled by service from account of token, so password policy can check identity
+	Email    string `json:"-"`
+	Username string `json:"-"`
+}
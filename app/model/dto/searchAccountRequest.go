@@ -0,0 +1,11 @@
+package dto
+
+// SearchAccountRequest is query of search account
+type SearchAccountRequest struct {
+	// Q is part of username or email, word separated by space must all match
+	Q string `json:"q,omitempty"`
+	// 0 mean default limit of config pagination
+	Limit int `json:"limit,omitempty"`
+	// next_cursor of previous page, empty for first page
+	Cursor string `json:"cursor,omitempty"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+// SessionClient is device info of login request recorded in session, filled by handler from request header
+type SessionClient struct {
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type SessionResponse struct {
+	Id         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+	// true for session of token used in request
+	Current bool `json:"current"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code,omitempty" validate:"required"`
+}
+
+type TwoFactorLoginRequest struct {
+	MfaToken string `json:"mfa_token,omitempty" validate:"required"`
+	// totp code or recovery code
+	Code string `json:"code,omitempty" validate:"required"`
+
+	Client SessionClient `json:"-"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password,omitempty" validate:"required"`
+	// totp code or recovery code
+	Code string `json:"code,omitempty" validate:"required"`
+}
@@ -9,4 +9,89 @@ type Account struct {
 	Password  string    `json:"password"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// failed login since last success login, account locked every time it reach threshold
+	FailedLoginCount int        `json:"failed_login_count"`
+	LockedUntil      *time.Time `json:"locked_until,omitempty"`
+
+	// TokenVersion increased when password reset, access token with older version rejected
+	TokenVersion int `json:"token_version"`
+
+	// VerifiedAt is time email verified, nil for account that not verify email yet
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+
+	// TotpSecret is secret of two factor authentication, filled on enroll but only used after TotpEnabled.
+	// TotpLastStep is last time step used, so one code can not be used twice
+	TotpSecret   string `json:"-"`
+	TotpEnabled  bool   `json:"totp_enabled"`
+	TotpLastStep int64  `json:"-"`
+}
+
+// IsVerified return true when email of account already verified
+func (a *Account) IsVerified() bool {
+	return a.VerifiedAt != nil
+}
+
+// IsLocked return true when account still locked at time now
+func (a *Account) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
+
+// column that list account can be sorted by, id always used as tie breaker
+const (
+	AccountSortId        = "id"
+	AccountSortCreatedAt = "created_at"
+	AccountSortUsername  = "username"
+	AccountSortEmail     = "email"
+)
+
+// AccountListQuery is filter, order and keyset position of list account
+type AccountListQuery struct {
+	Limit      int
+	SortBy     string
+	Descending bool
+
+	// EmailPrefix match start of email, CreatedFrom inclusive and CreatedTo exclusive
+	EmailPrefix string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+
+	// After is key of last row of previous page, nil for first page
+	After *AccountKey
+}
+
+// AccountKey is value of sort column and id of one row, next page start right after it.
+// Value is nil when sorted by id, time.Time for created_at and string for username or email
+type AccountKey struct {
+	Value any
+	Id    int
+}
+
+// Key return key of account in order of sortBy
+func (a *Account) Key(sortBy string) *AccountKey {
+	key := AccountKey{Id: a.Id}
+	switch sortBy {
+	case AccountSortCreatedAt:
+		key.Value = a.CreatedAt
+	case AccountSortUsername:
+		key.Value = a.Username
+	case AccountSortEmail:
+		key.Value = a.Email
+	}
+
+	return &key
+}
+
+// AccountSearchQuery is search of account by username or email, result ordered by rank then id
+type AccountSearchQuery struct {
+	// Terms is lower case term of search, every term must match username or email
+	Terms  []string
+	Limit  int
+	Offset int
+}
+
+// AccountSearchResult is account found by search, higher rank more relevant
+type AccountSearchResult struct {
+	Account Account
+	Rank    float64
 }
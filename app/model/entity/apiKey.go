@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// ScopeAccountsRead allow api key to read account, GET /account and GET /accounts
+const ScopeAccountsRead = "accounts:read"
+
+// ApiKeyScopes is every scope that can be granted to api key
+var ApiKeyScopes = []string{ScopeAccountsRead}
+
+// ApiKey is long lived credential of account or of internal service, only hash of secret stored
+type ApiKey struct {
+	Id         string `json:"id"`
+	Prefix     string `json:"prefix"`
+	SecretHash string `json:"-"`
+	Name       string `json:"name"`
+	// nil for api key owned by service
+	AccountId  *int       `json:"account_id,omitempty"`
+	Service    string     `json:"service,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsActive return true when api key not revoked and not expired at time now
+func (a *ApiKey) IsActive(now time.Time) bool {
+	return a.RevokedAt == nil && (a.ExpiresAt == nil || now.Before(*a.ExpiresAt))
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// EmailVerification is single use token to verify email of account, only sha256 of token stored
+type EmailVerification struct {
+	Id        int        `json:"id"`
+	AccountId int        `json:"account_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsUsable return true when token not used and not expired at time now
+func (e *EmailVerification) IsUsable(now time.Time) bool {
+	return e.UsedAt == nil && now.Before(e.ExpiresAt)
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// OAuthClient is backend registered for OAuth2 client_credentials grant, only hash of secret stored
+type OAuthClient struct {
+	Id         string `json:"id"`
+	SecretHash string `json:"-"`
+	Name       string `json:"name"`
+	// scope that client allowed to request, same scope as api key
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsActive return true when client not revoked
+func (o *OAuthClient) IsActive() bool {
+	return o.RevokedAt == nil
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// PasswordReset is single use token to reset password, only sha256 of token stored
+type PasswordReset struct {
+	Id        int        `json:"id"`
+	AccountId int        `json:"account_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsUsable return true when token not used and not expired at time now
+func (p *PasswordReset) IsUsable(now time.Time) bool {
+	return p.UsedAt == nil && now.Before(p.ExpiresAt)
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// Session is one login of account, id of session embedded in access token as sid claims
+type Session struct {
+	Id         string     `json:"id"`
+	AccountId  int        `json:"account_id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsActive return true when session not revoked and not expired at time now
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -2,9 +2,39 @@ package jwt
 
 import "github.com/golang-jwt/jwt/v5"
 
+// SubjectMfaPending is subject of token issued after password of account with two factor valid,
+// it only can be exchanged with access token in login two factor and rejected by AuthMiddleware
+const SubjectMfaPending = "mfa_pending"
+
+// SubjectClient is subject of token issued to oauth client by client_credentials grant, it carry client_id and space separated scope instead of account
+const SubjectClient = "client"
+
 type Claims struct {
-	Email            string               `json:"email,omitempty"`
+	Id            int    `json:"id,omitempty"`
+	Email         string `json:"email,omitempty"`
+	TokenVersion  int    `json:"token_version,omitempty"`
+	EmailVerified bool   `json:"email_verified"`
+	SessionId     string `json:"sid,omitempty"`
+	ClientId      string `json:"client_id,omitempty"`
+	Scope         string `json:"scope,omitempty"`
+	// failed login count of account when mfa_pending token issued, code failed after it counted against the token
+	FailedLoginCount int                  `json:"failed_login_count,omitempty"`
 	RegisteredClaims jwt.RegisteredClaims `json:"registered_claims"`
+
+	// filled only for request authenticated with api key or token of oauth client, never part of token
+	ApiKeyId string   `json:"-"`
+	Service  string   `json:"-"`
+	Scopes   []string `json:"-"`
+}
+
+// IsApiKey return true when principal authenticated with api key instead of login token
+func (c *Claims) IsApiKey() bool {
+	return c.ApiKeyId != ""
+}
+
+// IsUser return true when principal is account logged in with login token, not api key or oauth client
+func (c *Claims) IsUser() bool {
+	return !c.IsApiKey() && c.ClientId == ""
 }
 
 func (c *Claims) GetExpirationTime() (*jwt.NumericDate, error) {
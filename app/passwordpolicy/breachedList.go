@@ -0,0 +1,101 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// prefixLength is length of hash prefix, same as range api of haveibeenpwned
+const prefixLength = 5
+
+// BreachedList is sha1 hash of leaked password grouped by 5 char prefix (k-anonymity range).
+// file format is one uppercase or lowercase sha1 hex per line, optionally followed by ":<count>",
+// same as password dump of haveibeenpwned. empty line and line started with # ignored
+type BreachedList struct {
+	ranges map[string]map[string]struct{}
+	count  int
+}
+
+// LoadBreachedList read breached list from file
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseBreachedList(file)
+}
+
+// ParseBreachedList read breached list from reader
+func ParseBreachedList(reader io.Reader) (*BreachedList, error) {
+	list := &BreachedList{ranges: map[string]map[string]struct{}{}}
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("invalid sha1 hash on line %v of breached list", line)
+		}
+
+		list.add(hash)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (b *BreachedList) add(hash string) {
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	suffixes, ok := b.ranges[prefix]
+	if !ok {
+		suffixes = map[string]struct{}{}
+		b.ranges[prefix] = suffixes
+	}
+
+	if _, ok = suffixes[suffix]; !ok {
+		suffixes[suffix] = struct{}{}
+		b.count++
+	}
+}
+
+// Range return suffix of every hash with prefix
+func (b *BreachedList) Range(prefix string) []string {
+	prefix = strings.ToUpper(prefix)
+
+	suffixes := make([]string, 0, len(b.ranges[prefix]))
+	for suffix := range b.ranges[prefix] {
+		suffixes = append(suffixes, suffix)
+	}
+
+	return suffixes
+}
+
+// Contains return true when password found in breached list
+func (b *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, ok := b.ranges[hash[:prefixLength]][hash[prefixLength:]]
+	return ok
+}
+
+// Len return number of hash in list
+func (b *BreachedList) Len() int {
+	return b.count
+}
@@ -0,0 +1,134 @@
+package passwordpolicy
+
+import (
+	"cobaMetrics/app/config"
+	"strings"
+	"unicode"
+)
+
+// rule violated by password, used as message key suffix
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleUpper     = "upper"
+	RuleLower     = "lower"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleIdentity  = "identity"
+	RuleBreached  = "breached"
+)
+
+// identity shorter than this not checked, too many password contain it by chance
+const minIdentityLength = 3
+
+// Policy is rule of new password
+type Policy struct {
+	MinLength int
+	// MaxLength in byte, bcrypt only use first 72 byte of password
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// DisallowIdentity reject password that contain email or username of account
+	DisallowIdentity bool
+	// Breached is list of leaked password, nil when not checked
+	Breached *BreachedList
+}
+
+// Default is policy of validator without config, same as old min=6 rule plus bcrypt max length
+func Default() *Policy {
+	return &Policy{MinLength: 6, MaxLength: 72}
+}
+
+// function provider, breached list loaded from file when configured
+func NewPolicy(policyConfig *config.PasswordPolicy) (*Policy, error) {
+	policy := &Policy{
+		MinLength:        policyConfig.MinLength,
+		MaxLength:        policyConfig.MaxLength,
+		RequireUpper:     policyConfig.RequireUpper,
+		RequireLower:     policyConfig.RequireLower,
+		RequireDigit:     policyConfig.RequireDigit,
+		RequireSymbol:    policyConfig.RequireSymbol,
+		DisallowIdentity: policyConfig.DisallowIdentity,
+	}
+
+	if policyConfig.BreachedFile != "" {
+		breached, err := LoadBreachedList(policyConfig.BreachedFile)
+		if err != nil {
+			return nil, err
+		}
+
+		policy.Breached = breached
+	}
+
+	return policy, nil
+}
+
+// Check return first rule violated by password, empty when password allowed.
+// identities is email and username of account
+func (p *Policy) Check(password string, identities ...string) string {
+	if len([]rune(password)) < p.MinLength {
+		return RuleMinLength
+	}
+
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return RuleMaxLength
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case p.RequireUpper && !hasUpper:
+		return RuleUpper
+	case p.RequireLower && !hasLower:
+		return RuleLower
+	case p.RequireDigit && !hasDigit:
+		return RuleDigit
+	case p.RequireSymbol && !hasSymbol:
+		return RuleSymbol
+	}
+
+	if p.DisallowIdentity && containsIdentity(password, identities) {
+		return RuleIdentity
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		return RuleBreached
+	}
+
+	return ""
+}
+
+// containsIdentity check username, email and local part of email inside password, case insensitive
+func containsIdentity(password string, identities []string) bool {
+	password = strings.ToLower(password)
+	for _, identity := range identities {
+		identity = strings.ToLower(strings.TrimSpace(identity))
+
+		candidates := []string{identity}
+		if localPart, _, ok := strings.Cut(identity, "@"); ok {
+			candidates = append(candidates, localPart)
+		}
+
+		for _, candidate := range candidates {
+			if len(candidate) >= minIdentityLength && strings.Contains(password, candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often full bucket removed from memory
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is time when bucket full again and safe to remove
+	full time.Time
+}
+
+// MemoryStore keep bucket in memory of one instance
+type MemoryStore struct {
+	Now       func() time.Time
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// function provider
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		Now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (m *MemoryStore) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.Now()
+	m.sweep(now)
+
+	capacity := float64(rule.Capacity())
+	rate := rule.rate()
+
+	current, ok := m.buckets[key]
+	if !ok {
+		current = &bucket{tokens: capacity, last: now}
+		m.buckets[key] = current
+	}
+
+	// refill token since last request
+	current.tokens = math.Min(capacity, current.tokens+now.Sub(current.last).Seconds()*rate)
+	current.last = now
+
+	result := Result{Limit: rule.Capacity()}
+	if current.tokens >= 1 {
+		current.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - current.tokens) / rate)
+	}
+
+	result.Remaining = int(math.Floor(current.tokens))
+	result.ResetAfter = seconds((capacity - current.tokens) / rate)
+	current.full = now.Add(result.ResetAfter)
+
+	return result, nil
+}
+
+// sweep remove bucket that already full, it is same as bucket not exist
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, current := range m.buckets {
+		if !now.Before(current.full) {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.lastSweep = now
+}
+
+// Len return number of bucket in memory
+func (m *MemoryStore) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return len(m.buckets)
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
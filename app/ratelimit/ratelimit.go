@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Rule allow Limit request every Period with token bucket, Burst is bucket capacity (default Limit)
+type Rule struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Capacity return maximum token in bucket
+func (r Rule) Capacity() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+
+	return r.Limit
+}
+
+// rate return token added every second
+func (r Rule) rate() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
+
+// Result is state of bucket after one request taken
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is time until bucket full again
+	ResetAfter time.Duration
+	// RetryAfter is time until next request allowed, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store keep bucket of every key. implement it with shared backend, like redis, when app run more than one instance
+type Store interface {
+	Take(ctx context.Context, key string, rule Rule) (Result, error)
+}
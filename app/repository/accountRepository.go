@@ -2,27 +2,65 @@ package repository
 
 import (
 	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
 	"cobaMetrics/app/model/entity"
 	IRepo "cobaMetrics/app/repository/interface"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/transaction"
 	"context"
 	"database/sql"
 	"encoding/json"
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"strings"
 	"time"
 )
 
+// accountColumns is column selected into entity.Account, order same as accountFields
+const accountColumns = "id, email, username, password, created_at, updated_at, failed_login_count, locked_until, token_version, verified_at, totp_secret, totp_enabled, totp_last_step"
+
+func accountFields(account *entity.Account) []any {
+	return []any{&account.Id, &account.Email, &account.Username, &account.Password, &account.CreatedAt, &account.UpdatedAt, &account.FailedLoginCount, &account.LockedUntil, &account.TokenVersion, &account.VerifiedAt, &account.TotpSecret, &account.TotpEnabled, &account.TotpLastStep}
+}
+
 type AccountRepository struct {
+	DB      *database.Cluster
+	Dialect dialect.Dialect
+	Logger  *slog.Logger
 }
 
 // function provider
-func NewAccountRepository() IRepo.IAccountRepository {
-	return &AccountRepository{}
+func NewAccountRepository(db *database.Cluster, dbDialect dialect.Dialect, logger *slog.Logger) IRepo.IAccountRepository {
+	return &AccountRepository{
+		DB:      db,
+		Dialect: dbDialect,
+		Logger:  logger,
+	}
+}
+
+// executor return transaction in ctx when exist, so repository can run inside or outside transaction
+func (a *AccountRepository) executor(ctx context.Context) transaction.Executor {
+	return transaction.GetExecutor(ctx, a.DB.Writer())
+}
+
+// reader same as executor, but read outside transaction routed to replica
+func (a *AccountRepository) reader(ctx context.Context) transaction.Executor {
+	return transaction.GetExecutor(ctx, a.DB.Reader(ctx))
+}
+
+// internalError log database error and convert it into InternalServerError
+func (a *AccountRepository) internalError(ctx context.Context, operation string, err error) error {
+	a.Logger.ErrorContext(ctx, "account query failed",
+		slog.String("operation", operation),
+		slog.String("error", err.Error()))
+
+	return customError.NewInternalServerError(err.Error())
 }
 
 // method implementasi Add new data account
-func (a *AccountRepository) Add(ctx context.Context, tx *sql.Tx, input *entity.Account) (*entity.Account, error) {
+func (a *AccountRepository) Add(ctx context.Context, input *entity.Account) (*entity.Account, error) {
 	// tracing
 	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "Repository Add Account")
 	defer span.Finish()
@@ -31,18 +69,28 @@ func (a *AccountRepository) Add(ctx context.Context, tx *sql.Tx, input *entity.A
 	span.LogFields(log.String("request", string(inputJson)))
 
 	query := "INSERT INTO accounts(email, username, password) VALUES (?, ?, ?)"
-	result, err := tx.ExecContext(ctxTracing, query, input.Email, input.Username, input.Password)
-	if err != nil {
-		return nil, customError.NewInternalServerError(err.Error())
-	}
 
-	if row, _ := result.RowsAffected(); row == 0 {
-		return nil, customError.NewInternalServerError("failed to insert new user")
+	// postgres not support LastInsertId, get id with RETURNING
+	if a.Dialect.SupportReturning() {
+		if err := a.executor(ctxTracing).QueryRowContext(ctxTracing, a.Dialect.Rebind(query+" RETURNING id"), input.Email, input.Username, input.Password).Scan(&input.Id); err != nil {
+			return nil, a.internalError(ctxTracing, "Add", err)
+		}
+	} else {
+		result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind(query), input.Email, input.Username, input.Password)
+		if err != nil {
+			return nil, a.internalError(ctxTracing, "Add", err)
+		}
+
+		if row, _ := result.RowsAffected(); row == 0 {
+			return nil, customError.New(customError.CodeAccountInsertFailed)
+		}
+
+		id, _ := result.LastInsertId()
+		input.Id = int(id)
 	}
 
-	id, _ := result.LastInsertId()
-	input.Id = int(id)
 	input.CreatedAt = time.Now()
+	a.DB.MarkWrite(ctx)
 
 	// success
 	responseJson, _ := json.Marshal(&input)
@@ -51,7 +99,7 @@ func (a *AccountRepository) Add(ctx context.Context, tx *sql.Tx, input *entity.A
 }
 
 // method implementasi GetByEmail
-func (a *AccountRepository) GetByEmail(ctx context.Context, tx *sql.Tx, email string) (*entity.Account, error) {
+func (a *AccountRepository) GetByEmail(ctx context.Context, email string) (*entity.Account, error) {
 	// span tracing
 	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository Get By Email")
 	defer span.Finish()
@@ -61,19 +109,19 @@ func (a *AccountRepository) GetByEmail(ctx context.Context, tx *sql.Tx, email st
 		log.String("email", email))
 
 	// execute
-	row := tx.QueryRowContext(ctxTracing, "SELECT id, email, username, password, created_at, updated_at FROM accounts WHERE email = ?", email)
+	row := a.reader(ctxTracing).QueryRowContext(ctxTracing, a.Dialect.Rebind("SELECT "+accountColumns+" FROM accounts WHERE email = ?"), email)
 	if row.Err() != nil {
-		return nil, customError.NewInternalServerError(row.Err().Error())
+		return nil, a.internalError(ctxTracing, "GetByEmail", row.Err())
 	}
 
 	// scan
 	account := entity.Account{}
-	if err := row.Scan(&account.Id, &account.Email, &account.Username, &account.Password, &account.CreatedAt, &account.UpdatedAt); err != nil {
+	if err := row.Scan(accountFields(&account)...); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, customError.NewNotFoundError("record not found")
+			return nil, customError.New(customError.CodeAccountNotFound)
 		}
 
-		return nil, customError.NewInternalServerError(err.Error())
+		return nil, a.internalError(ctxTracing, "GetByEmail", err)
 	}
 
 	// success
@@ -85,8 +133,30 @@ func (a *AccountRepository) GetByEmail(ctx context.Context, tx *sql.Tx, email st
 	return &account, nil
 }
 
+// method implementasi GetById
+func (a *AccountRepository) GetById(ctx context.Context, id int) (*entity.Account, error) {
+	// span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository Get By Id")
+	defer span.Finish()
+
+	span.LogFields(log.Int("id", id))
+
+	row := a.reader(ctxTracing).QueryRowContext(ctxTracing, a.Dialect.Rebind("SELECT "+accountColumns+" FROM accounts WHERE id = ?"), id)
+
+	account := entity.Account{}
+	if err := row.Scan(accountFields(&account)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customError.New(customError.CodeAccountNotFound)
+		}
+
+		return nil, a.internalError(ctxTracing, "GetById", err)
+	}
+
+	return &account, nil
+}
+
 // implementasi method Update data account
-func (a *AccountRepository) Update(ctx context.Context, tx *sql.Tx, input *entity.Account) (*entity.Account, error) {
+func (a *AccountRepository) Update(ctx context.Context, input *entity.Account) (*entity.Account, error) {
 	// start span tracing
 	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository Update")
 	defer span.Finish()
@@ -97,17 +167,18 @@ func (a *AccountRepository) Update(ctx context.Context, tx *sql.Tx, input *entit
 		log.String("request", string(requestJson)))
 
 	// update
-	result, err := tx.ExecContext(ctxTracing, "UPDATE accounts SET email=?, username=?, password=? WHERE id = ?",
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE accounts SET email=?, username=?, password=?, updated_at=CURRENT_TIMESTAMP WHERE id = ?"),
 		input.Email, input.Username, input.Password, input.Id)
 	if err != nil {
-		return nil, customError.NewInternalServerError(err.Error())
+		return nil, a.internalError(ctxTracing, "Update", err)
 	}
 
 	if row, _ := result.RowsAffected(); row == 0 {
-		return nil, customError.NewInternalServerError("failed to update data account")
+		return nil, customError.New(customError.CodeAccountUpdateFailed)
 	}
 
 	input.UpdatedAt = time.Now()
+	a.DB.MarkWrite(ctx)
 
 	// log with tracing
 	responseJson, _ := json.Marshal(&input)
@@ -117,60 +188,503 @@ func (a *AccountRepository) Update(ctx context.Context, tx *sql.Tx, input *entit
 	return input, nil
 }
 
-func (a *AccountRepository) DeleteByEmail(ctx context.Context, tx *sql.Tx, email string) error {
+// UpdatePassword replace password hash of account, without change updated_at
+func (a *AccountRepository) UpdatePassword(ctx context.Context, id int, password string) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository UpdatePassword")
+	defer span.Finish()
+
+	span.LogFields(log.Int("id", id))
+
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE accounts SET password = ?, updated_at = updated_at WHERE id = ?"), password, id)
+	if err != nil {
+		return a.internalError(ctxTracing, "UpdatePassword", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return customError.New(customError.CodeAccountNotFound)
+	}
+
+	a.DB.MarkWrite(ctx)
+	return nil
+}
+
+// ResetPassword replace password, unlock account and increase token version so every issued token revoked
+func (a *AccountRepository) ResetPassword(ctx context.Context, id int, password string) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository ResetPassword")
+	defer span.Finish()
+
+	span.LogFields(log.Int("id", id))
+
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE accounts SET password = ?, token_version = token_version + 1, failed_login_count = 0, locked_until = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?"),
+		password, id)
+	if err != nil {
+		return a.internalError(ctxTracing, "ResetPassword", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return customError.New(customError.CodeAccountNotFound)
+	}
+
+	a.DB.MarkWrite(ctx)
+	return nil
+}
+
+// MarkVerified set verified time of account, account already verified keep its first verified time
+func (a *AccountRepository) MarkVerified(ctx context.Context, id int, verifiedAt time.Time) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository MarkVerified")
+	defer span.Finish()
+
+	span.LogFields(log.Int("id", id))
+
+	if _, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE accounts SET verified_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND verified_at IS NULL"), verifiedAt, id); err != nil {
+		return a.internalError(ctxTracing, "MarkVerified", err)
+	}
+
+	a.DB.MarkWrite(ctx)
+	return nil
+}
+
+// UpdateTotp save two factor secret and state of account, empty secret mean two factor removed
+func (a *AccountRepository) UpdateTotp(ctx context.Context, id int, secret string, enabled bool) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository UpdateTotp")
+	defer span.Finish()
+
+	span.LogFields(log.Int("id", id), log.Bool("enabled", enabled))
+
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE accounts SET totp_secret = ?, totp_enabled = ?, totp_last_step = 0, updated_at = CURRENT_TIMESTAMP WHERE id = ?"), secret, enabled, id)
+	if err != nil {
+		return a.internalError(ctxTracing, "UpdateTotp", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return customError.New(customError.CodeAccountNotFound)
+	}
+
+	a.DB.MarkWrite(ctx)
+	return nil
+}
+
+// UseTotpStep save step of used code, return false when step not newer than last used step,
+// it is checked in query so same code used by concurrent request accepted only once. used step not change updated_at
+func (a *AccountRepository) UseTotpStep(ctx context.Context, id int, step int64) (bool, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository UseTotpStep")
+	defer span.Finish()
+
+	span.LogFields(log.Int("id", id), log.Int64("step", step))
+
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE accounts SET totp_last_step = ?, updated_at = updated_at WHERE id = ? AND totp_last_step < ?"), step, id, step)
+	if err != nil {
+		return false, a.internalError(ctxTracing, "UseTotpStep", err)
+	}
+
+	a.DB.MarkWrite(ctx)
+	row, _ := result.RowsAffected()
+	return row > 0, nil
+}
+
+// UpdateLoginState save failed login count and lock time of account, nil lockedUntil mean not locked.
+// login state not change updated_at, it is sort key of account list and part of export
+func (a *AccountRepository) UpdateLoginState(ctx context.Context, id int, failedLoginCount int, lockedUntil *time.Time) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository UpdateLoginState")
+	defer span.Finish()
+
+	span.LogFields(
+		log.Int("id", id),
+		log.Int("failed_login_count", failedLoginCount))
+
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE accounts SET failed_login_count = ?, locked_until = ?, updated_at = updated_at WHERE id = ?"),
+		failedLoginCount, lockedUntil, id)
+	if err != nil {
+		return a.internalError(ctxTracing, "UpdateLoginState", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return customError.New(customError.CodeAccountNotFound)
+	}
+
+	a.DB.MarkWrite(ctx)
+	return nil
+}
+
+// IncrementFailedLogin add one to failed login count in query and return the stored count.
+// run it inside transaction, row stay locked until commit so concurrent failure counted one by one
+func (a *AccountRepository) IncrementFailedLogin(ctx context.Context, id int) (int, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository IncrementFailedLogin")
+	defer span.Finish()
+
+	span.LogFields(log.Int("id", id))
+
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE accounts SET failed_login_count = failed_login_count + 1, updated_at = updated_at WHERE id = ?"), id)
+	if err != nil {
+		return 0, a.internalError(ctxTracing, "IncrementFailedLogin", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return 0, customError.New(customError.CodeAccountNotFound)
+	}
+
+	var failedLoginCount int
+	if err = a.executor(ctxTracing).QueryRowContext(ctxTracing, a.Dialect.Rebind("SELECT failed_login_count FROM accounts WHERE id = ?"), id).Scan(&failedLoginCount); err != nil {
+		return 0, a.internalError(ctxTracing, "IncrementFailedLogin", err)
+	}
+
+	a.DB.MarkWrite(ctx)
+	span.LogFields(log.Int("failed_login_count", failedLoginCount))
+	return failedLoginCount, nil
+}
+
+func (a *AccountRepository) DeleteByEmail(ctx context.Context, email string) error {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (a *AccountRepository) GetAll(ctx context.Context, tx *sql.Tx, limit int, offset int) ([]entity.Account, error) {
+// GetAll return account after query.After in order of query, at most query.Limit row
+func (a *AccountRepository) GetAll(ctx context.Context, query *entity.AccountListQuery) ([]entity.Account, error) {
 	// start tracing
 	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository GetAll")
 	defer span.Finish()
 
 	// log with tracing
-	req := map[string]int{
-		"limit":  limit,
-		"offset": offset,
+	reqJson, _ := json.Marshal(query)
+	span.LogFields(log.String("request", string(reqJson)))
+
+	where, args := a.listCondition(query, true)
+
+	direction := "ASC"
+	if query.Descending {
+		direction = "DESC"
 	}
 
-	reqJson, _ := json.Marshal(&req)
-	span.LogFields(log.String("request", string(reqJson)))
+	orderBy := "accounts.id " + direction
+	if column := sortColumn(query.SortBy); column != "accounts.id" {
+		orderBy = column + " " + direction + ", " + orderBy
+	}
 
 	// query
-	rows, err := tx.QueryContext(ctxTracing, "SELECT id, email, username, password, created_at, updated_at FROM accounts ORDER BY accounts.id LIMIT ? OFFSET ?", limit, offset)
+	rows, err := a.reader(ctxTracing).QueryContext(ctxTracing, a.Dialect.Rebind("SELECT "+accountColumns+" FROM accounts"+where+" ORDER BY "+orderBy+" LIMIT ?"), append(args, query.Limit)...)
 	if err != nil {
 		// log error
 		span.LogFields(log.String("response", err.Error()))
-		return nil, customError.NewInternalServerError(err.Error())
+		return nil, a.internalError(ctxTracing, "GetAll", err)
+	}
+	defer rows.Close()
+
+	var accounts []entity.Account
+	for rows.Next() {
+		var account entity.Account
+		if err = rows.Scan(accountFields(&account)...); err != nil {
+			span.LogFields(log.String("response", err.Error()))
+			return nil, a.internalError(ctxTracing, "GetAll", err)
+		}
+
+		// append to accounts
+		accounts = append(accounts, account)
 	}
 
 	if rows.Err() != nil {
 		// log with tracing
 		span.LogFields(log.String("response", rows.Err().Error()))
-		return nil, customError.NewInternalServerError(rows.Err().Error())
+		return nil, a.internalError(ctxTracing, "GetAll", rows.Err())
+	}
+
+	span.LogFields(log.Int("count", len(accounts)))
+	return accounts, nil
+}
+
+// Count return number of account match filter of query, position and limit ignored
+func (a *AccountRepository) Count(ctx context.Context, query *entity.AccountListQuery) (int, error) {
+	// start tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository Count")
+	defer span.Finish()
+
+	where, args := a.listCondition(query, false)
+
+	var total int
+	row := a.reader(ctxTracing).QueryRowContext(ctxTracing, a.Dialect.Rebind("SELECT COUNT(*) FROM accounts"+where), args...)
+	if err := row.Scan(&total); err != nil {
+		span.LogFields(log.String("response", err.Error()))
+		return 0, a.internalError(ctxTracing, "Count", err)
+	}
+
+	span.LogFields(log.Int("total", total))
+	return total, nil
+}
+
+// Search return account match every term of query, ranked by relevance.
+// dialect with full text use MATCH AGAINST, other dialect and term too short for full text index use LIKE
+func (a *AccountRepository) Search(ctx context.Context, query *entity.AccountSearchQuery) ([]entity.AccountSearchResult, error) {
+	// start tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository Search")
+	defer span.Finish()
+
+	var rank, where string
+	var args []any
+	if booleanQuery, ok := fullTextQuery(query.Terms); ok && a.Dialect.SupportFullText() {
+		rank = "MATCH(accounts.username, accounts.email) AGAINST (? IN BOOLEAN MODE)"
+		where = rank
+		args = []any{booleanQuery, booleanQuery}
+	} else {
+		rank, where, args = likeSearch(query.Terms)
+	}
+
+	span.LogFields(log.String("condition", where))
+
+	rows, err := a.reader(ctxTracing).QueryContext(ctxTracing, a.Dialect.Rebind("SELECT "+accountColumns+", "+rank+" AS search_rank FROM accounts WHERE "+where+" ORDER BY search_rank DESC, accounts.id LIMIT ? OFFSET ?"), append(args, query.Limit, query.Offset)...)
+	if err != nil {
+		span.LogFields(log.String("response", err.Error()))
+		return nil, a.internalError(ctxTracing, "Search", err)
+	}
+	defer rows.Close()
+
+	var results []entity.AccountSearchResult
+	for rows.Next() {
+		var result entity.AccountSearchResult
+		if err = rows.Scan(append(accountFields(&result.Account), &result.Rank)...); err != nil {
+			span.LogFields(log.String("response", err.Error()))
+			return nil, a.internalError(ctxTracing, "Search", err)
+		}
+
+		results = append(results, result)
+	}
+
+	if rows.Err() != nil {
+		span.LogFields(log.String("response", rows.Err().Error()))
+		return nil, a.internalError(ctxTracing, "Search", rows.Err())
 	}
 
+	span.LogFields(log.Int("count", len(results)))
+	return results, nil
+}
+
+// GetByEmailsOrUsernames return account that email is one of emails or username is one of usernames.
+// read from primary, result used to check conflict right before insert
+func (a *AccountRepository) GetByEmailsOrUsernames(ctx context.Context, emails []string, usernames []string) ([]entity.Account, error) {
+	// start tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository GetByEmailsOrUsernames")
+	defer span.Finish()
+
+	span.LogFields(log.Int("emails", len(emails)), log.Int("usernames", len(usernames)))
+
+	var conditions []string
+	var args []any
+	if len(emails) > 0 {
+		conditions = append(conditions, "email IN ("+placeholders(len(emails))+")")
+		for _, email := range emails {
+			args = append(args, email)
+		}
+	}
+	if len(usernames) > 0 {
+		conditions = append(conditions, "username IN ("+placeholders(len(usernames))+")")
+		for _, username := range usernames {
+			args = append(args, username)
+		}
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	rows, err := a.executor(ctxTracing).QueryContext(ctxTracing, a.Dialect.Rebind("SELECT "+accountColumns+" FROM accounts WHERE "+strings.Join(conditions, " OR ")), args...)
+	if err != nil {
+		span.LogFields(log.String("response", err.Error()))
+		return nil, a.internalError(ctxTracing, "GetByEmailsOrUsernames", err)
+	}
+	defer rows.Close()
+
 	var accounts []entity.Account
 	for rows.Next() {
 		var account entity.Account
-		if err = rows.Scan(&account.Id, &account.Email, &account.Username, &account.Password, &account.CreatedAt, &account.UpdatedAt); err != nil {
+		if err = rows.Scan(accountFields(&account)...); err != nil {
+			span.LogFields(log.String("response", err.Error()))
+			return nil, a.internalError(ctxTracing, "GetByEmailsOrUsernames", err)
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	if rows.Err() != nil {
+		span.LogFields(log.String("response", rows.Err().Error()))
+		return nil, a.internalError(ctxTracing, "GetByEmailsOrUsernames", rows.Err())
+	}
+
+	span.LogFields(log.Int("count", len(accounts)))
+	return accounts, nil
+}
+
+// AddBatch insert every account with one statement and fill Id of each element.
+// run it inside transaction, id read back by email so batch must not contain same email twice
+func (a *AccountRepository) AddBatch(ctx context.Context, accounts []entity.Account) error {
+	// start tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountRepository AddBatch")
+	defer span.Finish()
+
+	span.LogFields(log.Int("count", len(accounts)))
+
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	values := make([]string, len(accounts))
+	args := make([]any, 0, len(accounts)*3)
+	emails := make([]any, len(accounts))
+	for i, account := range accounts {
+		values[i] = "(?, ?, ?)"
+		args = append(args, account.Email, account.Username, account.Password)
+		emails[i] = account.Email
+	}
+
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("INSERT INTO accounts(email, username, password) VALUES "+strings.Join(values, ", ")), args...)
+	if err != nil {
+		span.LogFields(log.String("response", err.Error()))
+		return a.internalError(ctxTracing, "AddBatch", err)
+	}
+
+	if row, _ := result.RowsAffected(); row != int64(len(accounts)) {
+		return customError.New(customError.CodeAccountInsertFailed)
+	}
+
+	// LastInsertId of multi row insert not portable, read id back by email
+	rows, err := a.executor(ctxTracing).QueryContext(ctxTracing, a.Dialect.Rebind("SELECT id, email FROM accounts WHERE email IN ("+placeholders(len(emails))+")"), emails...)
+	if err != nil {
+		span.LogFields(log.String("response", err.Error()))
+		return a.internalError(ctxTracing, "AddBatch", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int, len(accounts))
+	for rows.Next() {
+		var id int
+		var email string
+		if err = rows.Scan(&id, &email); err != nil {
 			span.LogFields(log.String("response", err.Error()))
+			return a.internalError(ctxTracing, "AddBatch", err)
+		}
+
+		ids[email] = id
+	}
+
+	if rows.Err() != nil {
+		span.LogFields(log.String("response", rows.Err().Error()))
+		return a.internalError(ctxTracing, "AddBatch", rows.Err())
+	}
+
+	now := time.Now()
+	for i := range accounts {
+		accounts[i].Id = ids[accounts[i].Email]
+		accounts[i].CreatedAt = now
+		accounts[i].UpdatedAt = now
+	}
 
-			// if error not found data
-			if err == sql.ErrNoRows {
-				return nil, customError.NewNotFoundError(err.Error())
+	a.DB.MarkWrite(ctx)
+	return nil
+}
+
+// placeholders return n placeholder separated by comma, for IN and VALUES
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// minFullTextWord is innodb_ft_min_token_size default, shorter word not in full text index
+const minFullTextWord = 3
+
+// fullTextQuery return boolean mode query that require prefix of every word of terms,
+// false when some word too short to be found in full text index
+func fullTextQuery(terms []string) (string, bool) {
+	var words []string
+	for _, term := range terms {
+		for _, word := range helper.SearchWords(term) {
+			if len(word) < minFullTextWord {
+				return "", false
 			}
 
-			return nil, customError.NewInternalServerError(err.Error())
+			words = append(words, "+"+word+"*")
 		}
+	}
 
-		// append to accounts
-		accounts = append(accounts, account)
+	return strings.Join(words, " "), len(words) > 0
+}
+
+// likeSearch return rank and condition of search with LIKE. every term must be part of username or email,
+// exact username ranked highest, then username prefix, part of username and part of email
+func likeSearch(terms []string) (string, string, []any) {
+	var ranks, conditions []string
+	var rankArgs, conditionArgs []any
+	for _, term := range terms {
+		escaped := likeEscaper.Replace(term)
+
+		ranks = append(ranks, "CASE WHEN LOWER(accounts.username) = ? THEN 4 WHEN LOWER(accounts.username) LIKE ? ESCAPE '!' THEN 3 WHEN LOWER(accounts.username) LIKE ? ESCAPE '!' THEN 2 ELSE 0 END + CASE WHEN LOWER(accounts.email) LIKE ? ESCAPE '!' THEN 1 ELSE 0 END")
+		rankArgs = append(rankArgs, term, escaped+"%", "%"+escaped+"%", "%"+escaped+"%")
+
+		conditions = append(conditions, "(LOWER(accounts.username) LIKE ? ESCAPE '!' OR LOWER(accounts.email) LIKE ? ESCAPE '!')")
+		conditionArgs = append(conditionArgs, "%"+escaped+"%", "%"+escaped+"%")
 	}
 
-	// log response with tracing
-	resJson, _ := json.Marshal(&accounts)
-	span.LogFields(log.String("response", string(resJson)))
+	return "(" + strings.Join(ranks, " + ") + ")", strings.Join(conditions, " AND "), append(rankArgs, conditionArgs...)
+}
+
+// listCondition build where clause of filter of query, and keyset position when withKey true
+func (a *AccountRepository) listCondition(query *entity.AccountListQuery, withKey bool) (string, []any) {
+	var conditions []string
+	var args []any
 
-	return accounts, nil
+	if query.EmailPrefix != "" {
+		// % and _ in prefix matched literally
+		conditions = append(conditions, "accounts.email LIKE ? ESCAPE '!'")
+		args = append(args, likeEscaper.Replace(query.EmailPrefix)+"%")
+	}
+	if query.CreatedFrom != nil {
+		conditions = append(conditions, "accounts.created_at >= ?")
+		args = append(args, a.Dialect.Time(*query.CreatedFrom))
+	}
+	if query.CreatedTo != nil {
+		conditions = append(conditions, "accounts.created_at < ?")
+		args = append(args, a.Dialect.Time(*query.CreatedTo))
+	}
+
+	// row after key in sort order, id break tie of same sort value
+	if withKey && query.After != nil {
+		operator := ">"
+		if query.Descending {
+			operator = "<"
+		}
+
+		column := sortColumn(query.SortBy)
+		if column == "accounts.id" {
+			conditions = append(conditions, "accounts.id "+operator+" ?")
+			args = append(args, query.After.Id)
+		} else {
+			value := query.After.Value
+			if createdAt, ok := value.(time.Time); ok {
+				value = a.Dialect.Time(createdAt)
+			}
+
+			conditions = append(conditions, "("+column+" "+operator+" ? OR ("+column+" = ? AND accounts.id "+operator+" ?))")
+			args = append(args, value, value, query.After.Id)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// likeEscaper escape wildcard of LIKE pattern with escape character !
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// sortColumn return column of sort name, unknown name sorted by id
+func sortColumn(sortBy string) string {
+	switch sortBy {
+	case entity.AccountSortCreatedAt, entity.AccountSortUsername, entity.AccountSortEmail:
+		return "accounts." + sortBy
+	default:
+		return "accounts.id"
+	}
 }
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/transaction"
+	"context"
+	"database/sql"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// apiKeyColumns is column selected into entity.ApiKey, order same as scanApiKey
+const apiKeyColumns = "id, prefix, secret_hash, name, account_id, service, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanApiKey scan one row of apiKeyColumns, scopes stored as space separated string
+func scanApiKey(row rowScanner) (*entity.ApiKey, error) {
+	apiKey := entity.ApiKey{}
+	var accountId sql.NullInt64
+	var scopes string
+	if err := row.Scan(&apiKey.Id, &apiKey.Prefix, &apiKey.SecretHash, &apiKey.Name, &accountId, &apiKey.Service, &scopes, &apiKey.CreatedAt, &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.RevokedAt); err != nil {
+		return nil, err
+	}
+
+	if accountId.Valid {
+		id := int(accountId.Int64)
+		apiKey.AccountId = &id
+	}
+	apiKey.Scopes = strings.Fields(scopes)
+
+	return &apiKey, nil
+}
+
+// ownerCondition return where condition of owner of api key, nil account id mean owned by service
+func ownerCondition(accountId *int) (string, []any) {
+	if accountId == nil {
+		return "account_id IS NULL", nil
+	}
+
+	return "account_id = ?", []any{*accountId}
+}
+
+type ApiKeyRepository struct {
+	DB      *database.Cluster
+	Dialect dialect.Dialect
+	Logger  *slog.Logger
+}
+
+// function provider
+func NewApiKeyRepository(db *database.Cluster, dbDialect dialect.Dialect, logger *slog.Logger) IRepo.IApiKeyRepository {
+	return &ApiKeyRepository{
+		DB:      db,
+		Dialect: dbDialect,
+		Logger:  logger,
+	}
+}
+
+// api key always read from primary, revoked api key must be rejected immediately
+func (a *ApiKeyRepository) executor(ctx context.Context) transaction.Executor {
+	return transaction.GetExecutor(ctx, a.DB.Writer())
+}
+
+func (a *ApiKeyRepository) internalError(ctx context.Context, operation string, err error) error {
+	a.Logger.ErrorContext(ctx, "api key query failed",
+		slog.String("operation", operation),
+		slog.String("error", err.Error()))
+
+	return customError.NewInternalServerError(err.Error())
+}
+
+// method implementasi Add new api key
+func (a *ApiKeyRepository) Add(ctx context.Context, input *entity.ApiKey) (*entity.ApiKey, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "ApiKeyRepository Add")
+	defer span.Finish()
+
+	span.LogFields(log.String("prefix", input.Prefix))
+
+	_, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("INSERT INTO api_keys(id, prefix, secret_hash, name, account_id, service, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		input.Id, input.Prefix, input.SecretHash, input.Name, input.AccountId, input.Service, strings.Join(input.Scopes, " "), input.CreatedAt, input.ExpiresAt)
+	if err != nil {
+		return nil, a.internalError(ctxTracing, "Add", err)
+	}
+
+	return input, nil
+}
+
+// method implementasi GetByPrefix, prefix is visible part of api key
+func (a *ApiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entity.ApiKey, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "ApiKeyRepository GetByPrefix")
+	defer span.Finish()
+
+	span.LogFields(log.String("prefix", prefix))
+
+	row := a.executor(ctxTracing).QueryRowContext(ctxTracing, a.Dialect.Rebind("SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = ?"), prefix)
+
+	apiKey, err := scanApiKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customError.New(customError.CodeApiKeyNotFound)
+		}
+
+		return nil, a.internalError(ctxTracing, "GetByPrefix", err)
+	}
+
+	return apiKey, nil
+}
+
+// GetAll return api key of owner that not revoked, newest first. expired api key still returned so owner can see it
+func (a *ApiKeyRepository) GetAll(ctx context.Context, accountId *int) ([]entity.ApiKey, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "ApiKeyRepository GetAll")
+	defer span.Finish()
+
+	condition, args := ownerCondition(accountId)
+	rows, err := a.executor(ctxTracing).QueryContext(ctxTracing, a.Dialect.Rebind("SELECT "+apiKeyColumns+" FROM api_keys WHERE "+condition+" AND revoked_at IS NULL ORDER BY created_at DESC"), args...)
+	if err != nil {
+		return nil, a.internalError(ctxTracing, "GetAll", err)
+	}
+	defer rows.Close()
+
+	var apiKeys []entity.ApiKey
+	for rows.Next() {
+		apiKey, err := scanApiKey(rows)
+		if err != nil {
+			return nil, a.internalError(ctxTracing, "GetAll", err)
+		}
+
+		apiKeys = append(apiKeys, *apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, a.internalError(ctxTracing, "GetAll", err)
+	}
+
+	return apiKeys, nil
+}
+
+// Touch update last used of api key
+func (a *ApiKeyRepository) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "ApiKeyRepository Touch")
+	defer span.Finish()
+
+	if _, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE api_keys SET last_used_at = ? WHERE id = ?"), lastUsedAt, id); err != nil {
+		return a.internalError(ctxTracing, "Touch", err)
+	}
+
+	return nil
+}
+
+// Revoke api key of owner, error not found when api key belong to other owner or already revoked
+func (a *ApiKeyRepository) Revoke(ctx context.Context, accountId *int, id string, revokedAt time.Time) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "ApiKeyRepository Revoke")
+	defer span.Finish()
+
+	span.LogFields(log.String("id", id))
+
+	condition, args := ownerCondition(accountId)
+	result, err := a.executor(ctxTracing).ExecContext(ctxTracing, a.Dialect.Rebind("UPDATE api_keys SET revoked_at = ? WHERE id = ? AND "+condition+" AND revoked_at IS NULL"), append([]any{revokedAt, id}, args...)...)
+	if err != nil {
+		return a.internalError(ctxTracing, "Revoke", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return customError.New(customError.CodeApiKeyNotFound)
+	}
+
+	return nil
+}
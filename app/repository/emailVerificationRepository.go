@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/transaction"
+	"context"
+	"database/sql"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"time"
+)
+
+type EmailVerificationRepository struct {
+	DB      *database.Cluster
+	Dialect dialect.Dialect
+	Logger  *slog.Logger
+}
+
+// function provider
+func NewEmailVerificationRepository(db *database.Cluster, dbDialect dialect.Dialect, logger *slog.Logger) IRepo.IEmailVerificationRepository {
+	return &EmailVerificationRepository{
+		DB:      db,
+		Dialect: dbDialect,
+		Logger:  logger,
+	}
+}
+
+// token always read from primary, token just created may not exist yet in replica
+func (p *EmailVerificationRepository) executor(ctx context.Context) transaction.Executor {
+	return transaction.GetExecutor(ctx, p.DB.Writer())
+}
+
+func (p *EmailVerificationRepository) internalError(ctx context.Context, operation string, err error) error {
+	p.Logger.ErrorContext(ctx, "email verification query failed",
+		slog.String("operation", operation),
+		slog.String("error", err.Error()))
+
+	return customError.NewInternalServerError(err.Error())
+}
+
+// method implementasi Add new verification token
+func (p *EmailVerificationRepository) Add(ctx context.Context, input *entity.EmailVerification) (*entity.EmailVerification, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "EmailVerificationRepository Add")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", input.AccountId))
+
+	query := "INSERT INTO email_verifications(account_id, token_hash, expires_at) VALUES (?, ?, ?)"
+
+	if p.Dialect.SupportReturning() {
+		if err := p.executor(ctxTracing).QueryRowContext(ctxTracing, p.Dialect.Rebind(query+" RETURNING id"), input.AccountId, input.TokenHash, input.ExpiresAt).Scan(&input.Id); err != nil {
+			return nil, p.internalError(ctxTracing, "Add", err)
+		}
+	} else {
+		result, err := p.executor(ctxTracing).ExecContext(ctxTracing, p.Dialect.Rebind(query), input.AccountId, input.TokenHash, input.ExpiresAt)
+		if err != nil {
+			return nil, p.internalError(ctxTracing, "Add", err)
+		}
+
+		id, _ := result.LastInsertId()
+		input.Id = int(id)
+	}
+
+	input.CreatedAt = time.Now()
+	return input, nil
+}
+
+// method implementasi GetByTokenHash
+func (p *EmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*entity.EmailVerification, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "EmailVerificationRepository GetByTokenHash")
+	defer span.Finish()
+
+	row := p.executor(ctxTracing).QueryRowContext(ctxTracing, p.Dialect.Rebind("SELECT id, account_id, token_hash, expires_at, used_at, created_at FROM email_verifications WHERE token_hash = ?"), tokenHash)
+
+	emailVerification := entity.EmailVerification{}
+	if err := row.Scan(&emailVerification.Id, &emailVerification.AccountId, &emailVerification.TokenHash, &emailVerification.ExpiresAt, &emailVerification.UsedAt, &emailVerification.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customError.New(customError.CodeVerificationTokenInvalid)
+		}
+
+		return nil, p.internalError(ctxTracing, "GetByTokenHash", err)
+	}
+
+	span.LogFields(log.Int("account_id", emailVerification.AccountId))
+	return &emailVerification, nil
+}
+
+// MarkUsed mark token used, error when token already used so one token can not be used twice concurrently
+func (p *EmailVerificationRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "EmailVerificationRepository MarkUsed")
+	defer span.Finish()
+
+	span.LogFields(log.Int("id", id))
+
+	result, err := p.executor(ctxTracing).ExecContext(ctxTracing, p.Dialect.Rebind("UPDATE email_verifications SET used_at = ? WHERE id = ? AND used_at IS NULL"), usedAt, id)
+	if err != nil {
+		return p.internalError(ctxTracing, "MarkUsed", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return customError.New(customError.CodeVerificationTokenInvalid)
+	}
+
+	return nil
+}
+
+// InvalidateByAccount mark every unused token of account used, so only the newest token can be used
+func (p *EmailVerificationRepository) InvalidateByAccount(ctx context.Context, accountId int, usedAt time.Time) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "EmailVerificationRepository InvalidateByAccount")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	if _, err := p.executor(ctxTracing).ExecContext(ctxTracing, p.Dialect.Rebind("UPDATE email_verifications SET used_at = ? WHERE account_id = ? AND used_at IS NULL"), usedAt, accountId); err != nil {
+		return p.internalError(ctxTracing, "InvalidateByAccount", err)
+	}
+
+	return nil
+}
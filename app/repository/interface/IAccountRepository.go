@@ -3,13 +3,25 @@ package repository
 import (
 	"cobaMetrics/app/model/entity"
 	"context"
-	"database/sql"
+	"time"
 )
 
 type IAccountRepository interface {
-	Add(ctx context.Context, tx *sql.Tx, input *entity.Account) (*entity.Account, error)
-	GetByEmail(ctx context.Context, tx *sql.Tx, email string) (*entity.Account, error)
-	Update(ctx context.Context, tx *sql.Tx, input *entity.Account) (*entity.Account, error)
-	DeleteByEmail(ctx context.Context, tx *sql.Tx, email string) error
-	GetAll(ctx context.Context, tx *sql.Tx, limit int, offset int) ([]entity.Account, error)
+	Add(ctx context.Context, input *entity.Account) (*entity.Account, error)
+	GetByEmail(ctx context.Context, email string) (*entity.Account, error)
+	GetById(ctx context.Context, id int) (*entity.Account, error)
+	Update(ctx context.Context, input *entity.Account) (*entity.Account, error)
+	ResetPassword(ctx context.Context, id int, password string) error
+	UpdatePassword(ctx context.Context, id int, password string) error
+	MarkVerified(ctx context.Context, id int, verifiedAt time.Time) error
+	UpdateTotp(ctx context.Context, id int, secret string, enabled bool) error
+	UseTotpStep(ctx context.Context, id int, step int64) (bool, error)
+	UpdateLoginState(ctx context.Context, id int, failedLoginCount int, lockedUntil *time.Time) error
+	IncrementFailedLogin(ctx context.Context, id int) (int, error)
+	DeleteByEmail(ctx context.Context, email string) error
+	GetAll(ctx context.Context, query *entity.AccountListQuery) ([]entity.Account, error)
+	Count(ctx context.Context, query *entity.AccountListQuery) (int, error)
+	Search(ctx context.Context, query *entity.AccountSearchQuery) ([]entity.AccountSearchResult, error)
+	GetByEmailsOrUsernames(ctx context.Context, emails []string, usernames []string) ([]entity.Account, error)
+	AddBatch(ctx context.Context, accounts []entity.Account) error
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"cobaMetrics/app/model/entity"
+	"context"
+	"time"
+)
+
+// accountId nil mean api key owned by service
+type IApiKeyRepository interface {
+	Add(ctx context.Context, input *entity.ApiKey) (*entity.ApiKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*entity.ApiKey, error)
+	GetAll(ctx context.Context, accountId *int) ([]entity.ApiKey, error)
+	Touch(ctx context.Context, id string, lastUsedAt time.Time) error
+	Revoke(ctx context.Context, accountId *int, id string, revokedAt time.Time) error
+}
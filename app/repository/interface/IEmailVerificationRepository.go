@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"cobaMetrics/app/model/entity"
+	"context"
+	"time"
+)
+
+type IEmailVerificationRepository interface {
+	Add(ctx context.Context, input *entity.EmailVerification) (*entity.EmailVerification, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*entity.EmailVerification, error)
+	MarkUsed(ctx context.Context, id int, usedAt time.Time) error
+	InvalidateByAccount(ctx context.Context, accountId int, usedAt time.Time) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"cobaMetrics/app/model/entity"
+	"context"
+	"time"
+)
+
+type IOAuthClientRepository interface {
+	Add(ctx context.Context, input *entity.OAuthClient) (*entity.OAuthClient, error)
+	GetById(ctx context.Context, id string) (*entity.OAuthClient, error)
+	GetAll(ctx context.Context) ([]entity.OAuthClient, error)
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+}
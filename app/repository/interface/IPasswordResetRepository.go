@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"cobaMetrics/app/model/entity"
+	"context"
+	"time"
+)
+
+type IPasswordResetRepository interface {
+	Add(ctx context.Context, input *entity.PasswordReset) (*entity.PasswordReset, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*entity.PasswordReset, error)
+	MarkUsed(ctx context.Context, id int, usedAt time.Time) error
+	InvalidateByAccount(ctx context.Context, accountId int, usedAt time.Time) error
+}
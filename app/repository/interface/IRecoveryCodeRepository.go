@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type IRecoveryCodeRepository interface {
+	ReplaceByAccount(ctx context.Context, accountId int, codeHashes []string) error
+	Use(ctx context.Context, accountId int, codeHash string, usedAt time.Time) (bool, error)
+	DeleteByAccount(ctx context.Context, accountId int) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"cobaMetrics/app/model/entity"
+	"context"
+	"time"
+)
+
+type ISessionRepository interface {
+	Add(ctx context.Context, input *entity.Session) (*entity.Session, error)
+	GetById(ctx context.Context, id string) (*entity.Session, error)
+	GetActiveByAccount(ctx context.Context, accountId int, now time.Time) ([]entity.Session, error)
+	Touch(ctx context.Context, id string, lastSeenAt time.Time) error
+	Revoke(ctx context.Context, accountId int, id string, revokedAt time.Time) error
+	RevokeByAccount(ctx context.Context, accountId int, revokedAt time.Time) error
+}
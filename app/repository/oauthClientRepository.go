@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/transaction"
+	"context"
+	"database/sql"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// oauthClientColumns is column selected into entity.OAuthClient, order same as scanOAuthClient
+const oauthClientColumns = "id, secret_hash, name, scopes, created_at, revoked_at"
+
+// scanOAuthClient scan one row of oauthClientColumns, scopes stored as space separated string
+func scanOAuthClient(row rowScanner) (*entity.OAuthClient, error) {
+	client := entity.OAuthClient{}
+	var scopes string
+	if err := row.Scan(&client.Id, &client.SecretHash, &client.Name, &scopes, &client.CreatedAt, &client.RevokedAt); err != nil {
+		return nil, err
+	}
+
+	client.Scopes = strings.Fields(scopes)
+	return &client, nil
+}
+
+type OAuthClientRepository struct {
+	DB      *database.Cluster
+	Dialect dialect.Dialect
+	Logger  *slog.Logger
+}
+
+// function provider
+func NewOAuthClientRepository(db *database.Cluster, dbDialect dialect.Dialect, logger *slog.Logger) IRepo.IOAuthClientRepository {
+	return &OAuthClientRepository{
+		DB:      db,
+		Dialect: dbDialect,
+		Logger:  logger,
+	}
+}
+
+// client always read from primary, revoked client must be rejected immediately
+func (o *OAuthClientRepository) executor(ctx context.Context) transaction.Executor {
+	return transaction.GetExecutor(ctx, o.DB.Writer())
+}
+
+func (o *OAuthClientRepository) internalError(ctx context.Context, operation string, err error) error {
+	o.Logger.ErrorContext(ctx, "oauth client query failed",
+		slog.String("operation", operation),
+		slog.String("error", err.Error()))
+
+	return customError.NewInternalServerError(err.Error())
+}
+
+// method implementasi Add new client
+func (o *OAuthClientRepository) Add(ctx context.Context, input *entity.OAuthClient) (*entity.OAuthClient, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthClientRepository Add")
+	defer span.Finish()
+
+	span.LogFields(log.String("client_id", input.Id))
+
+	_, err := o.executor(ctxTracing).ExecContext(ctxTracing, o.Dialect.Rebind("INSERT INTO oauth_clients(id, secret_hash, name, scopes, created_at) VALUES (?, ?, ?, ?, ?)"),
+		input.Id, input.SecretHash, input.Name, strings.Join(input.Scopes, " "), input.CreatedAt)
+	if err != nil {
+		return nil, o.internalError(ctxTracing, "Add", err)
+	}
+
+	return input, nil
+}
+
+// method implementasi GetById, revoked client still returned
+func (o *OAuthClientRepository) GetById(ctx context.Context, id string) (*entity.OAuthClient, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthClientRepository GetById")
+	defer span.Finish()
+
+	span.LogFields(log.String("client_id", id))
+
+	row := o.executor(ctxTracing).QueryRowContext(ctxTracing, o.Dialect.Rebind("SELECT "+oauthClientColumns+" FROM oauth_clients WHERE id = ?"), id)
+
+	client, err := scanOAuthClient(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customError.New(customError.CodeOAuthClientNotFound)
+		}
+
+		return nil, o.internalError(ctxTracing, "GetById", err)
+	}
+
+	return client, nil
+}
+
+// GetAll return client that not revoked, newest first
+func (o *OAuthClientRepository) GetAll(ctx context.Context) ([]entity.OAuthClient, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthClientRepository GetAll")
+	defer span.Finish()
+
+	rows, err := o.executor(ctxTracing).QueryContext(ctxTracing, "SELECT "+oauthClientColumns+" FROM oauth_clients WHERE revoked_at IS NULL ORDER BY created_at DESC")
+	if err != nil {
+		return nil, o.internalError(ctxTracing, "GetAll", err)
+	}
+	defer rows.Close()
+
+	var clients []entity.OAuthClient
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, o.internalError(ctxTracing, "GetAll", err)
+		}
+
+		clients = append(clients, *client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, o.internalError(ctxTracing, "GetAll", err)
+	}
+
+	return clients, nil
+}
+
+// Revoke client, token issued to it rejected right after. error not found when client not exist or already revoked
+func (o *OAuthClientRepository) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthClientRepository Revoke")
+	defer span.Finish()
+
+	span.LogFields(log.String("client_id", id))
+
+	result, err := o.executor(ctxTracing).ExecContext(ctxTracing, o.Dialect.Rebind("UPDATE oauth_clients SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL"), revokedAt, id)
+	if err != nil {
+		return o.internalError(ctxTracing, "Revoke", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return customError.New(customError.CodeOAuthClientNotFound)
+	}
+
+	return nil
+}
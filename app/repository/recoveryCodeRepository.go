@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"cobaMetrics/app/customError"
+	IRepo "cobaMetrics/app/repository/interface"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/transaction"
+	"context"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"time"
+)
+
+type RecoveryCodeRepository struct {
+	DB      *database.Cluster
+	Dialect dialect.Dialect
+	Logger  *slog.Logger
+}
+
+// function provider
+func NewRecoveryCodeRepository(db *database.Cluster, dbDialect dialect.Dialect, logger *slog.Logger) IRepo.IRecoveryCodeRepository {
+	return &RecoveryCodeRepository{
+		DB:      db,
+		Dialect: dbDialect,
+		Logger:  logger,
+	}
+}
+
+// recovery code always read from primary, code just created may not exist yet in replica
+func (r *RecoveryCodeRepository) executor(ctx context.Context) transaction.Executor {
+	return transaction.GetExecutor(ctx, r.DB.Writer())
+}
+
+func (r *RecoveryCodeRepository) internalError(ctx context.Context, operation string, err error) error {
+	r.Logger.ErrorContext(ctx, "recovery code query failed",
+		slog.String("operation", operation),
+		slog.String("error", err.Error()))
+
+	return customError.NewInternalServerError(err.Error())
+}
+
+// ReplaceByAccount delete every recovery code of account then insert new code, should be called inside transaction
+func (r *RecoveryCodeRepository) ReplaceByAccount(ctx context.Context, accountId int, codeHashes []string) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "RecoveryCodeRepository ReplaceByAccount")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId), log.Int("count", len(codeHashes)))
+
+	if err := r.DeleteByAccount(ctxTracing, accountId); err != nil {
+		return err
+	}
+
+	query := r.Dialect.Rebind("INSERT INTO recovery_codes(account_id, code_hash) VALUES (?, ?)")
+	for _, codeHash := range codeHashes {
+		if _, err := r.executor(ctxTracing).ExecContext(ctxTracing, query, accountId, codeHash); err != nil {
+			return r.internalError(ctxTracing, "ReplaceByAccount", err)
+		}
+	}
+
+	return nil
+}
+
+// Use mark unused recovery code of account used, return false when code not exist or already used
+func (r *RecoveryCodeRepository) Use(ctx context.Context, accountId int, codeHash string, usedAt time.Time) (bool, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "RecoveryCodeRepository Use")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	result, err := r.executor(ctxTracing).ExecContext(ctxTracing, r.Dialect.Rebind("UPDATE recovery_codes SET used_at = ? WHERE account_id = ? AND code_hash = ? AND used_at IS NULL"), usedAt, accountId, codeHash)
+	if err != nil {
+		return false, r.internalError(ctxTracing, "Use", err)
+	}
+
+	row, _ := result.RowsAffected()
+	return row > 0, nil
+}
+
+// DeleteByAccount delete every recovery code of account
+func (r *RecoveryCodeRepository) DeleteByAccount(ctx context.Context, accountId int) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "RecoveryCodeRepository DeleteByAccount")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	if _, err := r.executor(ctxTracing).ExecContext(ctxTracing, r.Dialect.Rebind("DELETE FROM recovery_codes WHERE account_id = ?"), accountId); err != nil {
+		return r.internalError(ctxTracing, "DeleteByAccount", err)
+	}
+
+	return nil
+}
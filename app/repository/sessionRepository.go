@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/transaction"
+	"context"
+	"database/sql"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"time"
+)
+
+// sessionColumns is column selected into entity.Session, order same as sessionFields
+const sessionColumns = "id, account_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at"
+
+func sessionFields(session *entity.Session) []any {
+	return []any{&session.Id, &session.AccountId, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt}
+}
+
+type SessionRepository struct {
+	DB      *database.Cluster
+	Dialect dialect.Dialect
+	Logger  *slog.Logger
+}
+
+// function provider
+func NewSessionRepository(db *database.Cluster, dbDialect dialect.Dialect, logger *slog.Logger) IRepo.ISessionRepository {
+	return &SessionRepository{
+		DB:      db,
+		Dialect: dbDialect,
+		Logger:  logger,
+	}
+}
+
+// session always read from primary, revoked session must be rejected immediately
+func (s *SessionRepository) executor(ctx context.Context) transaction.Executor {
+	return transaction.GetExecutor(ctx, s.DB.Writer())
+}
+
+func (s *SessionRepository) internalError(ctx context.Context, operation string, err error) error {
+	s.Logger.ErrorContext(ctx, "session query failed",
+		slog.String("operation", operation),
+		slog.String("error", err.Error()))
+
+	return customError.NewInternalServerError(err.Error())
+}
+
+// method implementasi Add new session
+func (s *SessionRepository) Add(ctx context.Context, input *entity.Session) (*entity.Session, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionRepository Add")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", input.AccountId))
+
+	_, err := s.executor(ctxTracing).ExecContext(ctxTracing, s.Dialect.Rebind("INSERT INTO sessions(id, account_id, user_agent, ip, created_at, last_seen_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		input.Id, input.AccountId, input.UserAgent, input.IP, input.CreatedAt, input.LastSeenAt, input.ExpiresAt)
+	if err != nil {
+		return nil, s.internalError(ctxTracing, "Add", err)
+	}
+
+	return input, nil
+}
+
+// method implementasi GetById
+func (s *SessionRepository) GetById(ctx context.Context, id string) (*entity.Session, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionRepository GetById")
+	defer span.Finish()
+
+	row := s.executor(ctxTracing).QueryRowContext(ctxTracing, s.Dialect.Rebind("SELECT "+sessionColumns+" FROM sessions WHERE id = ?"), id)
+
+	session := entity.Session{}
+	if err := row.Scan(sessionFields(&session)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customError.New(customError.CodeSessionNotFound)
+		}
+
+		return nil, s.internalError(ctxTracing, "GetById", err)
+	}
+
+	return &session, nil
+}
+
+// GetActiveByAccount return session of account that not revoked and not expired, newest first
+func (s *SessionRepository) GetActiveByAccount(ctx context.Context, accountId int, now time.Time) ([]entity.Session, error) {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionRepository GetActiveByAccount")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	rows, err := s.executor(ctxTracing).QueryContext(ctxTracing, s.Dialect.Rebind("SELECT "+sessionColumns+" FROM sessions WHERE account_id = ? AND revoked_at IS NULL AND expires_at > ? ORDER BY created_at DESC"), accountId, now)
+	if err != nil {
+		return nil, s.internalError(ctxTracing, "GetActiveByAccount", err)
+	}
+	defer rows.Close()
+
+	var sessions []entity.Session
+	for rows.Next() {
+		session := entity.Session{}
+		if err := rows.Scan(sessionFields(&session)...); err != nil {
+			return nil, s.internalError(ctxTracing, "GetActiveByAccount", err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, s.internalError(ctxTracing, "GetActiveByAccount", err)
+	}
+
+	return sessions, nil
+}
+
+// Touch update last seen of session
+func (s *SessionRepository) Touch(ctx context.Context, id string, lastSeenAt time.Time) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionRepository Touch")
+	defer span.Finish()
+
+	if _, err := s.executor(ctxTracing).ExecContext(ctxTracing, s.Dialect.Rebind("UPDATE sessions SET last_seen_at = ? WHERE id = ?"), lastSeenAt, id); err != nil {
+		return s.internalError(ctxTracing, "Touch", err)
+	}
+
+	return nil
+}
+
+// Revoke end active session of account, error not found when session belong to other account or already ended
+func (s *SessionRepository) Revoke(ctx context.Context, accountId int, id string, revokedAt time.Time) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionRepository Revoke")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	result, err := s.executor(ctxTracing).ExecContext(ctxTracing, s.Dialect.Rebind("UPDATE sessions SET revoked_at = ? WHERE id = ? AND account_id = ? AND revoked_at IS NULL"), revokedAt, id, accountId)
+	if err != nil {
+		return s.internalError(ctxTracing, "Revoke", err)
+	}
+
+	if row, _ := result.RowsAffected(); row == 0 {
+		return customError.New(customError.CodeSessionNotFound)
+	}
+
+	return nil
+}
+
+// RevokeByAccount end every active session of account
+func (s *SessionRepository) RevokeByAccount(ctx context.Context, accountId int, revokedAt time.Time) error {
+	// tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionRepository RevokeByAccount")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	if _, err := s.executor(ctxTracing).ExecContext(ctxTracing, s.Dialect.Rebind("UPDATE sessions SET revoked_at = ? WHERE account_id = ? AND revoked_at IS NULL"), revokedAt, accountId); err != nil {
+		return s.internalError(ctxTracing, "RevokeByAccount", err)
+	}
+
+	return nil
+}
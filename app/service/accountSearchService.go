@@ -0,0 +1,131 @@
+package service
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/metrics"
+	"context"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// limit of query q, longer query rejected before sent to database
+const (
+	maxSearchLength = 100
+	maxSearchTerms  = 5
+)
+
+// searchCursor is content of opaque cursor of search. ranked result has no stable key, so cursor hold offset of next page
+// and query it made for
+type searchCursor struct {
+	Query  string `json:"q"`
+	Offset int    `json:"o"`
+}
+
+type AccountSearchService struct {
+	Config  config.IConfig
+	AccRepo IRepo.IAccountRepository
+	Metrics *metrics.MetricsApp
+	Logger  *slog.Logger
+}
+
+// function provider
+func NewAccountSearchService(config config.IConfig, accRepo IRepo.IAccountRepository, metrics *metrics.MetricsApp, logger *slog.Logger) IService.IAccountSearchService {
+	return &AccountSearchService{
+		Config:  config,
+		AccRepo: accRepo,
+		Metrics: metrics,
+		Logger:  logger,
+	}
+}
+
+// method implementasi Search, page of account match query q ordered by rank
+func (a *AccountSearchService) Search(ctx context.Context, request *dto.SearchAccountRequest) (*dto.AccountSearchResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountSearchService Search")
+	defer span.Finish()
+
+	span.LogFields(log.String("q", request.Q), log.Int("limit", request.Limit))
+
+	q := strings.ToLower(strings.Join(strings.Fields(request.Q), " "))
+	terms := strings.Fields(q)
+	if len(terms) == 0 || len(terms) > maxSearchTerms || utf8.RuneCountInString(q) > maxSearchLength {
+		ext.Error.Set(span, true)
+		return nil, customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQuerySearch)
+	}
+
+	limit, err := pageLimit(a.Config, request.Limit)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	cursor := searchCursor{Query: q}
+	if request.Cursor != "" && (!helper.DecodeCursor(request.Cursor, &cursor) || cursor.Query != q || cursor.Offset < 0) {
+		ext.Error.Set(span, true)
+		return nil, customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryCursor)
+	}
+
+	// one more row than limit tell whether next page exist
+	start := time.Now()
+	results, err := a.AccRepo.Search(ctxTracing, &entity.AccountSearchQuery{Terms: terms, Limit: limit + 1, Offset: cursor.Offset})
+	a.observe(start, len(results), err)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	response := dto.AccountSearchResponse{HasMore: len(results) > limit}
+	if response.HasMore {
+		results = results[:limit]
+		response.NextCursor = helper.EncodeCursor(&searchCursor{Query: q, Offset: cursor.Offset + limit})
+	}
+
+	response.Data = make([]dto.AccountSearchResult, len(results))
+	for i, result := range results {
+		response.Data[i] = dto.AccountSearchResult{
+			Id:        result.Account.Id,
+			Email:     result.Account.Email,
+			Username:  result.Account.Username,
+			CreatedAt: helper.DateToString(result.Account.CreatedAt),
+			Rank:      result.Rank,
+			Highlight: dto.AccountSearchHighlight{
+				Email:    helper.Highlight(result.Account.Email, terms),
+				Username: helper.Highlight(result.Account.Username, terms),
+			},
+		}
+	}
+	response.Count = len(response.Data)
+
+	a.Logger.DebugContext(ctxTracing, "account searched", slog.Int("terms", len(terms)), slog.Int("count", response.Count))
+	span.LogFields(log.Int("count", response.Count), log.Bool("has_more", response.HasMore))
+	return &response, nil
+}
+
+// observe record latency of search query, outcome hit, empty or error
+func (a *AccountSearchService) observe(start time.Time, count int, err error) {
+	if a.Metrics == nil {
+		return
+	}
+
+	outcome := "hit"
+	if err != nil {
+		outcome = "error"
+	} else if count == 0 {
+		outcome = "empty"
+	}
+
+	a.Metrics.SearchDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+}
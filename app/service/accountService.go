@@ -4,38 +4,54 @@ import (
 	"cobaMetrics/app/config"
 	"cobaMetrics/app/customError"
 	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
 	"cobaMetrics/app/model/dto"
 	"cobaMetrics/app/model/entity"
 	jwtModel "cobaMetrics/app/model/jwt"
 	IRepo "cobaMetrics/app/repository/interface"
 	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
 	"context"
-	"database/sql"
 	"encoding/json"
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
 
 type AccountService struct {
-	DB             *sql.DB
+	TxManager      transaction.ITxManager
 	Validate       *validator.Validate
 	AccRepo        IRepo.IAccountRepository
 	HelperPassword helper.IHelperPassword
 	Config         config.IConfig
+	Verification   IService.IVerificationService
+	Session        IService.ISessionService
+	Logger         *slog.Logger
+
+	// hash checked for unknown email on login
+	dummyOnce sync.Once
+	dummyHash string
 }
 
-func NewAccountService(db *sql.DB, validate *validator.Validate, config config.IConfig, accRepo IRepo.IAccountRepository, helperPassword helper.IHelperPassword) IService.IAccountService {
+func NewAccountService(txManager transaction.ITxManager, validate *validator.Validate, config config.IConfig, accRepo IRepo.IAccountRepository, helperPassword helper.IHelperPassword, verification IService.IVerificationService, session IService.ISessionService, logger *slog.Logger) IService.IAccountService {
 	return &AccountService{
-		DB:             db,
+		TxManager:      txManager,
 		Validate:       validate,
 		Config:         config,
 		AccRepo:        accRepo,
 		HelperPassword: helperPassword,
+		Verification:   verification,
+		Session:        session,
+		Logger:         logger,
 	}
 }
 
@@ -61,6 +77,7 @@ func (a *AccountService) Add(ctx context.Context, request *dto.AddUserRequest) (
 	if err != nil {
 		span.LogFields(log.String("response", err.Error()))
 		ext.Error.Set(span, true)
+		a.Logger.ErrorContext(ctxTracing, "failed to hash password", slog.String("error", err.Error()))
 		return nil, customError.NewInternalServerError(err.Error())
 	}
 
@@ -73,26 +90,32 @@ func (a *AccountService) Add(ctx context.Context, request *dto.AddUserRequest) (
 		UpdatedAt: time.Now(),
 	}
 
-	// begin transaction
-	tx, _ := a.DB.Begin()
-	defer tx.Rollback()
-
-	// cek if email already exist
-	if _, err = a.AccRepo.GetByEmail(ctxTracing, tx, request.Email); err == nil {
-		errMessage := "email already exist in database"
-		span.LogFields(log.String("response", errMessage))
-		ext.Error.Set(span, true)
-		return nil, customError.NewBadRequestError(errMessage)
-	}
-
-	// call procedure insert in repository
-	account, err := a.AccRepo.Add(ctxTracing, tx, &input)
+	// check email and insert in one transaction
+	var account *entity.Account
+	err = a.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		// cek if email already exist
+		if _, err := a.AccRepo.GetByEmail(ctx, request.Email); err == nil {
+			return customError.New(customError.CodeAccountEmailTaken)
+		}
+
+		// call procedure insert in repository
+		account, err = a.AccRepo.Add(ctx, &input)
+		return err
+	})
 	if err != nil {
 		span.LogFields(log.String("response", err.Error()))
 		ext.Error.Set(span, true)
 		return nil, err
 	}
 
+	a.Logger.InfoContext(ctxTracing, "account created", slog.Int("new_account_id", account.Id))
+
+	// account already created, user can request new link with resend when mail failed
+	if err = a.Verification.Send(ctxTracing, account); err != nil {
+		span.LogFields(log.String("verification", err.Error()))
+		a.Logger.WarnContext(ctxTracing, "failed to send verification", slog.Int("new_account_id", account.Id), slog.String("error", err.Error()))
+	}
+
 	// create response
 	response := dto.AddUserResponse{
 		Id:        account.Id,
@@ -102,7 +125,6 @@ func (a *AccountService) Add(ctx context.Context, request *dto.AddUserRequest) (
 	}
 
 	// success
-	tx.Commit()
 	resJson, _ := json.Marshal(&response)
 	span.LogFields(log.String("response", string(resJson)))
 	return &response, nil
@@ -119,18 +141,14 @@ func (a *AccountService) GetByEmail(ctx context.Context, email string) (*dto.Acc
 		log.String("email", email))
 
 	// validate
-	if err := a.Validate.Var(email, "email"); err != nil {
+	if err := a.Validate.Struct(dto.GetAccountByEmailRequest{Email: email}); err != nil {
 		span.LogFields(log.String("response", err.Error()))
 		ext.Error.Set(span, true)
 		return nil, err
 	}
 
-	// start transaction
-	tx, _ := a.DB.Begin()
-	defer tx.Rollback()
-
 	// call procedure in repository
-	account, err := a.AccRepo.GetByEmail(ctxTracing, tx, email)
+	account, err := a.AccRepo.GetByEmail(ctxTracing, email)
 	if err != nil {
 		span.LogFields(log.String("response", err.Error()))
 		ext.Error.Set(span, true)
@@ -152,7 +170,6 @@ func (a *AccountService) GetByEmail(ctx context.Context, email string) (*dto.Acc
 	span.LogFields(
 		log.String("response", string(responseJson)))
 
-	tx.Commit()
 	return &response, nil
 }
 
@@ -177,6 +194,7 @@ func (a *AccountService) Update(ctx context.Context, request *dto.UpdateAccountR
 	hashedPassword, err := a.HelperPassword.HashPassword(request.Password)
 	if err != nil {
 		ext.Error.Set(span, true)
+		a.Logger.ErrorContext(ctxTracing, "failed to hash password", slog.String("error", err.Error()))
 		return nil, customError.NewInternalServerError(err.Error())
 	}
 
@@ -188,12 +206,12 @@ func (a *AccountService) Update(ctx context.Context, request *dto.UpdateAccountR
 		Password: hashedPassword,
 	}
 
-	// create transaction
-	tx, _ := a.DB.Begin()
-	defer tx.Rollback()
-
 	// call procedure in repository
-	account, err := a.AccRepo.Update(ctxTracing, tx, &input)
+	var account *entity.Account
+	err = a.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		account, err = a.AccRepo.Update(ctx, &input)
+		return err
+	})
 	if err != nil {
 		ext.Error.Set(span, true)
 		return nil, err
@@ -212,7 +230,6 @@ func (a *AccountService) Update(ctx context.Context, request *dto.UpdateAccountR
 	responseJson, _ := json.Marshal(&response)
 	span.LogFields(log.String("response", string(responseJson)))
 
-	tx.Commit()
 	return &response, nil
 }
 
@@ -233,112 +250,438 @@ func (a *AccountService) Login(ctx context.Context, request *dto.LoginRequest) (
 		return nil, err
 	}
 
-	// start transaction
-	tx, _ := a.DB.Begin()
-	defer tx.Rollback()
+	cfg := a.Config.Config()
+
+	// login state read from primary, stale lock or failed login from replica let attempt skip lockout
+	ctxTracing = database.ReadPrimary(ctxTracing)
 
 	// cek email
-	account, err := a.AccRepo.GetByEmail(ctxTracing, tx, request.Email)
+	account, err := a.AccRepo.GetByEmail(ctxTracing, request.Email)
 	if err != nil {
-		errMessage := "record not found"
 		ext.Error.Set(span, true)
-		span.LogFields(log.String("response", errMessage))
-		return nil, customError.NewNotFoundError(errMessage)
+		span.LogFields(log.String("response", err.Error()))
+
+		// database failure is not wrong credential
+		if customError.FromError(err).HttpStatus != http.StatusNotFound {
+			return nil, err
+		}
+
+		// check password like existing account, so response time not tell whether email registered
+		a.HelperPassword.CheckPasswordHash(request.Password, a.dummyPasswordHash(ctxTracing))
+
+		a.Logger.WarnContext(ctxTracing, "login failed", slog.String("reason", "account not found"))
+		return nil, loginError(cfg.Lockout, customError.CodeAccountNotFound)
+	}
+
+	// locked account rejected before password checked
+	now := time.Now()
+	if lockoutEnabled(cfg.Lockout) && account.IsLocked(now) {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "account locked"))
+		a.Logger.WarnContext(ctxTracing, "login failed", slog.String("reason", "account locked"), slog.Int("login_account_id", account.Id))
+		return nil, customError.New(customError.CodeAccountLocked)
 	}
 
 	// check password
 	if isValid := a.HelperPassword.CheckPasswordHash(request.Password, account.Password); !isValid {
-		errMessage := "password not match"
 		ext.Error.Set(span, true)
-		span.LogFields(log.String("response", errMessage))
-		return nil, customError.NewBadRequestError(errMessage)
+		span.LogFields(log.String("response", "password not match"))
+		a.Logger.WarnContext(ctxTracing, "login failed", slog.String("reason", "password not match"), slog.Int("login_account_id", account.Id))
+
+		if lockoutEnabled(cfg.Lockout) {
+			if err := a.recordLoginFailure(ctxTracing, cfg.Lockout, account, now); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, loginError(cfg.Lockout, customError.CodeAccountPasswordMismatch)
 	}
 
-	// create token
-	jwtConfig := a.Config.Config().Jwt
+	// unverified account refused only when required, otherwise flagged in claims
+	if !account.IsVerified() && verificationRequired(cfg.EmailVerification) {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "email not verified"))
+		a.Logger.WarnContext(ctxTracing, "login failed", slog.String("reason", "email not verified"), slog.Int("login_account_id", account.Id))
+		return nil, customError.New(customError.CodeAccountNotVerified)
+	}
+
+	// plain password only known on login, so hash with old algorithm or parameter replaced here
+	if a.HelperPassword.NeedsRehash(account.Password) {
+		a.rehashPassword(ctxTracing, account.Id, request.Password)
+	}
+
+	// account with two factor get short lived mfa_pending token, access token issued after code valid
+	if account.TotpEnabled {
+		mfaToken, err := newMfaToken(cfg, account)
+		if err != nil {
+			span.LogFields(log.String("response", err.Error()))
+			ext.Error.Set(span, true)
+			a.Logger.ErrorContext(ctxTracing, "failed to sign token", slog.String("error", err.Error()))
+			return nil, customError.NewInternalServerError(err.Error())
+		}
+
+		span.LogFields(log.String("response", "mfa required"))
+		return &dto.LoginResponse{MfaRequired: true, MfaToken: mfaToken}, nil
+	}
+
+	// success login reset failed login, account with two factor reset only after code valid
+	if account.FailedLoginCount > 0 || account.LockedUntil != nil {
+		if err := a.AccRepo.UpdateLoginState(ctxTracing, account.Id, 0, nil); err != nil {
+			a.Logger.WarnContext(ctxTracing, "failed to reset failed login", slog.String("error", err.Error()))
+		}
+	}
+
+	// create session and its token
+	response, err := a.Session.Start(ctxTracing, account, &request.Client)
+	if err != nil {
+		span.LogFields(log.String("response", err.Error()))
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	// log with tracing
+	responseJson, _ := json.Marshal(response)
+	span.LogFields(log.String("response", string(responseJson)))
+
+	return response, nil
+}
+
+// newMfaToken sign mfa_pending token of account, only accepted by login two factor
+func newMfaToken(cfg *config.ConfigApp, account *entity.Account) (string, error) {
 	claims := jwtModel.Claims{
-		Email: account.Email,
+		Id:               account.Id,
+		Email:            account.Email,
+		TokenVersion:     account.TokenVersion,
+		FailedLoginCount: account.FailedLoginCount,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    jwtConfig.Issuer,
-			Subject:   jwtConfig.Subject,
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(10 * time.Minute)),
+			Issuer:    cfg.Jwt.Issuer,
+			Subject:   jwtModel.SubjectMfaPending,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.TwoFactor.PendingTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	tokenWithClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
-	token, err := tokenWithClaims.SignedString([]byte(jwtConfig.SecretKey))
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).SignedString([]byte(cfg.Jwt.SecretKey))
+}
+
+// rehashPassword store new hash of password, failure only logged because login already success
+func (a *AccountService) rehashPassword(ctx context.Context, id int, password string) {
+	hashedPassword, err := a.HelperPassword.HashPassword(password)
+	if err == nil {
+		err = a.AccRepo.UpdatePassword(ctx, id, hashedPassword)
+	}
+
 	if err != nil {
+		a.Logger.WarnContext(ctx, "failed to rehash password", slog.String("error", err.Error()))
+		return
+	}
+
+	a.Logger.InfoContext(ctx, "password rehashed", slog.Int("login_account_id", id))
+}
+
+// recordLoginFailure increase failed login of account and lock it every time failed login reach threshold.
+// count increased in database and lock derived from stored count, so concurrent failure can not overwrite each other
+func (a *AccountService) recordLoginFailure(ctx context.Context, lockout *config.Lockout, account *entity.Account, now time.Time) error {
+	return a.TxManager.WithinTx(ctx, nil, func(ctx context.Context) error {
+		failedLoginCount, err := a.AccRepo.IncrementFailedLogin(ctx, account.Id)
+		if err != nil {
+			return err
+		}
+
+		return lockAccount(ctx, a.AccRepo, a.Logger, lockout, account.Id, failedLoginCount, now)
+	})
+}
+
+// lockAccount lock account when failed login count reach threshold, count must be the stored count after increased
+func lockAccount(ctx context.Context, accRepo IRepo.IAccountRepository, logger *slog.Logger, lockout *config.Lockout, accountId int, failedLoginCount int, now time.Time) error {
+	duration := lockoutDuration(lockout, failedLoginCount)
+	if duration == 0 {
+		return nil
+	}
+
+	logger.WarnContext(ctx, "account locked",
+		slog.Int("login_account_id", accountId),
+		slog.Int("failed_login_count", failedLoginCount),
+		slog.Duration("lock_duration", duration))
+
+	until := now.Add(duration)
+	return accRepo.UpdateLoginState(ctx, accountId, failedLoginCount, &until)
+}
+
+// dummyPassword is hashed once and checked when email not found, never match any password of account
+const dummyPassword = "dummy-password-for-unknown-email"
+
+// dummyPasswordHash return hash of dummyPassword with current algorithm and parameter
+func (a *AccountService) dummyPasswordHash(ctx context.Context) string {
+	a.dummyOnce.Do(func() {
+		hash, err := a.HelperPassword.HashPassword(dummyPassword)
+		if err != nil {
+			a.Logger.WarnContext(ctx, "failed to hash dummy password", slog.String("error", err.Error()))
+		}
+		a.dummyHash = hash
+	})
+
+	return a.dummyHash
+}
+
+func lockoutEnabled(lockout *config.Lockout) bool {
+	return lockout != nil && lockout.Enabled && lockout.Threshold > 0
+}
+
+// lockoutDuration return lock duration after failed login, zero when account not locked.
+// duration doubled on every lock : Duration, 2*Duration, 4*Duration ... until MaxDuration
+func lockoutDuration(lockout *config.Lockout, failedLoginCount int) time.Duration {
+	if failedLoginCount == 0 || failedLoginCount%lockout.Threshold != 0 {
+		return 0
+	}
+
+	maxDuration := lockout.MaxDuration
+	if maxDuration <= 0 {
+		maxDuration = math.MaxInt64 / 2
+	}
+
+	duration := lockout.Duration
+	for lock := failedLoginCount / lockout.Threshold; lock > 1 && duration < maxDuration; lock-- {
+		duration *= 2
+	}
+
+	return min(duration, maxDuration)
+}
+
+func verificationRequired(emailVerification *config.EmailVerification) bool {
+	return emailVerification != nil && emailVerification.RequireVerified
+}
+
+// loginError return same error for unknown email and wrong password when uniform error enabled
+func loginError(lockout *config.Lockout, code string) error {
+	if lockout != nil && lockout.UniformError {
+		return customError.New(customError.CodeAccountInvalidLogin)
+	}
+
+	return customError.New(code)
+}
+
+// implementasi method Unlock, reset failed login and lock of account
+func (a *AccountService) Unlock(ctx context.Context, request *dto.UnlockAccountRequest) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountService Unlock")
+	defer span.Finish()
+
+	span.LogFields(log.String("email", request.Email))
+
+	// validate
+	if err := a.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
 		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	account, err := a.AccRepo.GetByEmail(ctxTracing, request.Email)
+	if err != nil {
 		ext.Error.Set(span, true)
-		return nil, customError.NewInternalServerError(err.Error())
+		span.LogFields(log.String("response", err.Error()))
+		return err
 	}
 
-	// success create Token
-	// create response
-	response := dto.LoginResponse{
-		Token:     token,
-		CreatedAt: helper.DateToString(time.Now()),
+	if err = a.AccRepo.UpdateLoginState(ctxTracing, account.Id, 0, nil); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
 	}
 
-	// log with tracing
-	responseJson, _ := json.Marshal(&response)
-	span.LogFields(log.String("response", string(responseJson)))
+	a.Logger.InfoContext(ctxTracing, "account unlocked", slog.Int("unlocked_account_id", account.Id))
+	return nil
+}
 
-	tx.Commit()
-	return &response, nil
+// default of config pagination, used when pagination not configured
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// accountCursor is content of opaque cursor of list account. sort included, so cursor only valid for same sort
+type accountCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	Id    int    `json:"i"`
 }
 
-// implementasi method GetAll
-func (a *AccountService) GetAll(ctx context.Context, limit int, page int) ([]dto.AccountDetailResponse, error) {
+// implementasi method GetAll, page of account after cursor
+func (a *AccountService) GetAll(ctx context.Context, request *dto.GetAllAccountRequest) (*dto.AccountPageResponse, error) {
 	// start span tracing
 	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountService GetAll")
 	defer span.Finish()
 
 	// log request
-	req := map[string]int{
-		"limit": limit,
-		"page":  page,
-	}
-	reqJson, _ := json.Marshal(&req)
+	reqJson, _ := json.Marshal(request)
 	span.LogFields(log.String("request", string(reqJson)))
 
-	// create transaction
-	tx, _ := a.DB.BeginTx(ctxTracing, nil)
-	defer tx.Rollback()
+	query, err := a.listQuery(request)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	// one more row than limit tell whether next page exist
+	limit := query.Limit
+	query.Limit++
 
 	// call procedure GetAll in repository
-	offset := (limit * page) - limit
-	accounts, err := a.AccRepo.GetAll(ctxTracing, tx, limit, offset)
+	accounts, err := a.AccRepo.GetAll(ctxTracing, query)
 	if err != nil {
 		span.LogFields(log.String("response", err.Error()))
 		return nil, err
 	}
 
-	var response []dto.AccountDetailResponse
+	page := dto.AccountPageResponse{HasMore: len(accounts) > limit}
+	if page.HasMore {
+		accounts = accounts[:limit]
+		page.NextCursor = encodeAccountCursor(query, accounts[len(accounts)-1].Key(query.SortBy))
+	}
 
-	wg := &sync.WaitGroup{}
-	for _, account := range accounts {
-		wg.Add(1)
-		go func(wg *sync.WaitGroup, acc entity.Account) {
-			defer wg.Done()
-			response = append(response, dto.AccountDetailResponse{
-				Id:        acc.Id,
-				Email:     acc.Email,
-				Username:  acc.Username,
-				Password:  acc.Password,
-				CreatedAt: helper.DateToString(acc.CreatedAt),
-				UpdatedAt: helper.DateToString(acc.UpdatedAt),
-			})
-		}(wg, account)
+	if request.IncludeTotal {
+		total, err := a.AccRepo.Count(ctxTracing, query)
+		if err != nil {
+			span.LogFields(log.String("response", err.Error()))
+			return nil, err
+		}
+
+		page.Total = &total
 	}
 
-	wg.Wait()
+	response := MapAccountDetails(accounts)
+
+	page.Count = len(response)
+	page.Data = response
 
 	// log response
-	resJson, _ := json.Marshal(&response)
-	span.LogFields(log.String("response", string(resJson)))
+	span.LogFields(log.Int("count", page.Count), log.Bool("has_more", page.HasMore))
+	return &page, nil
+}
 
-	tx.Commit()
-	return response, nil
+// MapAccountDetails convert accounts into response in same order, page at most max page limit so mapped in place
+func MapAccountDetails(accounts []entity.Account) []dto.AccountDetailResponse {
+	response := make([]dto.AccountDetailResponse, len(accounts))
+	for i := range accounts {
+		response[i] = accountDetail(&accounts[i])
+	}
+
+	return response
+}
+
+func accountDetail(account *entity.Account) dto.AccountDetailResponse {
+	return dto.AccountDetailResponse{
+		Id:        account.Id,
+		Email:     account.Email,
+		Username:  account.Username,
+		Password:  account.Password,
+		CreatedAt: helper.DateToString(account.CreatedAt),
+		UpdatedAt: helper.DateToString(account.UpdatedAt),
+	}
+}
+
+// pageLimit return limit of page of config pagination when limit 0, error when limit out of range
+func pageLimit(config config.IConfig, limit int) (int, error) {
+	defaultLimit, maxLimit := defaultPageLimit, maxPageLimit
+	if pagination := config.Config().Pagination; pagination != nil {
+		defaultLimit, maxLimit = pagination.DefaultLimit, pagination.MaxLimit
+	}
+
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit < 1 || limit > maxLimit {
+		return 0, customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryLimitRange)
+	}
+
+	return limit, nil
+}
+
+// listQuery validate request of list account and convert it into query of repository
+func (a *AccountService) listQuery(request *dto.GetAllAccountRequest) (*entity.AccountListQuery, error) {
+	limit, err := pageLimit(a.Config, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	query := entity.AccountListQuery{Limit: limit, EmailPrefix: request.EmailPrefix}
+
+	sortBy, descending := strings.CutPrefix(request.Sort, "-")
+	switch sortBy {
+	case "":
+		sortBy = entity.AccountSortId
+	case entity.AccountSortId, entity.AccountSortCreatedAt, entity.AccountSortUsername, entity.AccountSortEmail:
+	default:
+		return nil, customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQuerySort)
+	}
+	query.SortBy, query.Descending = sortBy, descending
+
+	for _, created := range []struct {
+		value  string
+		target **time.Time
+	}{{request.CreatedFrom, &query.CreatedFrom}, {request.CreatedTo, &query.CreatedTo}} {
+		if created.value == "" {
+			continue
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, created.value)
+		if err != nil {
+			return nil, customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryCreatedRange)
+		}
+		*created.target = &createdAt
+	}
+
+	if request.Cursor != "" {
+		key, ok := decodeAccountCursor(request.Cursor, &query)
+		if !ok {
+			return nil, customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryCursor)
+		}
+		query.After = key
+	}
+
+	return &query, nil
+}
+
+// cursorSort return sort of query in form of query sort, so empty sort and id share cursor
+func cursorSort(query *entity.AccountListQuery) string {
+	if query.Descending {
+		return "-" + query.SortBy
+	}
+
+	return query.SortBy
+}
+
+func encodeAccountCursor(query *entity.AccountListQuery, key *entity.AccountKey) string {
+	cursor := accountCursor{Sort: cursorSort(query), Id: key.Id}
+	switch value := key.Value.(type) {
+	case time.Time:
+		cursor.Value = value.UTC().Format(time.RFC3339Nano)
+	case string:
+		cursor.Value = value
+	}
+
+	return helper.EncodeCursor(&cursor)
+}
+
+// decodeAccountCursor return key of cursor, false when cursor broken or made for other sort
+func decodeAccountCursor(encoded string, query *entity.AccountListQuery) (*entity.AccountKey, bool) {
+	var cursor accountCursor
+	if !helper.DecodeCursor(encoded, &cursor) || cursor.Sort != cursorSort(query) || cursor.Id < 1 {
+		return nil, false
+	}
+
+	key := entity.AccountKey{Id: cursor.Id}
+	switch query.SortBy {
+	case entity.AccountSortCreatedAt:
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, false
+		}
+		key.Value = createdAt
+	case entity.AccountSortUsername, entity.AccountSortEmail:
+		key.Value = cursor.Value
+	}
+
+	return &key, true
 }
@@ -0,0 +1,572 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/database/transaction"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fallback of config account_transfer
+const (
+	defaultImportBatchSize = 500
+	defaultImportMaxRows   = 10000
+	defaultImportMaxBytes  = 16 * 1024 * 1024
+	defaultHashWorkers     = 4
+	defaultExportBatchSize = 1000
+)
+
+// maxImportLine is longest line of ndjson import, row of AddUserRequest far shorter than it
+const maxImportLine = 64 * 1024
+
+// AccountTransferService import account in bulk and export every account.
+// imported account not verified and no verification mail sent, user request link with resend verification
+type AccountTransferService struct {
+	TxManager      transaction.ITxManager
+	Validate       *validator.Validate
+	Config         config.IConfig
+	AccRepo        IRepo.IAccountRepository
+	HelperPassword helper.IHelperPassword
+	Logger         *slog.Logger
+}
+
+// function provider
+func NewAccountTransferService(txManager transaction.ITxManager, validate *validator.Validate, config config.IConfig, accRepo IRepo.IAccountRepository, helperPassword helper.IHelperPassword, logger *slog.Logger) IService.IAccountTransferService {
+	return &AccountTransferService{
+		TxManager:      txManager,
+		Validate:       validate,
+		Config:         config,
+		AccRepo:        accRepo,
+		HelperPassword: helperPassword,
+		Logger:         logger,
+	}
+}
+
+// importRow is row that pass validation, waiting in batch for conflict check and insert
+type importRow struct {
+	// index of row in report
+	index   int
+	request dto.AddUserRequest
+}
+
+// method implementasi Import, every row validated like POST /account and inserted per batch.
+// batch inserted in own transaction, row of failed batch reported failed and other batch kept
+func (a *AccountTransferService) Import(ctx context.Context, request *dto.ImportAccountRequest) (*dto.ImportAccountResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountTransferService Import")
+	defer span.Finish()
+
+	span.LogFields(log.String("format", request.Format), log.Bool("dry_run", request.DryRun))
+
+	transfer := a.transferConfig()
+
+	// body read after max byte fail, row before it still imported and report marked truncated
+	body := http.MaxBytesReader(nil, io.NopCloser(request.Body), transfer.ImportMaxBytes)
+	reader, err := newImportReader(request.Format, body)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	locale := i18n.FromContext(ctx)
+	response := dto.ImportAccountResponse{DryRun: request.DryRun, Rows: []dto.ImportAccountRowResult{}}
+
+	// key of email and username already used by previous valid row, lower case like collation of mysql
+	seen := make(map[string]bool)
+	batch := make([]importRow, 0, transfer.ImportBatchSize)
+	for {
+		line, row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(response.Rows) == transfer.ImportMaxRows {
+			response.Truncated = true
+			break
+		}
+
+		var rowErr *importRowError
+		if errors.As(err, &rowErr) {
+			response.Rows = append(response.Rows, dto.ImportAccountRowResult{Line: rowErr.line})
+			failImportRow(&response.Rows[len(response.Rows)-1], customError.CodeImportRowInvalid, locale)
+			continue
+		}
+		if err != nil {
+			// body can not be read anymore, batch before already inserted so report still returned
+			a.Logger.WarnContext(ctxTracing, "import body read failed", slog.Int("rows", len(response.Rows)), slog.String("error", err.Error()))
+			span.LogFields(log.String("read", err.Error()))
+			response.Truncated = true
+			break
+		}
+
+		response.Rows = append(response.Rows, dto.ImportAccountRowResult{Line: line, Email: row.Email, Username: row.Username})
+		result := &response.Rows[len(response.Rows)-1]
+
+		if err = a.Validate.Struct(*row); err != nil {
+			failImportRow(result, customError.CodeValidation, locale)
+
+			var validationErrors validator.ValidationErrors
+			if errors.As(err, &validationErrors) {
+				result.Errors = customError.FieldErrors(validationErrors, locale)
+			}
+			continue
+		}
+
+		emailKey, usernameKey := "email:"+strings.ToLower(row.Email), "username:"+strings.ToLower(row.Username)
+		if seen[emailKey] || seen[usernameKey] {
+			failImportRow(result, customError.CodeImportRowDuplicate, locale)
+			continue
+		}
+		seen[emailKey], seen[usernameKey] = true, true
+
+		batch = append(batch, importRow{index: len(response.Rows) - 1, request: *row})
+		if len(batch) == transfer.ImportBatchSize {
+			a.importBatch(ctxTracing, request.DryRun, transfer.HashWorkers, batch, response.Rows, locale)
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		a.importBatch(ctxTracing, request.DryRun, transfer.HashWorkers, batch, response.Rows, locale)
+	}
+
+	response.Total = len(response.Rows)
+	for _, row := range response.Rows {
+		switch row.Status {
+		case dto.ImportStatusCreated:
+			response.Created++
+		case dto.ImportStatusValid:
+			response.Valid++
+		default:
+			response.Failed++
+		}
+	}
+
+	a.Logger.InfoContext(ctxTracing, "account imported",
+		slog.Bool("dry_run", response.DryRun),
+		slog.Int("total", response.Total),
+		slog.Int("created", response.Created),
+		slog.Int("failed", response.Failed),
+		slog.Bool("truncated", response.Truncated))
+	span.LogFields(log.Int("total", response.Total), log.Int("created", response.Created), log.Int("failed", response.Failed))
+	return &response, nil
+}
+
+// importBatch check conflict of batch with database, then hash password and insert row without conflict.
+// dry run stop after conflict check. result written into results at index of each row
+func (a *AccountTransferService) importBatch(ctx context.Context, dryRun bool, workers int, batch []importRow, results []dto.ImportAccountRowResult, locale string) {
+	emails := make([]string, len(batch))
+	usernames := make([]string, len(batch))
+	for i, row := range batch {
+		emails[i], usernames[i] = row.request.Email, row.request.Username
+	}
+
+	existing, err := a.AccRepo.GetByEmailsOrUsernames(ctx, emails, usernames)
+	if err != nil {
+		failImportBatch(batch, results, customError.FromError(err).Code, locale)
+		return
+	}
+
+	taken := make(map[string]bool, len(existing)*2)
+	for _, account := range existing {
+		taken["email:"+strings.ToLower(account.Email)] = true
+		taken["username:"+strings.ToLower(account.Username)] = true
+	}
+
+	pending := make([]importRow, 0, len(batch))
+	for _, row := range batch {
+		switch {
+		case taken["email:"+strings.ToLower(row.request.Email)]:
+			failImportRow(&results[row.index], customError.CodeAccountEmailTaken, locale)
+		case taken["username:"+strings.ToLower(row.request.Username)]:
+			failImportRow(&results[row.index], customError.CodeAccountUsernameTaken, locale)
+		case dryRun:
+			results[row.index].Status = dto.ImportStatusValid
+		default:
+			pending = append(pending, row)
+		}
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+
+	hashed := a.hashPasswords(ctx, workers, pending)
+
+	inserted := make([]importRow, 0, len(pending))
+	accounts := make([]entity.Account, 0, len(pending))
+	for i, row := range pending {
+		if hashed[i] == "" {
+			failImportRow(&results[row.index], customError.CodeInternal, locale)
+			continue
+		}
+
+		inserted = append(inserted, row)
+		accounts = append(accounts, entity.Account{Email: row.request.Email, Username: row.request.Username, Password: hashed[i]})
+	}
+
+	if len(accounts) == 0 {
+		return
+	}
+
+	err = a.TxManager.WithinTx(ctx, nil, func(ctx context.Context) error {
+		return a.AccRepo.AddBatch(ctx, accounts)
+	})
+	if err != nil {
+		// other import or signup may take email between check and insert, whole batch rolled back
+		a.Logger.WarnContext(ctx, "import batch insert failed", slog.Int("rows", len(accounts)), slog.String("error", err.Error()))
+		failImportBatch(inserted, results, customError.FromError(err).Code, locale)
+		return
+	}
+
+	for i, row := range inserted {
+		results[row.index].Status = dto.ImportStatusCreated
+		results[row.index].Id = accounts[i].Id
+	}
+}
+
+// hashPasswords hash password of every row with at most workers goroutine.
+// hash written into slot of same index, empty string when hash failed
+func (a *AccountTransferService) hashPasswords(ctx context.Context, workers int, rows []importRow) []string {
+	hashed := make([]string, len(rows))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(rows)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				hash, err := a.HelperPassword.HashPassword(rows[i].request.Password)
+				if err != nil {
+					a.Logger.ErrorContext(ctx, "failed to hash password", slog.String("error", err.Error()))
+					continue
+				}
+
+				hashed[i] = hash
+			}
+		}()
+	}
+
+	for i := range rows {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return hashed
+}
+
+// failImportRow mark row failed with code and message of code in locale
+func failImportRow(result *dto.ImportAccountRowResult, code string, locale string) {
+	result.Status = dto.ImportStatusFailed
+	result.Code = code
+	result.Message = i18n.Translate(locale, code)
+}
+
+func failImportBatch(batch []importRow, results []dto.ImportAccountRowResult, code string, locale string) {
+	for _, row := range batch {
+		failImportRow(&results[row.index], code, locale)
+	}
+}
+
+// transferConfig return config account_transfer, zero value replaced with fallback
+func (a *AccountTransferService) transferConfig() config.AccountTransfer {
+	transfer := config.AccountTransfer{}
+	if cfg := a.Config.Config().AccountTransfer; cfg != nil {
+		transfer = *cfg
+	}
+
+	if transfer.ImportBatchSize < 1 {
+		transfer.ImportBatchSize = defaultImportBatchSize
+	}
+	if transfer.ImportMaxRows < 1 {
+		transfer.ImportMaxRows = defaultImportMaxRows
+	}
+	if transfer.ImportMaxBytes < 1 {
+		transfer.ImportMaxBytes = defaultImportMaxBytes
+	}
+	if transfer.HashWorkers < 1 {
+		transfer.HashWorkers = defaultHashWorkers
+	}
+	if transfer.ExportBatchSize < 1 {
+		transfer.ExportBatchSize = defaultExportBatchSize
+	}
+
+	return transfer
+}
+
+// importRowError is row that can not be decoded, reading continue with next row
+type importRowError struct {
+	line int
+	err  error
+}
+
+func (e *importRowError) Error() string {
+	return "line " + strconv.Itoa(e.line) + ": " + e.err.Error()
+}
+
+// importReader read one row of import at a time, io.EOF after last row
+type importReader interface {
+	Read() (int, *dto.AddUserRequest, error)
+}
+
+func newImportReader(format string, body io.Reader) (importReader, error) {
+	switch format {
+	case dto.FormatCSV:
+		return newCSVImportReader(body)
+	case dto.FormatNDJSON:
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 4096), maxImportLine)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	default:
+		return nil, customError.New(customError.CodeImportFormatUnsupported)
+	}
+}
+
+// csvImportReader read csv with header, column found by name so order and extra column free
+type csvImportReader struct {
+	reader   *csv.Reader
+	columns  int
+	email    int
+	username int
+	password int
+}
+
+func newCSVImportReader(body io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, customError.NewWithKey(customError.CodeRequestBodyInvalid, i18n.MessageImportHeader)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		// byte order mark written by spreadsheet in front of first column
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\uFEFF")
+		}
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	csvReader := csvImportReader{reader: reader, columns: len(header)}
+	for column, target := range map[string]*int{"email": &csvReader.email, "username": &csvReader.username, "password": &csvReader.password} {
+		i, ok := index[column]
+		if !ok {
+			return nil, customError.NewWithKey(customError.CodeRequestBodyInvalid, i18n.MessageImportHeader)
+		}
+		*target = i
+	}
+
+	return &csvReader, nil
+}
+
+func (c *csvImportReader) Read() (int, *dto.AddUserRequest, error) {
+	record, err := c.reader.Read()
+	if err == io.EOF {
+		return 0, nil, err
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return 0, nil, &importRowError{line: parseErr.StartLine, err: parseErr.Err}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	if len(record) != c.columns {
+		return 0, nil, &importRowError{line: line, err: csv.ErrFieldCount}
+	}
+
+	return line, &dto.AddUserRequest{
+		Email:    record[c.email],
+		Username: record[c.username],
+		Password: record[c.password],
+	}, nil
+}
+
+// ndjsonImportReader read one json object of AddUserRequest per line, blank line skipped
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (n *ndjsonImportReader) Read() (int, *dto.AddUserRequest, error) {
+	for n.scanner.Scan() {
+		n.line++
+
+		content := bytes.TrimSpace(n.scanner.Bytes())
+		if len(content) == 0 {
+			continue
+		}
+
+		var row dto.AddUserRequest
+		if err := json.Unmarshal(content, &row); err != nil {
+			return 0, nil, &importRowError{line: n.line, err: err}
+		}
+
+		return n.line, &row, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	return 0, nil, io.EOF
+}
+
+// method implementasi Export, write every account ordered by id without password.
+// account read per batch so memory not grow with number of account
+func (a *AccountTransferService) Export(ctx context.Context, format string, writer io.Writer) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "AccountTransferService Export")
+	defer span.Finish()
+
+	span.LogFields(log.String("format", format))
+
+	encoder, err := newExportWriter(format, writer)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return err
+	}
+
+	query := entity.AccountListQuery{Limit: a.transferConfig().ExportBatchSize, SortBy: entity.AccountSortId}
+	count := 0
+	for {
+		accounts, err := a.AccRepo.GetAll(ctxTracing, &query)
+		if err != nil {
+			// status already sent, only way to know export incomplete beside the short body
+			ext.Error.Set(span, true)
+			span.LogFields(log.String("response", err.Error()))
+			a.Logger.ErrorContext(ctxTracing, "account export read failed", slog.Int("count", count), slog.String("error", err.Error()))
+			return err
+		}
+
+		for i := range accounts {
+			row := dto.ExportAccountRow{
+				Id:        accounts[i].Id,
+				Email:     accounts[i].Email,
+				Username:  accounts[i].Username,
+				Verified:  accounts[i].IsVerified(),
+				CreatedAt: helper.DateToString(accounts[i].CreatedAt),
+				UpdatedAt: helper.DateToString(accounts[i].UpdatedAt),
+			}
+			if err = encoder.Write(&row); err != nil {
+				return a.exportError(ctxTracing, span, count, err)
+			}
+		}
+		count += len(accounts)
+
+		// flush per batch so client receive data while next batch read
+		if err = encoder.Flush(); err != nil {
+			return a.exportError(ctxTracing, span, count, err)
+		}
+
+		if len(accounts) < query.Limit {
+			break
+		}
+		query.After = accounts[len(accounts)-1].Key(entity.AccountSortId)
+	}
+
+	a.Logger.InfoContext(ctxTracing, "account exported", slog.String("format", format), slog.Int("count", count))
+	span.LogFields(log.Int("count", count))
+	return nil
+}
+
+// exportError log write failure, mostly client closed connection in the middle of export
+func (a *AccountTransferService) exportError(ctx context.Context, span opentracing.Span, count int, err error) error {
+	ext.Error.Set(span, true)
+	span.LogFields(log.String("response", err.Error()))
+	a.Logger.WarnContext(ctx, "account export write failed", slog.Int("count", count), slog.String("error", err.Error()))
+	return err
+}
+
+// exportWriter encode row of export in one format
+type exportWriter interface {
+	Write(row *dto.ExportAccountRow) error
+	Flush() error
+}
+
+func newExportWriter(format string, writer io.Writer) (exportWriter, error) {
+	switch format {
+	case dto.FormatCSV:
+		csvWriter := csv.NewWriter(writer)
+		if err := csvWriter.Write([]string{"id", "email", "username", "verified", "created_at", "updated_at"}); err != nil {
+			return nil, err
+		}
+		return &csvExportWriter{csvWriter}, nil
+	case dto.FormatNDJSON:
+		buffered := bufio.NewWriter(writer)
+		return &ndjsonExportWriter{buffered, json.NewEncoder(buffered)}, nil
+	default:
+		return nil, customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryFormat)
+	}
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvExportWriter) Write(row *dto.ExportAccountRow) error {
+	return c.writer.Write([]string{
+		strconv.Itoa(row.Id),
+		csvCell(row.Email),
+		csvCell(row.Username),
+		strconv.FormatBool(row.Verified),
+		row.CreatedAt,
+		row.UpdatedAt,
+	})
+}
+
+func (c *csvExportWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// csvCell prefix value that spreadsheet read as formula with ', so opening export not run formula of username
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+type ndjsonExportWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (n *ndjsonExportWriter) Write(row *dto.ExportAccountRow) error {
+	return n.encoder.Encode(row)
+}
+
+func (n *ndjsonExportWriter) Flush() error {
+	return n.writer.Flush()
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	IService "cobaMetrics/app/service/interface"
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"time"
+)
+
+type ApiKeyService struct {
+	Validate   *validator.Validate
+	ApiKeyRepo IRepo.IApiKeyRepository
+	Logger     *slog.Logger
+}
+
+// function provider
+func NewApiKeyService(validate *validator.Validate, apiKeyRepo IRepo.IApiKeyRepository, logger *slog.Logger) IService.IApiKeyService {
+	return &ApiKeyService{
+		Validate:   validate,
+		ApiKeyRepo: apiKeyRepo,
+		Logger:     logger,
+	}
+}
+
+// method implementasi Create, api key owned by account when AccountId filled, otherwise owned by Service.
+// only hash of secret stored, full api key only returned here
+func (a *ApiKeyService) Create(ctx context.Context, request *dto.CreateApiKeyRequest) (*dto.CreateApiKeyResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "ApiKeyService Create")
+	defer span.Finish()
+
+	if err := a.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	key, prefix, secretHash, err := helper.NewApiKey()
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, customError.NewInternalServerError(err.Error())
+	}
+
+	now := time.Now()
+	apiKey := entity.ApiKey{
+		Id:         uuid.NewString(),
+		Prefix:     prefix,
+		SecretHash: secretHash,
+		Name:       request.Name,
+		AccountId:  request.AccountId,
+		Service:    request.Service,
+		Scopes:     request.Scopes,
+		CreatedAt:  now,
+	}
+	if request.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, request.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if _, err := a.ApiKeyRepo.Add(ctxTracing, &apiKey); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	a.Logger.InfoContext(ctxTracing, "api key created", slog.String("api_key_prefix", prefix), slog.String("api_key_service", apiKey.Service))
+	return &dto.CreateApiKeyResponse{
+		ApiKeyResponse: apiKeyResponse(&apiKey),
+		Key:            key,
+	}, nil
+}
+
+// method implementasi GetAll, return api key of owner that not revoked
+func (a *ApiKeyService) GetAll(ctx context.Context, accountId *int) ([]dto.ApiKeyResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "ApiKeyService GetAll")
+	defer span.Finish()
+
+	apiKeys, err := a.ApiKeyRepo.GetAll(ctxTracing, accountId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	response := make([]dto.ApiKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		response = append(response, apiKeyResponse(&apiKey))
+	}
+
+	return response, nil
+}
+
+// method implementasi Revoke, api key rejected by AuthMiddleware right after revoked
+func (a *ApiKeyService) Revoke(ctx context.Context, accountId *int, id string) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "ApiKeyService Revoke")
+	defer span.Finish()
+
+	if err := a.ApiKeyRepo.Revoke(ctxTracing, accountId, id, time.Now()); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	a.Logger.InfoContext(ctxTracing, "api key revoked", slog.String("api_key_id", id))
+	return nil
+}
+
+func apiKeyResponse(apiKey *entity.ApiKey) dto.ApiKeyResponse {
+	response := dto.ApiKeyResponse{
+		Id:        apiKey.Id,
+		Prefix:    helper.ApiKeyPrefix + "_" + apiKey.Prefix,
+		Name:      apiKey.Name,
+		Service:   apiKey.Service,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: helper.DateToString(apiKey.CreatedAt),
+	}
+	if apiKey.ExpiresAt != nil {
+		response.ExpiresAt = helper.DateToString(*apiKey.ExpiresAt)
+	}
+	if apiKey.LastUsedAt != nil {
+		response.LastUsedAt = helper.DateToString(*apiKey.LastUsedAt)
+	}
+
+	return response
+}
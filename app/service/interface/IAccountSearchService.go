@@ -0,0 +1,10 @@
+package service
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+)
+
+type IAccountSearchService interface {
+	Search(ctx context.Context, request *dto.SearchAccountRequest) (*dto.AccountSearchResponse, error)
+}
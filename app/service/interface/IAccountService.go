@@ -10,5 +10,6 @@ type IAccountService interface {
 	GetByEmail(ctx context.Context, email string) (*dto.AccountDetailResponse, error)
 	Update(ctx context.Context, request *dto.UpdateAccountRequest) (*dto.AccountDetailResponse, error)
 	Login(ctx context.Context, request *dto.LoginRequest) (*dto.LoginResponse, error)
-	GetAll(ctx context.Context, limit int, page int) ([]dto.AccountDetailResponse, error)
+	GetAll(ctx context.Context, request *dto.GetAllAccountRequest) (*dto.AccountPageResponse, error)
+	Unlock(ctx context.Context, request *dto.UnlockAccountRequest) error
 }
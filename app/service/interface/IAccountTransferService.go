@@ -0,0 +1,12 @@
+package service
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+	"io"
+)
+
+type IAccountTransferService interface {
+	Import(ctx context.Context, request *dto.ImportAccountRequest) (*dto.ImportAccountResponse, error)
+	Export(ctx context.Context, format string, writer io.Writer) error
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+)
+
+// accountId nil mean api key owned by service
+type IApiKeyService interface {
+	Create(ctx context.Context, request *dto.CreateApiKeyRequest) (*dto.CreateApiKeyResponse, error)
+	GetAll(ctx context.Context, accountId *int) ([]dto.ApiKeyResponse, error)
+	Revoke(ctx context.Context, accountId *int, id string) error
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+)
+
+type IOAuthService interface {
+	Token(ctx context.Context, request *dto.OAuthTokenRequest) (*dto.OAuthTokenResponse, error)
+	Introspect(ctx context.Context, request *dto.OAuthIntrospectRequest) (*dto.OAuthIntrospectResponse, error)
+	CreateClient(ctx context.Context, request *dto.CreateOAuthClientRequest) (*dto.CreateOAuthClientResponse, error)
+	GetAllClients(ctx context.Context) ([]dto.OAuthClientResponse, error)
+	RevokeClient(ctx context.Context, clientId string) error
+}
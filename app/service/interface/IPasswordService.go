@@ -0,0 +1,11 @@
+package service
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+)
+
+type IPasswordService interface {
+	Forgot(ctx context.Context, request *dto.ForgotPasswordRequest) error
+	Reset(ctx context.Context, request *dto.ResetPasswordRequest) error
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	"context"
+)
+
+type ISessionService interface {
+	Start(ctx context.Context, account *entity.Account, client *dto.SessionClient) (*dto.LoginResponse, error)
+	GetAll(ctx context.Context, accountId int, currentId string) ([]dto.SessionResponse, error)
+	Revoke(ctx context.Context, accountId int, sessionId string) error
+	RevokeAll(ctx context.Context, accountId int) error
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+)
+
+type ITwoFactorService interface {
+	Enroll(ctx context.Context, accountId int) (*dto.TwoFactorEnrollResponse, error)
+	Confirm(ctx context.Context, accountId int, request *dto.TwoFactorCodeRequest) (*dto.TwoFactorRecoveryCodesResponse, error)
+	Disable(ctx context.Context, accountId int, request *dto.TwoFactorDisableRequest) error
+	Login(ctx context.Context, request *dto.TwoFactorLoginRequest) (*dto.LoginResponse, error)
+}
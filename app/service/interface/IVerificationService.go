@@ -0,0 +1,13 @@
+package service
+
+import (
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	"context"
+)
+
+type IVerificationService interface {
+	Send(ctx context.Context, account *entity.Account) error
+	Verify(ctx context.Context, token string) error
+	Resend(ctx context.Context, request *dto.ResendVerificationRequest) error
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	jwtModel "cobaMetrics/app/model/jwt"
+	IRepo "cobaMetrics/app/repository/interface"
+	IService "cobaMetrics/app/service/interface"
+	"context"
+	"crypto/subtle"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GrantTypeClientCredentials is only grant type supported by token endpoint
+const GrantTypeClientCredentials = "client_credentials"
+
+// TokenTypeBearer is token_type of every token issued by token endpoint
+const TokenTypeBearer = "Bearer"
+
+type OAuthService struct {
+	Validate    *validator.Validate
+	Config      config.IConfig
+	ClientRepo  IRepo.IOAuthClientRepository
+	AccRepo     IRepo.IAccountRepository
+	SessionRepo IRepo.ISessionRepository
+	Logger      *slog.Logger
+}
+
+// function provider
+func NewOAuthService(validate *validator.Validate, config config.IConfig, clientRepo IRepo.IOAuthClientRepository, accRepo IRepo.IAccountRepository, sessionRepo IRepo.ISessionRepository, logger *slog.Logger) IService.IOAuthService {
+	return &OAuthService{
+		Validate:    validate,
+		Config:      config,
+		ClientRepo:  clientRepo,
+		AccRepo:     accRepo,
+		SessionRepo: sessionRepo,
+		Logger:      logger,
+	}
+}
+
+// method implementasi Token, issue token of client_credentials grant limited to requested scope
+func (o *OAuthService) Token(ctx context.Context, request *dto.OAuthTokenRequest) (*dto.OAuthTokenResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthService Token")
+	defer span.Finish()
+
+	span.LogFields(log.String("client_id", request.ClientId), log.String("grant_type", request.GrantType))
+
+	if request.GrantType == "" {
+		ext.Error.Set(span, true)
+		return nil, customError.New(customError.CodeOAuthInvalidRequest)
+	}
+
+	client, err := o.authenticateClient(ctxTracing, request.ClientId, request.ClientSecret)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	if request.GrantType != GrantTypeClientCredentials {
+		ext.Error.Set(span, true)
+		return nil, customError.New(customError.CodeOAuthUnsupportedGrantType)
+	}
+
+	// scope not requested get every scope allowed for client
+	scopes := strings.Fields(request.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			ext.Error.Set(span, true)
+			span.LogFields(log.String("response", "scope not allowed: "+scope))
+			return nil, customError.New(customError.CodeOAuthInvalidScope)
+		}
+	}
+
+	jwtConfig := o.Config.Config().Jwt
+	ttl := o.Config.Config().OAuth.TokenTTL
+	now := time.Now()
+	claims := jwtModel.Claims{
+		ClientId: client.Id,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    jwtConfig.Issuer,
+			Subject:   jwtModel.SubjectClient,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	// signed with same key as login token, so AuthMiddleware verify both
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).SignedString([]byte(jwtConfig.SecretKey))
+	if err != nil {
+		ext.Error.Set(span, true)
+		o.Logger.ErrorContext(ctxTracing, "failed to sign client token", slog.String("error", err.Error()))
+		return nil, customError.NewInternalServerError(err.Error())
+	}
+
+	o.Logger.InfoContext(ctxTracing, "client token issued", slog.String("client_id", client.Id), slog.String("scope", claims.Scope))
+	return &dto.OAuthTokenResponse{
+		AccessToken: token,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   int(ttl.Seconds()),
+		Scope:       claims.Scope,
+	}, nil
+}
+
+// method implementasi Introspect, caller must be registered client. token that not valid, expired or revoked is inactive
+func (o *OAuthService) Introspect(ctx context.Context, request *dto.OAuthIntrospectRequest) (*dto.OAuthIntrospectResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthService Introspect")
+	defer span.Finish()
+
+	span.LogFields(log.String("client_id", request.ClientId))
+
+	if request.Token == "" {
+		ext.Error.Set(span, true)
+		return nil, customError.New(customError.CodeOAuthInvalidRequest)
+	}
+
+	if _, err := o.authenticateClient(ctxTracing, request.ClientId, request.ClientSecret); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	inactive := &dto.OAuthIntrospectResponse{Active: false}
+
+	var claims jwtModel.Claims
+	token, err := jwt.ParseWithClaims(request.Token, &claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(o.Config.Config().Jwt.SecretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid || claims.RegisteredClaims.Subject == jwtModel.SubjectMfaPending {
+		return inactive, nil
+	}
+
+	response := dto.OAuthIntrospectResponse{
+		Active:    true,
+		TokenType: TokenTypeBearer,
+		Iss:       claims.RegisteredClaims.Issuer,
+	}
+	if claims.RegisteredClaims.ExpiresAt != nil {
+		response.Exp = claims.RegisteredClaims.ExpiresAt.Unix()
+	}
+	if claims.RegisteredClaims.IssuedAt != nil {
+		response.Iat = claims.RegisteredClaims.IssuedAt.Unix()
+	}
+
+	// token of client active while client not revoked
+	if claims.RegisteredClaims.Subject == jwtModel.SubjectClient {
+		client, err := o.ClientRepo.GetById(ctxTracing, claims.ClientId)
+		if err != nil {
+			if customError.FromError(err).Code == customError.CodeOAuthClientNotFound {
+				return inactive, nil
+			}
+
+			ext.Error.Set(span, true)
+			return nil, err
+		}
+
+		if !client.IsActive() {
+			return inactive, nil
+		}
+
+		response.Scope = claims.Scope
+		response.ClientId = claims.ClientId
+		response.Sub = claims.ClientId
+		return &response, nil
+	}
+
+	// login token active while token_version match and session not ended, same rule as AuthMiddleware
+	account, err := o.AccRepo.GetById(ctxTracing, claims.Id)
+	if err != nil {
+		if customError.FromError(err).Code == customError.CodeAccountNotFound {
+			return inactive, nil
+		}
+
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	if account.TokenVersion != claims.TokenVersion {
+		return inactive, nil
+	}
+
+	session, err := o.SessionRepo.GetById(ctxTracing, claims.SessionId)
+	if err != nil {
+		if customError.FromError(err).Code == customError.CodeSessionNotFound {
+			return inactive, nil
+		}
+
+		ext.Error.Set(span, true)
+		return nil, err
+	}
+
+	if session.AccountId != account.Id || !session.IsActive(time.Now()) {
+		return inactive, nil
+	}
+
+	response.Username = account.Email
+	response.Sub = strconv.Itoa(account.Id)
+	return &response, nil
+}
+
+// method implementasi CreateClient, register client with random id and secret. only hash of secret stored
+func (o *OAuthService) CreateClient(ctx context.Context, request *dto.CreateOAuthClientRequest) (*dto.CreateOAuthClientResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthService CreateClient")
+	defer span.Finish()
+
+	if err := o.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	secret, secretHash, err := helper.NewToken()
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, customError.NewInternalServerError(err.Error())
+	}
+
+	client := entity.OAuthClient{
+		Id:         uuid.NewString(),
+		SecretHash: secretHash,
+		Name:       request.Name,
+		Scopes:     request.Scopes,
+		CreatedAt:  time.Now(),
+	}
+
+	if _, err := o.ClientRepo.Add(ctxTracing, &client); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	o.Logger.InfoContext(ctxTracing, "oauth client registered", slog.String("client_id", client.Id))
+	return &dto.CreateOAuthClientResponse{
+		OAuthClientResponse: oauthClientResponse(&client),
+		ClientSecret:        secret,
+	}, nil
+}
+
+// method implementasi GetAllClients, return client that not revoked
+func (o *OAuthService) GetAllClients(ctx context.Context) ([]dto.OAuthClientResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthService GetAllClients")
+	defer span.Finish()
+
+	clients, err := o.ClientRepo.GetAll(ctxTracing)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	response := make([]dto.OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		response = append(response, oauthClientResponse(&client))
+	}
+
+	return response, nil
+}
+
+// method implementasi RevokeClient, token issued to client rejected right after revoked
+func (o *OAuthService) RevokeClient(ctx context.Context, clientId string) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "OAuthService RevokeClient")
+	defer span.Finish()
+
+	if err := o.ClientRepo.Revoke(ctxTracing, clientId, time.Now()); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	o.Logger.InfoContext(ctxTracing, "oauth client revoked", slog.String("client_id", clientId))
+	return nil
+}
+
+// authenticateClient return active client of credential, same error for unknown client and wrong secret
+func (o *OAuthService) authenticateClient(ctx context.Context, clientId string, clientSecret string) (*entity.OAuthClient, error) {
+	if clientId == "" || clientSecret == "" {
+		return nil, customError.New(customError.CodeOAuthInvalidClient)
+	}
+
+	client, err := o.ClientRepo.GetById(ctx, clientId)
+	if err != nil {
+		if customError.FromError(err).Code == customError.CodeOAuthClientNotFound {
+			return nil, customError.New(customError.CodeOAuthInvalidClient)
+		}
+
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(helper.HashToken(clientSecret)), []byte(client.SecretHash)) != 1 || !client.IsActive() {
+		o.Logger.WarnContext(ctx, "oauth client authentication failed", slog.String("client_id", clientId))
+		return nil, customError.New(customError.CodeOAuthInvalidClient)
+	}
+
+	return client, nil
+}
+
+func oauthClientResponse(client *entity.OAuthClient) dto.OAuthClientResponse {
+	return dto.OAuthClientResponse{
+		ClientId:  client.Id,
+		Name:      client.Name,
+		Scopes:    client.Scopes,
+		CreatedAt: helper.DateToString(client.CreatedAt),
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/mailer"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/database/transaction"
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type PasswordService struct {
+	TxManager      transaction.ITxManager
+	Validate       *validator.Validate
+	Config         config.IConfig
+	AccRepo        IRepo.IAccountRepository
+	ResetRepo      IRepo.IPasswordResetRepository
+	SessionRepo    IRepo.ISessionRepository
+	HelperPassword helper.IHelperPassword
+	Mailer         mailer.Mailer
+	Logger         *slog.Logger
+	pending        sync.WaitGroup
+}
+
+// function provider
+func NewPasswordService(txManager transaction.ITxManager, validate *validator.Validate, config config.IConfig, accRepo IRepo.IAccountRepository, resetRepo IRepo.IPasswordResetRepository, sessionRepo IRepo.ISessionRepository, helperPassword helper.IHelperPassword, mailer mailer.Mailer, logger *slog.Logger) IService.IPasswordService {
+	return &PasswordService{
+		TxManager:      txManager,
+		Validate:       validate,
+		Config:         config,
+		AccRepo:        accRepo,
+		ResetRepo:      resetRepo,
+		SessionRepo:    sessionRepo,
+		HelperPassword: helperPassword,
+		Mailer:         mailer,
+		Logger:         logger,
+	}
+}
+
+// method implementasi Forgot, send reset link to email in background.
+// unknown email get same response, so endpoint not reveal registered email
+func (p *PasswordService) Forgot(ctx context.Context, request *dto.ForgotPasswordRequest) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "PasswordService Forgot")
+	defer span.Finish()
+
+	span.LogFields(log.String("email", request.Email))
+
+	// validate
+	if err := p.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	account, err := p.AccRepo.GetByEmail(ctxTracing, request.Email)
+	if err != nil {
+		if customError.FromError(err).Code == customError.CodeAccountNotFound {
+			p.Logger.InfoContext(ctxTracing, "password reset requested for unknown email")
+			return nil
+		}
+
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// token and mail created in background, so known email answered as fast as unknown email.
+	// value copied before goroutine start, request context reused by next request after this return
+	resetCtx := logging.DetachedContext(ctxTracing)
+	parent := span.Context()
+	p.pending.Add(1)
+	go func() {
+		defer p.pending.Done()
+		p.sendReset(resetCtx, parent, account)
+	}()
+
+	return nil
+}
+
+// Wait block until every reset mail in background finished
+func (p *PasswordService) Wait() {
+	p.pending.Wait()
+}
+
+// sendReset create reset token and send its link to account, failure only logged because response already sent
+func (p *PasswordService) sendReset(ctx context.Context, parent opentracing.SpanContext, account *entity.Account) {
+	// start span tracing, span of Forgot already finished
+	span := opentracing.StartSpan("PasswordService sendReset", opentracing.FollowsFrom(parent))
+	defer span.Finish()
+	ctxTracing := opentracing.ContextWithSpan(ctx, span)
+
+	token, tokenHash, err := helper.NewToken()
+	if err != nil {
+		ext.Error.Set(span, true)
+		p.Logger.ErrorContext(ctxTracing, "failed to create password reset token", slog.String("error", err.Error()))
+		return
+	}
+
+	// only the newest token can be used
+	resetConfig := p.Config.Config().PasswordReset
+	now := time.Now()
+	err = p.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		if err := p.ResetRepo.InvalidateByAccount(ctx, account.Id, now); err != nil {
+			return err
+		}
+
+		_, err := p.ResetRepo.Add(ctx, &entity.PasswordReset{
+			AccountId: account.Id,
+			TokenHash: tokenHash,
+			ExpiresAt: now.Add(resetConfig.TokenTTL),
+		})
+		return err
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		p.Logger.ErrorContext(ctxTracing, "failed to save password reset token", slog.Int("reset_account_id", account.Id), slog.String("error", err.Error()))
+		return
+	}
+
+	link, err := helper.TokenLink(resetConfig.URL, token)
+	if err != nil {
+		ext.Error.Set(span, true)
+		p.Logger.ErrorContext(ctxTracing, "invalid password reset url", slog.String("error", err.Error()))
+		return
+	}
+
+	locale := i18n.FromContext(ctx)
+	message := mailer.Message{
+		To:      account.Email,
+		Subject: i18n.Translate(locale, i18n.MessageMailPasswordResetSubject),
+		Body:    i18n.Translate(locale, i18n.MessageMailPasswordResetBody, account.Username, link, resetConfig.TokenTTL),
+	}
+
+	if err = p.Mailer.Send(ctxTracing, message); err != nil {
+		ext.Error.Set(span, true)
+		p.Logger.ErrorContext(ctxTracing, "failed to send password reset mail", slog.Int("reset_account_id", account.Id), slog.String("error", err.Error()))
+		return
+	}
+
+	p.Logger.InfoContext(ctxTracing, "password reset mail sent", slog.Int("reset_account_id", account.Id))
+}
+
+// method implementasi Reset, replace password with single use token and revoke every issued access token
+func (p *PasswordService) Reset(ctx context.Context, request *dto.ResetPasswordRequest) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "PasswordService Reset")
+	defer span.Finish()
+
+	// validate
+	if err := p.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	passwordReset, err := p.ResetRepo.GetByTokenHash(ctxTracing, helper.HashToken(request.Token))
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	now := time.Now()
+	if !passwordReset.IsUsable(now) {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "token used or expired"))
+		return customError.New(customError.CodePasswordResetTokenInvalid)
+	}
+
+	account, err := p.AccRepo.GetById(ctxTracing, passwordReset.AccountId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	// validate again with identity of account
+	request.Email, request.Username = account.Email, account.Username
+	if err = p.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	hashedPassword, err := p.HelperPassword.HashPassword(request.Password)
+	if err != nil {
+		ext.Error.Set(span, true)
+		p.Logger.ErrorContext(ctxTracing, "failed to hash password", slog.String("error", err.Error()))
+		return customError.NewInternalServerError(err.Error())
+	}
+
+	err = p.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		// fail when token used by other request at the same time
+		if err := p.ResetRepo.MarkUsed(ctx, passwordReset.Id, now); err != nil {
+			return err
+		}
+
+		if err := p.AccRepo.ResetPassword(ctx, account.Id, hashedPassword); err != nil {
+			return err
+		}
+
+		if err := p.ResetRepo.InvalidateByAccount(ctx, account.Id, now); err != nil {
+			return err
+		}
+
+		// token already rejected by token_version, session ended so it not listed as active
+		return p.SessionRepo.RevokeByAccount(ctx, account.Id, now)
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	p.Logger.InfoContext(ctxTracing, "password reset", slog.Int("reset_account_id", account.Id))
+	return nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	jwtModel "cobaMetrics/app/model/jwt"
+	IRepo "cobaMetrics/app/repository/interface"
+	IService "cobaMetrics/app/service/interface"
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"time"
+)
+
+// accessTokenTTL is lifetime of access token and its session
+const accessTokenTTL = 10 * time.Minute
+
+// userAgentMaxLength is size of user_agent column
+const userAgentMaxLength = 255
+
+type SessionService struct {
+	Config      config.IConfig
+	SessionRepo IRepo.ISessionRepository
+	Logger      *slog.Logger
+}
+
+// function provider
+func NewSessionService(config config.IConfig, sessionRepo IRepo.ISessionRepository, logger *slog.Logger) IService.ISessionService {
+	return &SessionService{
+		Config:      config,
+		SessionRepo: sessionRepo,
+		Logger:      logger,
+	}
+}
+
+// method implementasi Start, record new session of account and sign access token of it
+func (s *SessionService) Start(ctx context.Context, account *entity.Account, client *dto.SessionClient) (*dto.LoginResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionService Start")
+	defer span.Finish()
+
+	now := time.Now()
+	userAgent := client.UserAgent
+	if len(userAgent) > userAgentMaxLength {
+		userAgent = userAgent[:userAgentMaxLength]
+	}
+
+	session, err := s.SessionRepo.Add(ctxTracing, &entity.Session{
+		Id:         uuid.NewString(),
+		AccountId:  account.Id,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(accessTokenTTL),
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	token, err := newAccessToken(s.Config.Config().Jwt, account, session)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		s.Logger.ErrorContext(ctxTracing, "failed to sign token", slog.String("error", err.Error()))
+		return nil, customError.NewInternalServerError(err.Error())
+	}
+
+	s.Logger.InfoContext(ctxTracing, "session started", slog.Int("session_account_id", account.Id), slog.String("session_id", session.Id))
+	return &dto.LoginResponse{
+		Token:     token,
+		CreatedAt: helper.DateToString(now),
+	}, nil
+}
+
+// method implementasi GetAll, return active session of account
+func (s *SessionService) GetAll(ctx context.Context, accountId int, currentId string) ([]dto.SessionResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionService GetAll")
+	defer span.Finish()
+
+	sessions, err := s.SessionRepo.GetActiveByAccount(ctxTracing, accountId, time.Now())
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	response := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, dto.SessionResponse{
+			Id:         session.Id,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  helper.DateToString(session.CreatedAt),
+			LastSeenAt: helper.DateToString(session.LastSeenAt),
+			ExpiresAt:  helper.DateToString(session.ExpiresAt),
+			Current:    session.Id == currentId,
+		})
+	}
+
+	return response, nil
+}
+
+// method implementasi Revoke, end one session of account
+func (s *SessionService) Revoke(ctx context.Context, accountId int, sessionId string) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionService Revoke")
+	defer span.Finish()
+
+	if err := s.SessionRepo.Revoke(ctxTracing, accountId, sessionId, time.Now()); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	s.Logger.InfoContext(ctxTracing, "session revoked", slog.Int("session_account_id", accountId), slog.String("session_id", sessionId))
+	return nil
+}
+
+// method implementasi RevokeAll, log out account from every device including current one
+func (s *SessionService) RevokeAll(ctx context.Context, accountId int) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "SessionService RevokeAll")
+	defer span.Finish()
+
+	if err := s.SessionRepo.RevokeByAccount(ctxTracing, accountId, time.Now()); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	s.Logger.InfoContext(ctxTracing, "every session revoked", slog.Int("session_account_id", accountId))
+	return nil
+}
+
+// newAccessToken sign access token of session, accepted by AuthMiddleware while session active
+func newAccessToken(jwtConfig *config.JWT, account *entity.Account, session *entity.Session) (string, error) {
+	claims := jwtModel.Claims{
+		Id:            account.Id,
+		Email:         account.Email,
+		TokenVersion:  account.TokenVersion,
+		EmailVerified: account.IsVerified(),
+		SessionId:     session.Id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    jwtConfig.Issuer,
+			Subject:   jwtConfig.Subject,
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(session.CreatedAt),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).SignedString([]byte(jwtConfig.SecretKey))
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	jwtModel "cobaMetrics/app/model/jwt"
+	IRepo "cobaMetrics/app/repository/interface"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/app/totp"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"time"
+)
+
+type TwoFactorService struct {
+	TxManager        transaction.ITxManager
+	Validate         *validator.Validate
+	Config           config.IConfig
+	AccRepo          IRepo.IAccountRepository
+	RecoveryCodeRepo IRepo.IRecoveryCodeRepository
+	HelperPassword   helper.IHelperPassword
+	Session          IService.ISessionService
+	Logger           *slog.Logger
+}
+
+// function provider
+func NewTwoFactorService(txManager transaction.ITxManager, validate *validator.Validate, config config.IConfig, accRepo IRepo.IAccountRepository, recoveryCodeRepo IRepo.IRecoveryCodeRepository, helperPassword helper.IHelperPassword, session IService.ISessionService, logger *slog.Logger) IService.ITwoFactorService {
+	return &TwoFactorService{
+		TxManager:        txManager,
+		Validate:         validate,
+		Config:           config,
+		AccRepo:          accRepo,
+		RecoveryCodeRepo: recoveryCodeRepo,
+		HelperPassword:   helperPassword,
+		Session:          session,
+		Logger:           logger,
+	}
+}
+
+// method implementasi Enroll, create new secret that active only after confirmed.
+// enroll again before confirm replace the secret
+func (t *TwoFactorService) Enroll(ctx context.Context, accountId int) (*dto.TwoFactorEnrollResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "TwoFactorService Enroll")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	account, err := t.AccRepo.GetById(ctxTracing, accountId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	if account.TotpEnabled {
+		ext.Error.Set(span, true)
+		return nil, customError.New(customError.CodeTwoFactorAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, customError.NewInternalServerError(err.Error())
+	}
+
+	if err = t.AccRepo.UpdateTotp(ctxTracing, account.Id, secret, false); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	t.Logger.InfoContext(ctxTracing, "two factor enrolled", slog.Int("two_factor_account_id", account.Id))
+	return &dto.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(t.Config.Config().TwoFactor.Issuer, account.Email, secret),
+	}, nil
+}
+
+// method implementasi Confirm, enable two factor with first code of authenticator and return recovery code.
+// recovery code only shown once, only the hash stored
+func (t *TwoFactorService) Confirm(ctx context.Context, accountId int, request *dto.TwoFactorCodeRequest) (*dto.TwoFactorRecoveryCodesResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "TwoFactorService Confirm")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	// validate
+	if err := t.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	account, err := t.AccRepo.GetById(ctxTracing, accountId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	if account.TotpEnabled {
+		ext.Error.Set(span, true)
+		return nil, customError.New(customError.CodeTwoFactorAlreadyEnabled)
+	}
+
+	if account.TotpSecret == "" {
+		ext.Error.Set(span, true)
+		return nil, customError.New(customError.CodeTwoFactorNotEnrolled)
+	}
+
+	// only totp code accepted, recovery code not exist yet
+	twoFactorConfig := t.Config.Config().TwoFactor
+	step, ok := totp.Validate(account.TotpSecret, request.Code, time.Now(), twoFactorConfig.Skew)
+	if !ok {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "code not valid"))
+		return nil, customError.New(customError.CodeTwoFactorCodeInvalid)
+	}
+
+	recoveryCodes, err := totp.GenerateRecoveryCodes(twoFactorConfig.RecoveryCodes)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return nil, customError.NewInternalServerError(err.Error())
+	}
+
+	codeHashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		codeHashes = append(codeHashes, helper.HashToken(totp.NormalizeRecoveryCode(code)))
+	}
+
+	err = t.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		if err := t.AccRepo.UpdateTotp(ctx, account.Id, account.TotpSecret, true); err != nil {
+			return err
+		}
+
+		if _, err := t.AccRepo.UseTotpStep(ctx, account.Id, step); err != nil {
+			return err
+		}
+
+		return t.RecoveryCodeRepo.ReplaceByAccount(ctx, account.Id, codeHashes)
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	t.Logger.InfoContext(ctxTracing, "two factor enabled", slog.Int("two_factor_account_id", account.Id))
+	return &dto.TwoFactorRecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// method implementasi Disable, require password and code again even though request already authenticated
+func (t *TwoFactorService) Disable(ctx context.Context, accountId int, request *dto.TwoFactorDisableRequest) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "TwoFactorService Disable")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", accountId))
+
+	// validate
+	if err := t.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	account, err := t.AccRepo.GetById(ctxTracing, accountId)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	if !account.TotpEnabled {
+		ext.Error.Set(span, true)
+		return customError.New(customError.CodeTwoFactorNotEnabled)
+	}
+
+	if !t.HelperPassword.CheckPasswordHash(request.Password, account.Password) {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "password not match"))
+		t.Logger.WarnContext(ctxTracing, "disable two factor failed", slog.String("reason", "password not match"), slog.Int("two_factor_account_id", account.Id))
+		return customError.New(customError.CodeAccountPasswordMismatch)
+	}
+
+	if err = t.checkCode(ctxTracing, account, request.Code); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	err = t.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		if err := t.AccRepo.UpdateTotp(ctx, account.Id, "", false); err != nil {
+			return err
+		}
+
+		return t.RecoveryCodeRepo.DeleteByAccount(ctx, account.Id)
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	t.Logger.InfoContext(ctxTracing, "two factor disabled", slog.Int("two_factor_account_id", account.Id))
+	return nil
+}
+
+// method implementasi Login, exchange mfa_pending token and valid code with access token
+func (t *TwoFactorService) Login(ctx context.Context, request *dto.TwoFactorLoginRequest) (*dto.LoginResponse, error) {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "TwoFactorService Login")
+	defer span.Finish()
+
+	// validate
+	if err := t.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	cfg := t.Config.Config()
+
+	var claims jwtModel.Claims
+	token, err := jwt.ParseWithClaims(request.MfaToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(cfg.Jwt.SecretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid || claims.RegisteredClaims.Subject != jwtModel.SubjectMfaPending {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "mfa token not valid"))
+		return nil, customError.New(customError.CodeAuthTokenInvalid)
+	}
+
+	// login state read from primary, stale lock or failed login from replica let attempt skip lockout
+	ctxTracing = database.ReadPrimary(ctxTracing)
+
+	account, err := t.AccRepo.GetById(ctxTracing, claims.Id)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		if customError.FromError(err).Code == customError.CodeAccountNotFound {
+			return nil, customError.New(customError.CodeAuthTokenRevoked)
+		}
+
+		return nil, err
+	}
+
+	// password reset or two factor disabled after mfa token issued
+	if account.TokenVersion != claims.TokenVersion || !account.TotpEnabled {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "mfa token revoked"))
+		return nil, customError.New(customError.CodeAuthTokenRevoked)
+	}
+
+	now := time.Now()
+	if lockoutEnabled(cfg.Lockout) && account.IsLocked(now) {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "account locked"))
+		t.Logger.WarnContext(ctxTracing, "login failed", slog.String("reason", "account locked"), slog.Int("login_account_id", account.Id))
+		return nil, customError.New(customError.CodeAccountLocked)
+	}
+
+	// attempt counted before code checked, so parallel guess can not pass the limit. reset again when code valid
+	var failedLoginCount int
+	err = t.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		failedLoginCount, err = t.AccRepo.IncrementFailedLogin(ctx, account.Id)
+		return err
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	if maxAttempts := cfg.TwoFactor.MaxAttempts; maxAttempts > 0 && failedLoginCount-claims.FailedLoginCount > maxAttempts {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "mfa token attempt exceeded"))
+		t.Logger.WarnContext(ctxTracing, "login failed", slog.String("reason", "two factor attempt exceeded"), slog.Int("login_account_id", account.Id))
+		return nil, customError.New(customError.CodeAuthTokenRevoked)
+	}
+
+	if err = t.checkCode(ctxTracing, account, request.Code); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		t.Logger.WarnContext(ctxTracing, "login failed", slog.String("reason", "two factor code not valid"), slog.Int("login_account_id", account.Id))
+
+		// wrong code count toward lockout like wrong password
+		if customError.FromError(err).Code == customError.CodeTwoFactorCodeInvalid && lockoutEnabled(cfg.Lockout) {
+			if err := lockAccount(ctxTracing, t.AccRepo, t.Logger, cfg.Lockout, account.Id, failedLoginCount, now); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, err
+	}
+
+	if err = t.AccRepo.UpdateLoginState(ctxTracing, account.Id, 0, nil); err != nil {
+		t.Logger.WarnContext(ctxTracing, "failed to reset failed login", slog.String("error", err.Error()))
+	}
+
+	response, err := t.Session.Start(ctxTracing, account, &request.Client)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// checkCode accept totp code or unused recovery code of account, every code only accepted once
+func (t *TwoFactorService) checkCode(ctx context.Context, account *entity.Account, code string) error {
+	now := time.Now()
+	if totp.IsCode(code) {
+		step, ok := totp.Validate(account.TotpSecret, code, now, t.Config.Config().TwoFactor.Skew)
+		if !ok {
+			return customError.New(customError.CodeTwoFactorCodeInvalid)
+		}
+
+		used, err := t.AccRepo.UseTotpStep(ctx, account.Id, step)
+		if err != nil {
+			return err
+		}
+
+		if !used {
+			return customError.New(customError.CodeTwoFactorCodeInvalid)
+		}
+
+		return nil
+	}
+
+	used, err := t.RecoveryCodeRepo.Use(ctx, account.Id, helper.HashToken(totp.NormalizeRecoveryCode(code)), now)
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return customError.New(customError.CodeTwoFactorCodeInvalid)
+	}
+
+	t.Logger.InfoContext(ctx, "recovery code used", slog.Int("two_factor_account_id", account.Id))
+	return nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/mailer"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	IRepo "cobaMetrics/app/repository/interface"
+	IService "cobaMetrics/app/service/interface"
+	"cobaMetrics/database/transaction"
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type VerificationService struct {
+	TxManager        transaction.ITxManager
+	Validate         *validator.Validate
+	Config           config.IConfig
+	AccRepo          IRepo.IAccountRepository
+	VerificationRepo IRepo.IEmailVerificationRepository
+	Mailer           mailer.Mailer
+	Logger           *slog.Logger
+	pending          sync.WaitGroup
+}
+
+// function provider
+func NewVerificationService(txManager transaction.ITxManager, validate *validator.Validate, config config.IConfig, accRepo IRepo.IAccountRepository, verificationRepo IRepo.IEmailVerificationRepository, mailer mailer.Mailer, logger *slog.Logger) IService.IVerificationService {
+	return &VerificationService{
+		TxManager:        txManager,
+		Validate:         validate,
+		Config:           config,
+		AccRepo:          accRepo,
+		VerificationRepo: verificationRepo,
+		Mailer:           mailer,
+		Logger:           logger,
+	}
+}
+
+// method implementasi Send, create new verification token of account and email the link.
+// previous token of account no longer valid
+func (v *VerificationService) Send(ctx context.Context, account *entity.Account) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "VerificationService Send")
+	defer span.Finish()
+
+	span.LogFields(log.Int("account_id", account.Id))
+
+	token, tokenHash, err := helper.NewToken()
+	if err != nil {
+		ext.Error.Set(span, true)
+		return customError.NewInternalServerError(err.Error())
+	}
+
+	verificationConfig := v.Config.Config().EmailVerification
+	now := time.Now()
+	err = v.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		if err := v.VerificationRepo.InvalidateByAccount(ctx, account.Id, now); err != nil {
+			return err
+		}
+
+		_, err := v.VerificationRepo.Add(ctx, &entity.EmailVerification{
+			AccountId: account.Id,
+			TokenHash: tokenHash,
+			ExpiresAt: now.Add(verificationConfig.TokenTTL),
+		})
+		return err
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	link, err := helper.TokenLink(verificationConfig.URL, token)
+	if err != nil {
+		ext.Error.Set(span, true)
+		v.Logger.ErrorContext(ctxTracing, "invalid email verification url", slog.String("error", err.Error()))
+		return customError.NewInternalServerError(err.Error())
+	}
+
+	locale := i18n.FromContext(ctx)
+	message := mailer.Message{
+		To:      account.Email,
+		Subject: i18n.Translate(locale, i18n.MessageMailVerifyEmailSubject),
+		Body:    i18n.Translate(locale, i18n.MessageMailVerifyEmailBody, account.Username, link, verificationConfig.TokenTTL),
+	}
+
+	if err = v.Mailer.Send(ctxTracing, message); err != nil {
+		ext.Error.Set(span, true)
+		v.Logger.ErrorContext(ctxTracing, "failed to send verification mail", slog.Int("verify_account_id", account.Id), slog.String("error", err.Error()))
+		return customError.NewInternalServerError(err.Error())
+	}
+
+	v.Logger.InfoContext(ctxTracing, "verification mail sent", slog.Int("verify_account_id", account.Id))
+	return nil
+}
+
+// method implementasi Verify, mark email of token account verified
+func (v *VerificationService) Verify(ctx context.Context, token string) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "VerificationService Verify")
+	defer span.Finish()
+
+	if token == "" {
+		ext.Error.Set(span, true)
+		return customError.New(customError.CodeVerificationTokenInvalid)
+	}
+
+	verification, err := v.VerificationRepo.GetByTokenHash(ctxTracing, helper.HashToken(token))
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	now := time.Now()
+	if !verification.IsUsable(now) {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", "token used or expired"))
+		return customError.New(customError.CodeVerificationTokenInvalid)
+	}
+
+	err = v.TxManager.WithinTx(ctxTracing, nil, func(ctx context.Context) error {
+		if err := v.VerificationRepo.MarkUsed(ctx, verification.Id, now); err != nil {
+			return err
+		}
+
+		return v.AccRepo.MarkVerified(ctx, verification.AccountId, now)
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	v.Logger.InfoContext(ctxTracing, "email verified", slog.Int("verify_account_id", verification.AccountId))
+	return nil
+}
+
+// method implementasi Resend, unknown email and verified account get same response as success.
+// link sent in background, so unverified account answered as fast as unknown email
+func (v *VerificationService) Resend(ctx context.Context, request *dto.ResendVerificationRequest) error {
+	// start span tracing
+	span, ctxTracing := opentracing.StartSpanFromContext(ctx, "VerificationService Resend")
+	defer span.Finish()
+
+	span.LogFields(log.String("email", request.Email))
+
+	// validate
+	if err := v.Validate.Struct(*request); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	account, err := v.AccRepo.GetByEmail(ctxTracing, request.Email)
+	if err != nil {
+		if customError.FromError(err).Code == customError.CodeAccountNotFound {
+			v.Logger.InfoContext(ctxTracing, "verification resend requested for unknown email")
+			return nil
+		}
+
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("response", err.Error()))
+		return err
+	}
+
+	if account.IsVerified() {
+		return nil
+	}
+
+	// value copied before goroutine start, request context reused by next request after this return
+	resendCtx := logging.DetachedContext(ctxTracing)
+	parent := span.Context()
+	v.pending.Add(1)
+	go func() {
+		defer v.pending.Done()
+
+		span := opentracing.StartSpan("VerificationService Resend send", opentracing.FollowsFrom(parent))
+		defer span.Finish()
+
+		// failure already logged by Send, response already sent
+		if err := v.Send(opentracing.ContextWithSpan(resendCtx, span), account); err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(log.String("response", err.Error()))
+		}
+	}()
+
+	return nil
+}
+
+// Wait block until every verification mail of Resend in background finished
+func (v *VerificationService) Wait() {
+	v.pending.Wait()
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"bytes"
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newAccessLogApp create fiber app with request id and access log middleware, log written into buffer
+func newAccessLogApp(accessLogConfig *config.AccessLog, trustedProxies []string) (*fiber.App, *bytes.Buffer) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger(&config.Log{Level: "info", Format: "json"}, buffer)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler:            handler.NewErrorHandler(logging.Discard()),
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies,
+		EnableIPValidation:      true,
+	})
+	app.Use(middleware.RequestIDMiddleware())
+	app.Use(middleware.AccessLogMiddleware(accessLogConfig, logger))
+	app.Post("/accounts/:email", func(ctx *fiber.Ctx) error {
+		ctx.Locals(logging.AccountIDKey, 7)
+		return ctx.SendString("created")
+	})
+	app.Get("/accounts/:email", func(ctx *fiber.Ctx) error {
+		return customError.New(customError.CodeAccountNotFound)
+	})
+
+	return app, buffer
+}
+
+// unit test request id accepted, generated and echoed
+func TestRequestIDMiddleware(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requestID string
+		accepted  bool
+	}{
+		{"accept request id from client", "0b6c3e4a-request-1", true},
+		{"generate when missing", "", false},
+		{"generate when contain space", "request 1", false},
+		{"generate when too long", strings.Repeat("a", 129), false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			app, buffer := newAccessLogApp(&config.AccessLog{Enabled: true, SuccessSampleRate: 1}, nil)
+
+			request := httptest.NewRequest(http.MethodPost, "/accounts/reoshby@gmail.com", nil)
+			request.Header.Set(fiber.HeaderXRequestID, testCase.requestID)
+
+			response, err := app.Test(request)
+			assert.Nil(t, err)
+
+			requestID := response.Header.Get(fiber.HeaderXRequestID)
+			assert.NotEmpty(t, requestID)
+			if testCase.accepted {
+				assert.Equal(t, testCase.requestID, requestID)
+			} else {
+				assert.NotEqual(t, testCase.requestID, requestID)
+			}
+
+			record := map[string]any{}
+			assert.Nil(t, json.Unmarshal(buffer.Bytes(), &record))
+			assert.Equal(t, requestID, record["request_id"])
+		})
+	}
+}
+
+// unit test content of access log line
+func TestAccessLogMiddleware(t *testing.T) {
+	t.Run("success request", func(t *testing.T) {
+		app, buffer := newAccessLogApp(&config.AccessLog{Enabled: true, SuccessSampleRate: 1}, []string{"0.0.0.0"})
+
+		request := httptest.NewRequest(http.MethodPost, "/accounts/reoshby@gmail.com", strings.NewReader(`{"username":"rshby"}`))
+		request.Header.Set(fiber.HeaderXForwardedFor, "203.0.113.7")
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		record := map[string]any{}
+		assert.Nil(t, json.Unmarshal(buffer.Bytes(), &record))
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "access", record["msg"])
+		assert.Equal(t, http.MethodPost, record["method"])
+		assert.Equal(t, "/accounts/:email", record["route_template"])
+		assert.Equal(t, float64(http.StatusOK), record["status"])
+		assert.Equal(t, float64(len(`{"username":"rshby"}`)), record["bytes_in"])
+		assert.Equal(t, float64(len("created")), record["bytes_out"])
+		assert.Equal(t, "203.0.113.7", record["client_ip"])
+		assert.Equal(t, float64(7), record["user_id"])
+		assert.Contains(t, record, "latency")
+	})
+	t.Run("proxy header ignored from untrusted proxy", func(t *testing.T) {
+		app, buffer := newAccessLogApp(&config.AccessLog{Enabled: true, SuccessSampleRate: 1}, []string{"10.0.0.1"})
+
+		request := httptest.NewRequest(http.MethodPost, "/accounts/reoshby@gmail.com", nil)
+		request.Header.Set(fiber.HeaderXForwardedFor, "203.0.113.7")
+
+		_, err := app.Test(request)
+		assert.Nil(t, err)
+
+		record := map[string]any{}
+		assert.Nil(t, json.Unmarshal(buffer.Bytes(), &record))
+		assert.Equal(t, "0.0.0.0", record["client_ip"])
+	})
+	t.Run("error request has final status and response written once", func(t *testing.T) {
+		app, buffer := newAccessLogApp(&config.AccessLog{Enabled: true, SuccessSampleRate: 1}, nil)
+
+		response, err := app.Test(httptest.NewRequest(http.MethodGet, "/accounts/reoshby@gmail.com", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+		responseBody := map[string]any{}
+		body, _ := io.ReadAll(response.Body)
+		assert.Nil(t, json.Unmarshal(body, &responseBody))
+		assert.Equal(t, "record not found", responseBody["message"])
+
+		record := map[string]any{}
+		assert.Nil(t, json.Unmarshal(buffer.Bytes(), &record))
+		assert.Equal(t, "WARN", record["level"])
+		assert.Equal(t, float64(http.StatusNotFound), record["status"])
+	})
+	t.Run("successful request sampled, error always written", func(t *testing.T) {
+		app, buffer := newAccessLogApp(&config.AccessLog{Enabled: true, SuccessSampleRate: 0}, nil)
+
+		for i := 0; i < 10; i++ {
+			_, err := app.Test(httptest.NewRequest(http.MethodPost, "/accounts/reoshby@gmail.com", nil))
+			assert.Nil(t, err)
+		}
+		assert.Empty(t, buffer.String())
+
+		_, err := app.Test(httptest.NewRequest(http.MethodGet, "/accounts/reoshby@gmail.com", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, strings.Count(buffer.String(), "\n"))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		app, buffer := newAccessLogApp(&config.AccessLog{Enabled: false}, nil)
+
+		response, err := app.Test(httptest.NewRequest(http.MethodGet, "/accounts/reoshby@gmail.com", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+		assert.Empty(t, buffer.String())
+	})
+}
@@ -1,10 +1,16 @@
 package test
 
 import (
+	"cobaMetrics/app/config"
 	"cobaMetrics/app/customError"
 	"cobaMetrics/app/handler"
 	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
 	"cobaMetrics/app/model/dto"
+	jwtModel "cobaMetrics/app/model/jwt"
+	mockConfig "cobaMetrics/app/test/mock/config"
 	mockService "cobaMetrics/app/test/mock/service"
 	mockError "cobaMetrics/app/test/mock/validationErrors"
 	"encoding/json"
@@ -27,7 +33,7 @@ func TestAddAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Add)
 
 		// test
@@ -56,7 +62,7 @@ func TestAddAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Add)
 
 		// create request body
@@ -99,7 +105,7 @@ func TestAddAccountHandler(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Add)
 
 		// create request body
@@ -138,7 +144,7 @@ func TestAddAccountHandler(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Add)
 
 		// mock
@@ -180,7 +186,7 @@ func TestAddAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Add)
 
 		// mock
@@ -224,7 +230,7 @@ func TestAddAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Add)
 
 		// mock
@@ -277,7 +283,7 @@ func TestGetByEmailAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetByEmail)
 
 		// mock
@@ -317,7 +323,7 @@ func TestGetByEmailAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetByEmail)
 
 		// mock
@@ -355,7 +361,7 @@ func TestGetByEmailAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetByEmail)
 
 		// mock
@@ -395,7 +401,7 @@ func TestGetByEmailAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetByEmail)
 
 		// mock
@@ -436,7 +442,7 @@ func TestGetByEmailAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetByEmail)
 
 		// mock
@@ -488,7 +494,7 @@ func TestLoginAccountHandler(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Login)
 
 		// test
@@ -517,7 +523,7 @@ func TestLoginAccountHandler(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Login)
 
 		// mock
@@ -561,7 +567,7 @@ func TestLoginAccountHandler(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Login)
 
 		// mock
@@ -604,7 +610,7 @@ func TestLoginAccountHandler(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Login)
 
 		// mock
@@ -648,7 +654,7 @@ func TestLoginAccountHandler(t *testing.T) {
 		accontServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accontServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Login)
 
 		// mock
@@ -692,7 +698,7 @@ func TestLoginAccountHandler(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Post("/", accountHandler.Login)
 
 		// mock
@@ -737,20 +743,37 @@ func TestLoginAccountHandler(t *testing.T) {
 
 // unit test get all account
 func TestGetAllAccountHandler(t *testing.T) {
-	t.Run("test get all account error page must numeric", func(t *testing.T) {
+	t.Run("test get all account query passed to service", func(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetAll)
 
+		// mock
+		accountServiceMock.Mock.On("GetAll", mock.Anything, &dto.GetAllAccountRequest{
+			Limit:        5,
+			Cursor:       "abc",
+			Sort:         "-created_at",
+			EmailPrefix:  "reo",
+			CreatedFrom:  "2024-01-01T00:00:00Z",
+			CreatedTo:    "2024-02-01T00:00:00Z",
+			IncludeTotal: true,
+		}).Return(nil, customError.NewWithKey(customError.CodeRequestQueryInvalid, i18n.MessageQueryCursor)).Times(1)
+
 		// test
 		// create http requset
 		request, err := http.NewRequest(http.MethodGet, "/", nil)
 		assert.Nil(t, err)
 
 		r := request.URL.Query()
-		r.Add("page", "a")
+		r.Add("limit", "5")
+		r.Add("cursor", "abc")
+		r.Add("sort", "-created_at")
+		r.Add("email_prefix", "reo")
+		r.Add("created_from", "2024-01-01T00:00:00Z")
+		r.Add("created_to", "2024-02-01T00:00:00Z")
+		r.Add("include_total", "true")
 		request.URL.RawQuery = r.Encode()
 
 		// receive response
@@ -767,14 +790,14 @@ func TestGetAllAccountHandler(t *testing.T) {
 		json.Unmarshal(body, &responseBody)
 
 		assert.Equal(t, http.StatusBadRequest, int(responseBody["status_code"].(float64)))
-		assert.Equal(t, helper.CodeToStatus(http.StatusBadRequest), responseBody["status"].(string))
-		assert.Equal(t, "query page must be numeric", responseBody["message"].(string))
+		assert.Equal(t, "query cursor not valid for this query", responseBody["message"].(string))
+		accountServiceMock.Mock.AssertExpectations(t)
 	})
 	t.Run("test get all accounts error limit must be numeric", func(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetAll)
 
 		// test
@@ -809,12 +832,12 @@ func TestGetAllAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetAll)
 
 		// mock
 		errMessage := "record not found"
-		accountService.Mock.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		accountService.Mock.On("GetAll", mock.Anything, mock.Anything).
 			Return(nil, customError.NewNotFoundError(errMessage)).Times(1)
 
 		// test
@@ -824,7 +847,6 @@ func TestGetAllAccountHandler(t *testing.T) {
 		assert.Nil(t, err)
 
 		r := request.URL.Query()
-		r.Add("page", "1")
 		r.Add("limit", "2")
 		request.URL.RawQuery = r.Encode()
 
@@ -851,12 +873,12 @@ func TestGetAllAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetAll)
 
 		// mock
 		errMessage := "error bad request"
-		accountService.Mock.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		accountService.Mock.On("GetAll", mock.Anything, mock.Anything).
 			Return(nil, customError.NewBadRequestError(errMessage)).Times(1)
 
 		// test
@@ -866,7 +888,6 @@ func TestGetAllAccountHandler(t *testing.T) {
 		assert.NotNil(t, request)
 
 		r := request.URL.Query()
-		r.Add("page", "1")
 		r.Add("limit", "2")
 		request.URL.RawQuery = r.Encode()
 
@@ -893,12 +914,12 @@ func TestGetAllAccountHandler(t *testing.T) {
 		accountServiceMock := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountServiceMock)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetAll)
 
 		// mock
 		errMessage := "error internal server error"
-		accountServiceMock.Mock.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		accountServiceMock.Mock.On("GetAll", mock.Anything, mock.Anything).
 			Return(nil, customError.NewInternalServerError(errMessage)).Times(1)
 
 		// test
@@ -908,7 +929,6 @@ func TestGetAllAccountHandler(t *testing.T) {
 		assert.Nil(t, err)
 
 		r := request.URL.Query()
-		r.Add("page", "1")
 		r.Add("limit", "2")
 		request.URL.RawQuery = r.Encode()
 
@@ -935,20 +955,25 @@ func TestGetAllAccountHandler(t *testing.T) {
 		accountService := mockService.NewAccountServiceMock()
 		accountHandler := handler.NewAccountHandler(accountService)
 
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
 		app.Get("/", accountHandler.GetAll)
 
 		// mock
-		accountService.Mock.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
-			Return([]dto.AccountDetailResponse{
-				{
-					Id:        1,
-					Email:     "reoshby@gmail.com",
-					Username:  "rshby",
-					Password:  "123456",
-					CreatedAt: "2020-10-10 00:00:00",
-					UpdatedAt: "2020-10-10 00:00:00",
+		accountService.Mock.On("GetAll", mock.Anything, mock.Anything).
+			Return(&dto.AccountPageResponse{
+				Count: 1,
+				Data: []dto.AccountDetailResponse{
+					{
+						Id:        1,
+						Email:     "reoshby@gmail.com",
+						Username:  "rshby",
+						Password:  "123456",
+						CreatedAt: "2020-10-10 00:00:00",
+						UpdatedAt: "2020-10-10 00:00:00",
+					},
 				},
+				NextCursor: "cursor",
+				HasMore:    true,
 			}, nil).Times(1)
 
 		// test
@@ -972,6 +997,80 @@ func TestGetAllAccountHandler(t *testing.T) {
 		json.Unmarshal(body, &responseBody)
 
 		assert.Equal(t, http.StatusOK, int(responseBody["status_code"].(float64)))
+		data := responseBody["data"].(map[string]any)
+		assert.Equal(t, 1, int(data["count"].(float64)))
+		assert.Equal(t, "cursor", data["next_cursor"])
+		assert.Equal(t, true, data["has_more"])
+		assert.NotContains(t, data, "total")
+		accountService.Mock.AssertExpectations(t)
+	})
+}
+
+// unit test unlock account handler, only admin allowed
+func TestUnlockAccountHandler(t *testing.T) {
+	newApp := func(email string, verified bool) (*fiber.App, *mockService.AccountServiceMock) {
+		accountService := mockService.NewAccountServiceMock()
+		accountHandler := handler.NewAccountHandler(accountService)
+
+		configMock := mockConfig.NewConfigMock()
+		configMock.Mock.On("Config").Return(&config.ConfigApp{
+			Admin: &config.Admin{Emails: []string{"admin@gmail.com"}},
+		})
+
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Post("/", func(ctx *fiber.Ctx) error {
+			// claims set by auth middleware
+			ctx.Locals(middleware.ClaimsKey, &jwtModel.Claims{Email: email, EmailVerified: verified})
+			return ctx.Next()
+		}, middleware.AdminMiddleware(configMock, logging.Discard()), accountHandler.Unlock)
+
+		return app, accountService
+	}
+
+	newRequest := func() *http.Request {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"reoshby@gmail.com"}`))
+		request.Header.Add("Content-Type", "application/json")
+		return request
+	}
+
+	t.Run("test unlock account error not admin", func(t *testing.T) {
+		app, accountService := newApp("reoshby@gmail.com", true)
+
+		response, err := app.Test(newRequest())
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+		accountService.Mock.AssertNotCalled(t, "Unlock", mock.Anything, mock.Anything)
+	})
+	t.Run("test unlock account error admin email not verified", func(t *testing.T) {
+		app, accountService := newApp("admin@gmail.com", false)
+
+		response, err := app.Test(newRequest())
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+		accountService.Mock.AssertNotCalled(t, "Unlock", mock.Anything, mock.Anything)
+	})
+	t.Run("test unlock account error not found", func(t *testing.T) {
+		app, accountService := newApp("Admin@gmail.com", true)
+		accountService.Mock.On("Unlock", mock.Anything, &dto.UnlockAccountRequest{Email: "reoshby@gmail.com"}).
+			Return(customError.New(customError.CodeAccountNotFound))
+
+		response, err := app.Test(newRequest())
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusNotFound, response.StatusCode)
+	})
+	t.Run("test unlock account success", func(t *testing.T) {
+		app, accountService := newApp("admin@gmail.com", true)
+		accountService.Mock.On("Unlock", mock.Anything, &dto.UnlockAccountRequest{Email: "reoshby@gmail.com"}).
+			Return(nil)
+
+		response, err := app.Test(newRequest())
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+
+		responseBody := map[string]any{}
+		body, _ := io.ReadAll(response.Body)
+		json.Unmarshal(body, &responseBody)
+		assert.Equal(t, "success unlock account", responseBody["message"])
 		accountService.Mock.AssertExpectations(t)
 	})
 }
@@ -0,0 +1,267 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	IService "cobaMetrics/app/service/interface"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/migration"
+	"cobaMetrics/database/transaction"
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSQLiteConfig create config of sqlite database file inside temporary directory
+func newSQLiteConfig(t *testing.T) *config.ConfigApp {
+	return &config.ConfigApp{
+		App: &config.App{Port: 5005},
+		Database: &config.Database{
+			Driver:              dialect.SQLite,
+			Name:                filepath.Join(t.TempDir(), "coba_metrics.db"),
+			Timeout:             5 * time.Second,
+			MaxOpenConns:        1,
+			MaxIdleConns:        1,
+			PingTimeout:         time.Second,
+			RetryInitialWait:    10 * time.Millisecond,
+			RetryMaxWait:        10 * time.Millisecond,
+			RetryMaxElapsedTime: time.Second,
+		},
+		Jwt: &config.JWT{
+			SecretKey: "rahasia",
+			Issuer:    "coba-metrics-app",
+			Subject:   "token",
+		},
+	}
+}
+
+// newSQLiteDB connect to sqlite and apply all migration
+func newSQLiteDB(t *testing.T, cfg *config.ConfigApp) (*sql.DB, dialect.Dialect) {
+	db := database.ConnectDB(cfg, logging.Discard())
+	t.Cleanup(func() { db.Close() })
+
+	sqliteDialect, err := database.NewDialect(cfg)
+	assert.Nil(t, err)
+
+	migrations, err := migration.Embedded(sqliteDialect.Name())
+	assert.Nil(t, err)
+
+	_, err = migration.NewMigrator(db, sqliteDialect, migrations).Up(context.Background())
+	assert.Nil(t, err)
+
+	return db, sqliteDialect
+}
+
+// newSQLiteSessionService create session service with session stored in sqlite database
+func newSQLiteSessionService(cfg *config.ConfigApp, cluster *database.Cluster, sqliteDialect dialect.Dialect) IService.ISessionService {
+	return service.NewSessionService(cfg, repository.NewSessionRepository(cluster, sqliteDialect, logging.Discard()), logging.Discard())
+}
+
+// integration test account service with sqlite database
+func TestAccountServiceSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "hashed").Return(true)
+	helperPasswordMock.Mock.On("NeedsRehash", "hashed").Return(false)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), cfg, repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard()), helperPasswordMock, newVerificationMock(), newSQLiteSessionService(cfg, cluster, sqliteDialect), logging.Discard())
+	ctx := context.Background()
+
+	t.Run("add account", func(t *testing.T) {
+		for _, request := range []dto.AddUserRequest{
+			{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"},
+			{Email: "reo@gmail.com", Username: "reo", Password: "123456"},
+		} {
+			account, err := accountService.Add(ctx, &request)
+			assert.Nil(t, err)
+			assert.NotZero(t, account.Id)
+			assert.Equal(t, request.Email, account.Email)
+		}
+	})
+	t.Run("add account error email already exist", func(t *testing.T) {
+		account, err := accountService.Add(ctx, &dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "other", Password: "123456"})
+		_, ok := err.(*customError.BadRequestError)
+		assert.Nil(t, account)
+		assert.True(t, ok)
+	})
+	t.Run("get account by email", func(t *testing.T) {
+		account, err := accountService.GetByEmail(ctx, "reoshby@gmail.com")
+		assert.Nil(t, err)
+		assert.Equal(t, "rshby", account.Username)
+		assert.NotEmpty(t, account.CreatedAt)
+	})
+	t.Run("get account by email not found", func(t *testing.T) {
+		account, err := accountService.GetByEmail(ctx, "notfound@gmail.com")
+		_, ok := err.(*customError.NotFoundError)
+		assert.Nil(t, account)
+		assert.True(t, ok)
+	})
+	t.Run("update account", func(t *testing.T) {
+		existing, err := accountService.GetByEmail(ctx, "reo@gmail.com")
+		assert.Nil(t, err)
+
+		account, err := accountService.Update(ctx, &dto.UpdateAccountRequest{
+			Id:              existing.Id,
+			Email:           "reo@gmail.com",
+			Username:        "reo_updated",
+			Password:        "123456",
+			ConfirmPassword: "123456",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "reo_updated", account.Username)
+	})
+	t.Run("get all account", func(t *testing.T) {
+		page, err := accountService.GetAll(ctx, &dto.GetAllAccountRequest{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, page.Count)
+		assert.False(t, page.HasMore)
+	})
+	t.Run("login", func(t *testing.T) {
+		login, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, login.Token)
+	})
+}
+
+// integration test account locked after failed login, with sqlite database
+func TestAccountLockoutSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.Lockout = &config.Lockout{
+		Enabled:      true,
+		Threshold:    2,
+		Duration:     time.Minute,
+		MaxDuration:  3 * time.Minute,
+		UniformError: true,
+	}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "hashed").Return(true)
+	helperPasswordMock.Mock.On("NeedsRehash", "hashed").Return(false)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "salah123", "hashed").Return(false)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	accountService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), cfg, accountRepository, helperPasswordMock, newVerificationMock(), newSQLiteSessionService(cfg, cluster, sqliteDialect), logging.Discard())
+	ctx := context.Background()
+
+	account, err := accountService.Add(ctx, &dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"})
+	assert.Nil(t, err)
+
+	login := func(email string, password string) (*dto.LoginResponse, string) {
+		response, err := accountService.Login(ctx, &dto.LoginRequest{Email: email, Password: password})
+		if err != nil {
+			return nil, customError.FromError(err).Code
+		}
+
+		return response, ""
+	}
+
+	// lockState set lock state of account directly, so test not wait lock expired
+	lockState := func(failedLoginCount int, lockedUntil *time.Time) {
+		assert.Nil(t, accountRepository.UpdateLoginState(ctx, account.Id, failedLoginCount, lockedUntil))
+	}
+
+	lockedFor := func() time.Duration {
+		existing, err := accountRepository.GetByEmail(ctx, "reoshby@gmail.com")
+		assert.Nil(t, err)
+		if existing.LockedUntil == nil {
+			return 0
+		}
+
+		return time.Until(*existing.LockedUntil).Round(time.Minute)
+	}
+
+	t.Run("unknown email and wrong password return same error", func(t *testing.T) {
+		_, code := login("notfound@gmail.com", "salah123")
+		assert.Equal(t, customError.CodeAccountInvalidLogin, code)
+
+		_, code = login("reoshby@gmail.com", "salah123")
+		assert.Equal(t, customError.CodeAccountInvalidLogin, code)
+		assert.Zero(t, lockedFor())
+	})
+	t.Run("locked after reach threshold", func(t *testing.T) {
+		_, code := login("reoshby@gmail.com", "salah123")
+		assert.Equal(t, customError.CodeAccountInvalidLogin, code)
+		assert.Equal(t, time.Minute, lockedFor())
+
+		// correct password still rejected while locked
+		_, code = login("reoshby@gmail.com", "123456")
+		assert.Equal(t, customError.CodeAccountLocked, code)
+	})
+	t.Run("lock duration doubled until max duration", func(t *testing.T) {
+		past := time.Now().Add(-time.Second)
+		lockState(3, &past)
+		_, code := login("reoshby@gmail.com", "salah123")
+		assert.Equal(t, customError.CodeAccountInvalidLogin, code)
+		assert.Equal(t, 2*time.Minute, lockedFor())
+
+		lockState(5, &past)
+		login("reoshby@gmail.com", "salah123")
+		assert.Equal(t, 3*time.Minute, lockedFor())
+	})
+	t.Run("unlock account", func(t *testing.T) {
+		assert.Nil(t, accountService.Unlock(ctx, &dto.UnlockAccountRequest{Email: "reoshby@gmail.com"}))
+
+		response, code := login("reoshby@gmail.com", "123456")
+		assert.Empty(t, code)
+		assert.NotEmpty(t, response.Token)
+	})
+	t.Run("success login reset failed login", func(t *testing.T) {
+		login("reoshby@gmail.com", "salah123")
+
+		_, code := login("reoshby@gmail.com", "123456")
+		assert.Empty(t, code)
+
+		existing, err := accountRepository.GetByEmail(ctx, "reoshby@gmail.com")
+		assert.Nil(t, err)
+		assert.Zero(t, existing.FailedLoginCount)
+		assert.Nil(t, existing.LockedUntil)
+	})
+	t.Run("concurrent failed login all counted", func(t *testing.T) {
+		lockoutCfg := *cfg
+		lockoutCfg.Lockout = &config.Lockout{Enabled: true, Threshold: 5, Duration: time.Minute, UniformError: true}
+		lockoutService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), &lockoutCfg, accountRepository, helperPasswordMock, newVerificationMock(), newSQLiteSessionService(cfg, cluster, sqliteDialect), logging.Discard())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				lockoutService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "salah123"})
+			}()
+		}
+		wg.Wait()
+
+		existing, err := accountRepository.GetByEmail(ctx, "reoshby@gmail.com")
+		assert.Nil(t, err)
+		assert.Equal(t, 4, existing.FailedLoginCount)
+		assert.Nil(t, existing.LockedUntil)
+
+		// fifth failure reach threshold
+		lockoutService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "salah123"})
+		assert.Equal(t, time.Minute, lockedFor())
+		assert.Nil(t, accountService.Unlock(ctx, &dto.UnlockAccountRequest{Email: "reoshby@gmail.com"}))
+	})
+	t.Run("unlock account not found", func(t *testing.T) {
+		err := accountService.Unlock(ctx, &dto.UnlockAccountRequest{Email: "notfound@gmail.com"})
+		assert.Equal(t, customError.CodeAccountNotFound, customError.FromError(err).Code)
+	})
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	IService "cobaMetrics/app/service/interface"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// listPages follow next_cursor from first page until has_more false, return id of every page
+func listPages(t *testing.T, accountService IService.IAccountService, request dto.GetAllAccountRequest) [][]int {
+	var pages [][]int
+	for {
+		page, err := accountService.GetAll(context.Background(), &request)
+		assert.Nil(t, err)
+		if err != nil {
+			return pages
+		}
+
+		var ids []int
+		for _, account := range page.Data {
+			ids = append(ids, account.Id)
+		}
+		pages = append(pages, ids)
+		assert.Equal(t, len(ids), page.Count)
+
+		if !page.HasMore {
+			assert.Empty(t, page.NextCursor)
+			return pages
+		}
+
+		request.Cursor = page.NextCursor
+	}
+}
+
+// integration test keyset pagination, sort and filter of list account with sqlite database
+func TestAccountListSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.Pagination = &config.Pagination{DefaultLimit: 3, MaxLimit: 4}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), cfg, repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard()), helperPasswordMock, newVerificationMock(), newSQLiteSessionService(cfg, cluster, sqliteDialect), logging.Discard())
+	ctx := context.Background()
+
+	// username in reverse order of id, created_at of most account in same second
+	for i, username := range []string{"eko", "dian", "citra", "budi", "andi"} {
+		_, err := accountService.Add(ctx, &dto.AddUserRequest{Email: fmt.Sprintf("%v_%v@gmail.com", username, i), Username: username, Password: "123456"})
+		assert.Nil(t, err)
+	}
+	_, err := db.Exec("UPDATE accounts SET created_at = ? WHERE id = 5", "2020-01-01 00:00:00")
+	assert.Nil(t, err)
+
+	t.Run("limit validated", func(t *testing.T) {
+		for _, limit := range []int{-1, 5, 1000000} {
+			_, err := accountService.GetAll(ctx, &dto.GetAllAccountRequest{Limit: limit})
+			assert.Equal(t, customError.CodeRequestQueryInvalid, customError.FromError(err).Code, limit)
+		}
+
+		page, err := accountService.GetAll(ctx, &dto.GetAllAccountRequest{})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, page.Count)
+		assert.True(t, page.HasMore)
+		assert.Nil(t, page.Total)
+	})
+	t.Run("keyset by id", func(t *testing.T) {
+		pages := listPages(t, accountService, dto.GetAllAccountRequest{Limit: 2})
+		assert.Len(t, pages, 3)
+		assert.ElementsMatch(t, []int{1, 2}, pages[0])
+		assert.ElementsMatch(t, []int{3, 4}, pages[1])
+		assert.ElementsMatch(t, []int{5}, pages[2])
+
+		pages = listPages(t, accountService, dto.GetAllAccountRequest{Limit: 4, Sort: "-id"})
+		assert.ElementsMatch(t, []int{5, 4, 3, 2}, pages[0])
+		assert.ElementsMatch(t, []int{1}, pages[1])
+	})
+	t.Run("sort by username and email", func(t *testing.T) {
+		pages := listPages(t, accountService, dto.GetAllAccountRequest{Limit: 2, Sort: "username"})
+		assert.Len(t, pages, 3)
+		assert.ElementsMatch(t, []int{5, 4}, pages[0])
+		assert.ElementsMatch(t, []int{3, 2}, pages[1])
+		assert.ElementsMatch(t, []int{1}, pages[2])
+
+		pages = listPages(t, accountService, dto.GetAllAccountRequest{Limit: 3, Sort: "-email"})
+		assert.ElementsMatch(t, []int{1, 2, 3}, pages[0])
+		assert.ElementsMatch(t, []int{4, 5}, pages[1])
+	})
+	t.Run("sort by created_at break tie with id", func(t *testing.T) {
+		pages := listPages(t, accountService, dto.GetAllAccountRequest{Limit: 2, Sort: "created_at"})
+		assert.Len(t, pages, 3)
+		assert.ElementsMatch(t, []int{5, 1}, pages[0])
+		assert.ElementsMatch(t, []int{2, 3}, pages[1])
+		assert.ElementsMatch(t, []int{4}, pages[2])
+
+		pages = listPages(t, accountService, dto.GetAllAccountRequest{Limit: 2, Sort: "-created_at"})
+		assert.ElementsMatch(t, []int{4, 3}, pages[0])
+		assert.ElementsMatch(t, []int{2, 1}, pages[1])
+		assert.ElementsMatch(t, []int{5}, pages[2])
+	})
+	t.Run("filter", func(t *testing.T) {
+		page, err := accountService.GetAll(ctx, &dto.GetAllAccountRequest{EmailPrefix: "budi_", IncludeTotal: true})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, page.Count)
+		assert.Equal(t, 1, *page.Total)
+
+		// _ matched literally, not as wildcard
+		page, err = accountService.GetAll(ctx, &dto.GetAllAccountRequest{EmailPrefix: "ek_"})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, page.Count)
+
+		page, err = accountService.GetAll(ctx, &dto.GetAllAccountRequest{Limit: 1, CreatedFrom: "2021-01-01T00:00:00Z", IncludeTotal: true})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, page.Count)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, 4, *page.Total)
+
+		page, err = accountService.GetAll(ctx, &dto.GetAllAccountRequest{CreatedFrom: "2019-12-31T00:00:00+07:00", CreatedTo: "2020-01-01T07:00:01+07:00"})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, page.Count)
+		assert.Equal(t, 5, page.Data[0].Id)
+	})
+	t.Run("query not valid", func(t *testing.T) {
+		first, err := accountService.GetAll(ctx, &dto.GetAllAccountRequest{Limit: 1, Sort: "username"})
+		assert.Nil(t, err)
+
+		for _, request := range []dto.GetAllAccountRequest{
+			{Sort: "password"},
+			{CreatedFrom: "2020-01-01"},
+			{Cursor: "bukan-cursor"},
+			{Cursor: first.NextCursor},
+			{Cursor: first.NextCursor, Sort: "-username"},
+		} {
+			_, err := accountService.GetAll(ctx, &request)
+			assert.Equal(t, customError.CodeRequestQueryInvalid, customError.FromError(err).Code, request)
+		}
+	})
+	t.Run("cursor of id shared by empty sort", func(t *testing.T) {
+		page, err := accountService.GetAll(ctx, &dto.GetAllAccountRequest{Limit: 4})
+		assert.Nil(t, err)
+
+		page, err = accountService.GetAll(ctx, &dto.GetAllAccountRequest{Sort: "id", Cursor: page.NextCursor})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, page.Count)
+		assert.Equal(t, 5, page.Data[0].Id)
+	})
+}
+
+// unit test cursor encode and decode
+func TestCursorHelper(t *testing.T) {
+	type cursor struct {
+		Id int `json:"i"`
+	}
+
+	var decoded cursor
+	assert.True(t, helper.DecodeCursor(helper.EncodeCursor(&cursor{Id: 7}), &decoded))
+	assert.Equal(t, 7, decoded.Id)
+	assert.False(t, helper.DecodeCursor("!!", &decoded))
+	assert.False(t, helper.DecodeCursor(helper.EncodeCursor("teks"), &decoded))
+}
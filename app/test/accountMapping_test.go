@@ -0,0 +1,92 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	"cobaMetrics/app/service"
+	mckConfig "cobaMetrics/app/test/mock/config"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mck "cobaMetrics/app/test/mock/repository"
+	"cobaMetrics/database/transaction"
+	"context"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newAccounts(count int) []entity.Account {
+	accounts := make([]entity.Account, count)
+	createdAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range accounts {
+		accounts[i] = entity.Account{
+			Id:        i + 1,
+			Email:     fmt.Sprintf("user%v@gmail.com", i+1),
+			Username:  fmt.Sprintf("user%v", i+1),
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Second),
+			UpdatedAt: createdAt,
+		}
+	}
+
+	return accounts
+}
+
+// unit test mapping keep order and every row
+func TestMapAccountDetails(t *testing.T) {
+	accounts := newAccounts(100)
+
+	response := service.MapAccountDetails(accounts)
+	assert.Len(t, response, len(accounts))
+	for i, account := range response {
+		if !assert.Equal(t, i+1, account.Id) {
+			break
+		}
+	}
+	assert.Equal(t, "user100@gmail.com", response[len(response)-1].Email)
+	assert.Equal(t, "2020-01-01 00:01:39", response[len(response)-1].CreatedAt)
+
+	assert.Empty(t, service.MapAccountDetails(nil))
+}
+
+// stress test GetAll called concurrently return page in order of repository, run with -race
+func TestGetAllAccountsConcurrent(t *testing.T) {
+	db, _, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	limit := 100
+	accounts := newAccounts(limit + 1)
+
+	configMock := mckConfig.NewConfigMock()
+	configMock.Mock.On("Config").Return(&config.ConfigApp{Pagination: &config.Pagination{DefaultLimit: limit, MaxLimit: limit}})
+	accountRepositoryMock := mck.NewAccountRepository()
+	accountRepositoryMock.Mock.On("GetAll", mock.Anything, mock.Anything).Return(accounts, nil)
+	accountService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), configMock, accountRepositoryMock, mckHelper.NewHelperPasswordMock(), newVerificationMock(), newSessionMock(), logging.Discard())
+
+	wg := &sync.WaitGroup{}
+	pages := make([]*dto.AccountPageResponse, 50)
+	for i := range pages {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pages[i], _ = accountService.GetAll(context.Background(), &dto.GetAllAccountRequest{})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, page := range pages {
+		assert.NotNil(t, page)
+		assert.Equal(t, limit, page.Count)
+		assert.True(t, page.HasMore)
+		for i, account := range page.Data {
+			if !assert.Equal(t, i+1, account.Id) {
+				break
+			}
+		}
+	}
+}
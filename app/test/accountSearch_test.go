@@ -0,0 +1,184 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mockService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/transaction"
+	"cobaMetrics/metrics"
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// unit test highlight of search term
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "<mark>Rsh</mark>by", helper.Highlight("Rshby", []string{"rsh"}))
+	assert.Equal(t, "reo@<mark>gmail.com</mark>", helper.Highlight("reo@gmail.com", []string{"gmail.com"}))
+	assert.Equal(t, "<mark>reo</mark>@<mark>gmail</mark>.com", helper.Highlight("reo@gmail.com", []string{"gmail", "reo"}))
+	assert.Equal(t, "<mark>ab</mark>x<mark>ab</mark>", helper.Highlight("abxab", []string{"ab"}))
+	assert.Equal(t, "a&lt;b", helper.Highlight("a<b", []string{"zz"}))
+	assert.Equal(t, []string{"gmail", "com"}, helper.SearchWords("@gmail.com"))
+}
+
+// integration test search account with LIKE, dialect without full text index, with sqlite database
+func TestAccountSearchSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.Pagination = &config.Pagination{DefaultLimit: 2, MaxLimit: 10}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	accountService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), cfg, accountRepository, helperPasswordMock, newVerificationMock(), newSQLiteSessionService(cfg, cluster, sqliteDialect), logging.Discard())
+	metricsApp := metrics.AddMetrics()
+	searchService := service.NewAccountSearchService(cfg, accountRepository, metricsApp, logging.Discard())
+	ctx := context.Background()
+
+	for _, request := range []dto.AddUserRequest{
+		{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"},
+		{Email: "rshby_support@yahoo.com", Username: "support", Password: "123456"},
+		{Email: "budi@gmail.com", Username: "budi_rshby", Password: "123456"},
+		{Email: "andi@corp.co.id", Username: "andi", Password: "123456"},
+	} {
+		_, err := accountService.Add(ctx, &request)
+		assert.Nil(t, err)
+	}
+
+	search := func(request dto.SearchAccountRequest) *dto.AccountSearchResponse {
+		response, err := searchService.Search(ctx, &request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	t.Run("ranked by relevance", func(t *testing.T) {
+		response := search(dto.SearchAccountRequest{Q: "RSHBY", Limit: 10})
+		assert.Equal(t, 3, response.Count)
+		assert.False(t, response.HasMore)
+
+		// exact username, then part of username, then only email
+		assert.Equal(t, "rshby", response.Data[0].Username)
+		assert.Equal(t, "budi_rshby", response.Data[1].Username)
+		assert.Equal(t, "support", response.Data[2].Username)
+		assert.Greater(t, response.Data[0].Rank, response.Data[1].Rank)
+		assert.Greater(t, response.Data[1].Rank, response.Data[2].Rank)
+
+		assert.Equal(t, "<mark>rshby</mark>", response.Data[0].Highlight.Username)
+		assert.Equal(t, "<mark>rshby</mark>_support@yahoo.com", response.Data[2].Highlight.Email)
+	})
+	t.Run("email domain and every term must match", func(t *testing.T) {
+		response := search(dto.SearchAccountRequest{Q: "gmail.com", Limit: 10})
+		assert.Equal(t, 2, response.Count)
+
+		response = search(dto.SearchAccountRequest{Q: "gmail  budi", Limit: 10})
+		assert.Equal(t, 1, response.Count)
+		assert.Equal(t, "budi@gmail.com", response.Data[0].Email)
+		assert.Equal(t, "<mark>budi</mark>@<mark>gmail</mark>.com", response.Data[0].Highlight.Email)
+
+		// _ matched literally
+		assert.Equal(t, 0, search(dto.SearchAccountRequest{Q: "rshby_s_"}).Count)
+	})
+	t.Run("paginated with cursor", func(t *testing.T) {
+		first := search(dto.SearchAccountRequest{Q: "rshby"})
+		assert.Equal(t, 2, first.Count)
+		assert.True(t, first.HasMore)
+
+		second := search(dto.SearchAccountRequest{Q: " rshby ", Cursor: first.NextCursor})
+		assert.Equal(t, 1, second.Count)
+		assert.False(t, second.HasMore)
+		assert.Equal(t, "support", second.Data[0].Username)
+
+		_, err := searchService.Search(ctx, &dto.SearchAccountRequest{Q: "gmail", Cursor: first.NextCursor})
+		assert.Equal(t, customError.CodeRequestQueryInvalid, customError.FromError(err).Code)
+	})
+	t.Run("query not valid", func(t *testing.T) {
+		for _, request := range []dto.SearchAccountRequest{
+			{Q: "  "},
+			{Q: "a b c d e f"},
+			{Q: string(make([]byte, 101))},
+			{Q: "rshby", Limit: 11},
+			{Q: "rshby", Cursor: "bukan-cursor"},
+		} {
+			_, err := searchService.Search(ctx, &request)
+			assert.Equal(t, customError.CodeRequestQueryInvalid, customError.FromError(err).Code, request.Q)
+		}
+	})
+	t.Run("latency observed", func(t *testing.T) {
+		search(dto.SearchAccountRequest{Q: "tidak-ada"})
+
+		// one series of outcome hit and one of outcome empty, query not valid not observed
+		assert.Equal(t, 2, testutil.CollectAndCount(metricsApp.SearchDuration, "account_search_duration_seconds"))
+	})
+}
+
+// unit test search of mysql use full text index, word too short for index use LIKE
+func TestAccountSearchMySQL(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mysqlDialect, _ := dialect.New(dialect.MySQL)
+	cluster := database.NewCluster(db, []*sql.DB{}, database.PolicyRoundRobin, 1, time.Second, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, mysqlDialect, logging.Discard())
+
+	columns := []string{"id", "email", "username", "password", "created_at", "updated_at", "failed_login_count", "locked_until", "token_version", "verified_at", "totp_secret", "totp_enabled", "totp_last_step", "search_rank"}
+
+	dbMock.ExpectQuery("MATCH\\(accounts.username, accounts.email\\) AGAINST \\(\\? IN BOOLEAN MODE\\) AS search_rank FROM accounts WHERE MATCH").
+		WithArgs("+gmail* +com* +rsh*", "+gmail* +com* +rsh*", 3, 0).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "reoshby@gmail.com", "rshby", "hashed", time.Now(), time.Now(), 0, nil, 0, nil, "", false, 0, 1.5))
+	results, err := accountRepository.Search(context.Background(), &entity.AccountSearchQuery{Terms: []string{"gmail.com", "rsh"}, Limit: 3})
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, 1.5, results[0].Rank)
+
+	dbMock.ExpectQuery("LIKE").
+		WithArgs("co", "co%", "%co%", "%co%", "%co%", "%co%", 3, 3).
+		WillReturnRows(sqlmock.NewRows(columns))
+	results, err = accountRepository.Search(context.Background(), &entity.AccountSearchQuery{Terms: []string{"co"}, Limit: 3, Offset: 3})
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+	assert.Nil(t, dbMock.ExpectationsWereMet())
+}
+
+// unit test search account handler
+func TestAccountSearchHandler(t *testing.T) {
+	newApp := func(searchService *mockService.AccountSearchServiceMock) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Get("/accounts/search", handler.NewAccountSearchHandler(searchService).Search)
+		return app
+	}
+
+	t.Run("query passed to service", func(t *testing.T) {
+		searchService := mockService.NewAccountSearchServiceMock()
+		searchService.Mock.On("Search", mock.Anything, &dto.SearchAccountRequest{Q: "gmail.com", Limit: 5, Cursor: "abc"}).
+			Return(&dto.AccountSearchResponse{Count: 0, Data: []dto.AccountSearchResult{}}, nil)
+
+		response, err := newApp(searchService).Test(httptest.NewRequest(http.MethodGet, "/accounts/search?q=gmail.com&limit=5&cursor=abc", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		searchService.Mock.AssertExpectations(t)
+	})
+	t.Run("limit must be numeric", func(t *testing.T) {
+		response, err := newApp(mockService.NewAccountSearchServiceMock()).Test(httptest.NewRequest(http.MethodGet, "/accounts/search?q=reo&limit=a", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}
@@ -4,12 +4,15 @@ import (
 	"cobaMetrics/app/config"
 	"cobaMetrics/app/customError"
 	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
 	"cobaMetrics/app/model/dto"
 	"cobaMetrics/app/model/entity"
 	"cobaMetrics/app/service"
 	mckConfig "cobaMetrics/app/test/mock/config"
 	mckHelper "cobaMetrics/app/test/mock/helper"
 	mck "cobaMetrics/app/test/mock/repository"
+	mckService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/database/transaction"
 	"context"
 	"encoding/json"
 	"errors"
@@ -22,15 +25,29 @@ import (
 	"time"
 )
 
+// newVerificationMock create verification service mock that accept every Send
+func newVerificationMock() *mckService.VerificationServiceMock {
+	verificationMock := mckService.NewVerificationServiceMock()
+	verificationMock.Mock.On("Send", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return verificationMock
+}
+
+// newSessionMock create session service mock that start session for every login
+func newSessionMock() *mckService.SessionServiceMock {
+	sessionMock := mckService.NewSessionServiceMock()
+	sessionMock.Mock.On("Start", mock.Anything, mock.Anything, mock.Anything).Return(&dto.LoginResponse{Token: "token", CreatedAt: time.Now().Format(time.RFC3339)}, nil).Maybe()
+	return sessionMock
+}
+
 // unit test method Add
 func TestAddUserService(t *testing.T) {
 	t.Run("add account error validate", func(t *testing.T) {
 		db, dbMock, _ := sqlmock.New()
-		validate := validator.New()
+		validate := helper.NewValidator()
 		config := mckConfig.NewConfigMock()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		accountRepository := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, config, accountRepository, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, config, accountRepository, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -58,11 +75,11 @@ func TestAddUserService(t *testing.T) {
 	})
 	t.Run("add account error hash password", func(t *testing.T) {
 		db, dbMock, _ := sqlmock.New()
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -89,11 +106,11 @@ func TestAddUserService(t *testing.T) {
 	})
 	t.Run("add account error internal server error", func(t *testing.T) {
 		db, dbMock, _ := sqlmock.New()
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPassword := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPassword)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPassword, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -102,11 +119,11 @@ func TestAddUserService(t *testing.T) {
 		helperPassword.Mock.On("HashPassword", mock.Anything).
 			Return("123456", nil)
 
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(nil, errors.New("record not found")).Times(1)
 
 		errMessage := "failed to add new data"
-		accountRepositoryMock.Mock.On("Add", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("Add", mock.Anything, mock.Anything).
 			Return(nil, customError.NewInternalServerError(errMessage))
 
 		// test
@@ -129,11 +146,11 @@ func TestAddUserService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPassword := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPassword)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPassword, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -142,11 +159,11 @@ func TestAddUserService(t *testing.T) {
 		helperPassword.Mock.On("HashPassword", mock.Anything).
 			Return("123456", nil)
 
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(nil, errors.New("record not found")).Times(1)
 
 		errMessage := "error bad request when add data"
-		accountRepositoryMock.Mock.On("Add", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("Add", mock.Anything, mock.Anything).
 			Return(nil, customError.NewBadRequestError(errMessage))
 
 		// test
@@ -170,11 +187,11 @@ func TestAddUserService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -183,11 +200,11 @@ func TestAddUserService(t *testing.T) {
 		helperPasswordMock.Mock.On("HashPassword", mock.Anything).
 			Return("123456", nil)
 
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(nil, errors.New("record not found")).Times(1)
 
 		errMessage := "record not found"
-		accountRepositoryMock.Mock.On("Add", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("Add", mock.Anything, mock.Anything).
 			Return(nil, customError.NewNotFoundError(errMessage))
 
 		// test
@@ -212,11 +229,11 @@ func TestAddUserService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -226,7 +243,7 @@ func TestAddUserService(t *testing.T) {
 			Return("123456", nil).Times(1)
 
 		errMessage := "email already exist in database"
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(&entity.Account{
 				Id:        1,
 				Email:     "reoshby@gmail.com",
@@ -253,11 +270,11 @@ func TestAddUserService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -266,10 +283,10 @@ func TestAddUserService(t *testing.T) {
 		helperPasswordMock.Mock.On("HashPassword", mock.Anything).
 			Return("123456", nil)
 
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(nil, errors.New("record not found")).Times(1)
 
-		accountRepositoryMock.Mock.On("Add", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("Add", mock.Anything, mock.Anything).
 			Return(&entity.Account{
 				Id:        1,
 				Email:     "reoshby@gmail.com",
@@ -304,11 +321,11 @@ func TestGetAccountByEmailService(t *testing.T) {
 		db, _, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// test
 		email := "reoshby"
@@ -323,18 +340,18 @@ func TestGetAccountByEmailService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectRollback()
 
 		errMessage := "error internal server error"
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(nil, customError.NewInternalServerError(errMessage))
 
 		// test
@@ -351,18 +368,18 @@ func TestGetAccountByEmailService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectRollback()
 
 		errMessage := "failed to get record error bad request"
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(nil, customError.NewBadRequestError(errMessage))
 
 		// test
@@ -380,18 +397,18 @@ func TestGetAccountByEmailService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectRollback()
 
 		errMessage := "record not found"
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(nil, customError.NewNotFoundError(errMessage))
 
 		// test
@@ -409,17 +426,17 @@ func TestGetAccountByEmailService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectCommit()
 
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(&entity.Account{
 				Id:        1,
 				Email:     "reoshby@gmail.com",
@@ -447,11 +464,11 @@ func TestUpdateAccountService(t *testing.T) {
 		db, _, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// test
 		request := dto.UpdateAccountRequest{
@@ -478,11 +495,11 @@ func TestUpdateAccountService(t *testing.T) {
 		db, _, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		errMessage := "cant hash password"
@@ -511,11 +528,11 @@ func TestUpdateAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		accountRepositoryMock := mck.NewAccountRepository()
 		configMock := mckConfig.NewConfigMock()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -525,7 +542,7 @@ func TestUpdateAccountService(t *testing.T) {
 			Return("123456", nil)
 
 		errMessage := "error internal server"
-		accountRepositoryMock.Mock.On("Update", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("Update", mock.Anything, mock.Anything).
 			Return(nil, customError.NewInternalServerError(errMessage))
 
 		// test
@@ -550,11 +567,11 @@ func TestUpdateAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		accountRepositoryMock := mck.NewAccountRepository()
 		configMock := mckConfig.NewConfigMock()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -564,7 +581,7 @@ func TestUpdateAccountService(t *testing.T) {
 			Return("123456", nil)
 
 		errMessage := "record not fouund"
-		accountRepositoryMock.Mock.On("Update", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("Update", mock.Anything, mock.Anything).
 			Return(nil, customError.NewNotFoundError(errMessage))
 
 		// test
@@ -590,11 +607,11 @@ func TestUpdateAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -604,7 +621,7 @@ func TestUpdateAccountService(t *testing.T) {
 			Return("123456", nil)
 
 		errMessage := "error bad request"
-		accountRepositoryMock.Mock.On("Update", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("Update", mock.Anything, mock.Anything).
 			Return(nil, customError.NewNotFoundError(errMessage))
 
 		// test
@@ -630,11 +647,11 @@ func TestUpdateAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		accountRepositoryMock := mck.NewAccountRepository()
 		configMock := mckConfig.NewConfigMock()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -643,7 +660,7 @@ func TestUpdateAccountService(t *testing.T) {
 		helperPasswordMock.Mock.On("HashPassword", mock.Anything).
 			Return("123456", nil).Times(1)
 
-		accountRepositoryMock.Mock.On("Update", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("Update", mock.Anything, mock.Anything).
 			Return(&entity.Account{
 				Id:        1,
 				Email:     "reoshby@gmail.com",
@@ -681,11 +698,11 @@ func TestLoginAccountService(t *testing.T) {
 		db, _, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// test
 		request := dto.LoginRequest{
@@ -709,20 +726,27 @@ func TestLoginAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectRollback()
 
+		// lockout not configured
+		configMock.Mock.On("Config").Return(&config.ConfigApp{}).Times(1)
+
 		errMessage := "record not found"
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(nil, customError.NewNotFoundError(errMessage)).Times(1)
 
+		// unknown email still check password, so it take as long as wrong password
+		helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("dummy-hash", nil).Times(1)
+		helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "dummy-hash").Return(false).Times(1)
+
 		// test
 		request := dto.LoginRequest{
 			Email:    "reoshby@gmail.com",
@@ -738,22 +762,41 @@ func TestLoginAccountService(t *testing.T) {
 		_, ok := err.(*customError.NotFoundError)
 		assert.True(t, ok)
 		accountRepositoryMock.Mock.AssertExpectations(t)
+		helperPasswordMock.Mock.AssertExpectations(t)
+	})
+	t.Run("test login error database not reported as not found", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		configMock := mckConfig.NewConfigMock()
+		accountRepositoryMock := mck.NewAccountRepository()
+		helperPasswordMock := mckHelper.NewHelperPasswordMock()
+		accountService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
+
+		configMock.Mock.On("Config").Return(&config.ConfigApp{Lockout: &config.Lockout{UniformError: true}}).Times(1)
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, "reoshby@gmail.com").
+			Return(nil, customError.NewInternalServerError("connection refused")).Times(1)
+
+		login, err := accountService.Login(context.Background(), &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		assert.Nil(t, login)
+		assert.Equal(t, customError.CodeInternal, customError.FromError(err).Code)
+		helperPasswordMock.Mock.AssertNotCalled(t, "CheckPasswordHash", mock.Anything, mock.Anything)
 	})
 	t.Run("test login error password not match", func(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectRollback()
 
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(&entity.Account{
 				Id:        1,
 				Email:     "reoshby@gmail.com",
@@ -766,6 +809,7 @@ func TestLoginAccountService(t *testing.T) {
 		errMessage := "password not match"
 		helperPasswordMock.Mock.On("CheckPasswordHash", mock.Anything, mock.Anything).
 			Return(false).Times(1)
+		configMock.Mock.On("Config").Return(&config.ConfigApp{}).Times(1)
 
 		// test
 		request := dto.LoginRequest{
@@ -789,11 +833,11 @@ func TestLoginAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		configMock := mckConfig.NewConfigMock()
 		accountRepositoryMock := mck.NewAccountRepository()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
@@ -811,7 +855,7 @@ func TestLoginAccountService(t *testing.T) {
 			},
 		}).Times(1)
 
-		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, mock.Anything).
 			Return(&entity.Account{
 				Id:        0,
 				Email:     "reoshby@gmail.com",
@@ -823,6 +867,8 @@ func TestLoginAccountService(t *testing.T) {
 
 		helperPasswordMock.Mock.On("CheckPasswordHash", mock.Anything, mock.Anything).
 			Return(true).Times(1)
+		helperPasswordMock.Mock.On("NeedsRehash", "123456").
+			Return(false).Times(1)
 
 		// test
 		request := dto.LoginRequest{
@@ -837,6 +883,62 @@ func TestLoginAccountService(t *testing.T) {
 		loginJson, _ := json.Marshal(&login)
 		fmt.Println(string(loginJson))
 	})
+	t.Run("test login success rehash outdated password", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		validate := helper.NewValidator()
+		configMock := mckConfig.NewConfigMock()
+		accountRepositoryMock := mck.NewAccountRepository()
+		helperPasswordMock := mckHelper.NewHelperPasswordMock()
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
+
+		// mock
+		configMock.Mock.On("Config").Return(&config.ConfigApp{
+			Jwt: &config.JWT{SecretKey: "sangatrahasia123"},
+		}).Times(1)
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, "reoshby@gmail.com").
+			Return(&entity.Account{Id: 1, Email: "reoshby@gmail.com", Password: "$2a$14$old"}, nil).Times(1)
+		helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "$2a$14$old").Return(true).Times(1)
+		helperPasswordMock.Mock.On("NeedsRehash", "$2a$14$old").Return(true).Times(1)
+		helperPasswordMock.Mock.On("HashPassword", "123456").Return("$argon2id$new", nil).Times(1)
+		accountRepositoryMock.Mock.On("UpdatePassword", mock.Anything, 1, "$argon2id$new").Return(nil).Times(1)
+
+		// test
+		login, err := accountService.Login(context.Background(), &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, login.Token)
+
+		accountRepositoryMock.Mock.AssertExpectations(t)
+		helperPasswordMock.Mock.AssertExpectations(t)
+	})
+	t.Run("test login success when rehash failed", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		validate := helper.NewValidator()
+		configMock := mckConfig.NewConfigMock()
+		accountRepositoryMock := mck.NewAccountRepository()
+		helperPasswordMock := mckHelper.NewHelperPasswordMock()
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
+
+		// mock
+		configMock.Mock.On("Config").Return(&config.ConfigApp{
+			Jwt: &config.JWT{SecretKey: "sangatrahasia123"},
+		}).Times(1)
+		accountRepositoryMock.Mock.On("GetByEmail", mock.Anything, "reoshby@gmail.com").
+			Return(&entity.Account{Id: 1, Email: "reoshby@gmail.com", Password: "$2a$14$old"}, nil).Times(1)
+		helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "$2a$14$old").Return(true).Times(1)
+		helperPasswordMock.Mock.On("NeedsRehash", "$2a$14$old").Return(true).Times(1)
+		helperPasswordMock.Mock.On("HashPassword", "123456").Return("$argon2id$new", nil).Times(1)
+		accountRepositoryMock.Mock.On("UpdatePassword", mock.Anything, 1, "$argon2id$new").
+			Return(customError.NewInternalServerError("database down")).Times(1)
+
+		// test
+		login, err := accountService.Login(context.Background(), &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, login.Token)
+	})
 }
 
 func TestGetAllAccountService(t *testing.T) {
@@ -844,22 +946,23 @@ func TestGetAllAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		configMock := mckConfig.NewConfigMock()
+		configMock.Mock.On("Config").Return(&config.ConfigApp{})
 		accountRepositoryMock := mck.NewAccountRepository()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectRollback()
 
 		errMessage := "database refused"
-		accountRepositoryMock.Mock.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetAll", mock.Anything, mock.Anything).
 			Return(nil, customError.NewInternalServerError(errMessage)).Times(1)
 
 		// test
-		accounts, err := accountService.GetAll(context.Background(), 10, 2)
+		accounts, err := accountService.GetAll(context.Background(), &dto.GetAllAccountRequest{Limit: 10})
 		assert.Nil(t, accounts)
 		assert.NotNil(t, err)
 		assert.Error(t, err)
@@ -870,22 +973,23 @@ func TestGetAllAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		configMock := mckConfig.NewConfigMock()
+		configMock.Mock.On("Config").Return(&config.ConfigApp{})
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
 		accountRepositoryMock := mck.NewAccountRepository()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectRollback()
 
 		errMessage := "record not found"
-		accountRepositoryMock.Mock.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetAll", mock.Anything, mock.Anything).
 			Return(nil, customError.NewNotFoundError(errMessage)).Times(1)
 
 		// test
-		all, err := accountService.GetAll(context.Background(), 10, 2)
+		all, err := accountService.GetAll(context.Background(), &dto.GetAllAccountRequest{Limit: 10})
 		assert.Nil(t, all)
 		assert.NotNil(t, err)
 		assert.Error(t, err)
@@ -896,17 +1000,18 @@ func TestGetAllAccountService(t *testing.T) {
 		db, dbMock, err := sqlmock.New()
 		assert.Nil(t, err)
 
-		validate := validator.New()
+		validate := helper.NewValidator()
 		configMock := mckConfig.NewConfigMock()
+		configMock.Mock.On("Config").Return(&config.ConfigApp{})
 		accountRepositoryMock := mck.NewAccountRepository()
 		helperPasswordMock := mckHelper.NewHelperPasswordMock()
-		accountService := service.NewAccountService(db, validate, configMock, accountRepositoryMock, helperPasswordMock)
+		accountService := service.NewAccountService(transaction.NewTxManager(db), validate, configMock, accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
 
 		// mock
 		dbMock.ExpectBegin()
 		dbMock.ExpectCommit()
 
-		accountRepositoryMock.Mock.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		accountRepositoryMock.Mock.On("GetAll", mock.Anything, mock.Anything).
 			Return([]entity.Account{
 				{
 					Id:        1,
@@ -927,10 +1032,12 @@ func TestGetAllAccountService(t *testing.T) {
 			}, nil).Times(1)
 
 		// test
-		accounts, err := accountService.GetAll(context.Background(), 2, 1)
+		page, err := accountService.GetAll(context.Background(), &dto.GetAllAccountRequest{Limit: 2})
 		assert.Nil(t, err)
-		assert.NotNil(t, accounts)
-		assert.Equal(t, 2, len(accounts))
+		assert.NotNil(t, page)
+		assert.Equal(t, 2, page.Count)
+		assert.False(t, page.HasMore)
+		assert.Empty(t, page.NextCursor)
 		accountRepositoryMock.Mock.AssertExpectations(t)
 	})
 }
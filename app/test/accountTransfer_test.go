@@ -0,0 +1,374 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mockService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// integration test bulk import and export account with sqlite database
+func TestAccountTransferSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.AccountTransfer = &config.AccountTransfer{ImportBatchSize: 2, ImportMaxRows: 100, HashWorkers: 3, ExportBatchSize: 2}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", "broken123").Return("", errors.New("hash failed"))
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	transferService := service.NewAccountTransferService(transaction.NewTxManager(db), helper.NewValidator(), cfg, accountRepository, helperPasswordMock, logging.Discard())
+	ctx := context.Background()
+
+	_, err := accountRepository.Add(ctx, &entity.Account{Email: "taken@gmail.com", Username: "taken", Password: "hashed"})
+	assert.Nil(t, err)
+
+	importBody := func(request dto.ImportAccountRequest) *dto.ImportAccountResponse {
+		response, err := transferService.Import(ctx, &request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	t.Run("import csv", func(t *testing.T) {
+		body := strings.Join([]string{
+			"Username,Email,Password,Note",
+			"reo,reo@gmail.com,rahasia123,first",
+			"budi,budi@gmail.com,rahasia123,",
+			"andi,not-an-email,rahasia123,",
+			"reo2,REO@gmail.com,rahasia123,",
+			"other,taken@gmail.com,rahasia123,",
+			"taken,other@gmail.com,rahasia123,",
+			"short,short@gmail.com",
+			"broken,broken@gmail.com,broken123,",
+			"=SUM(A1),formula@gmail.com,rahasia123,",
+		}, "\n")
+
+		response := importBody(dto.ImportAccountRequest{Format: dto.FormatCSV, Body: strings.NewReader(body)})
+		assert.False(t, response.DryRun)
+		assert.False(t, response.Truncated)
+		assert.Equal(t, 9, response.Total)
+		assert.Equal(t, 3, response.Created)
+		assert.Equal(t, 6, response.Failed)
+
+		// report in order of file, line of header is 1
+		lines := map[int]dto.ImportAccountRowResult{}
+		for _, row := range response.Rows {
+			lines[row.Line] = row
+		}
+		assert.Len(t, lines, 9)
+
+		assert.Equal(t, dto.ImportStatusCreated, lines[2].Status)
+		assert.NotZero(t, lines[2].Id)
+		assert.Equal(t, dto.ImportStatusCreated, lines[3].Status)
+		assert.NotEqual(t, lines[2].Id, lines[3].Id)
+		assert.Equal(t, dto.ImportStatusCreated, lines[10].Status)
+
+		assert.Equal(t, customError.CodeValidation, lines[4].Code)
+		assert.Equal(t, "email", lines[4].Errors[0].Field)
+		assert.Equal(t, customError.CodeImportRowDuplicate, lines[5].Code)
+		assert.Equal(t, customError.CodeAccountEmailTaken, lines[6].Code)
+		assert.Equal(t, customError.CodeAccountUsernameTaken, lines[7].Code)
+		assert.Equal(t, customError.CodeImportRowInvalid, lines[8].Code)
+		assert.Equal(t, customError.CodeInternal, lines[9].Code)
+		assert.Equal(t, dto.ImportStatusFailed, lines[9].Status)
+
+		account, err := accountRepository.GetByEmail(ctx, "budi@gmail.com")
+		assert.Nil(t, err)
+		assert.Equal(t, lines[3].Id, account.Id)
+		assert.Equal(t, "hashed", account.Password)
+		assert.False(t, account.IsVerified())
+
+		_, err = accountRepository.GetByEmail(ctx, "broken@gmail.com")
+		assert.Equal(t, customError.CodeAccountNotFound, customError.FromError(err).Code)
+	})
+	t.Run("dry run ndjson", func(t *testing.T) {
+		body := strings.Join([]string{
+			`{"email": "dry@gmail.com", "username": "dry", "password": "rahasia123"}`,
+			``,
+			`{"email": "reo@gmail.com", "username": "reo3", "password": "rahasia123"}`,
+			`{"email": "broken json"`,
+			`{"email": "dry2@gmail.com", "username": "d", "password": "rahasia123"}`,
+		}, "\n")
+
+		localeCtx := i18n.WithLocale(ctx, i18n.ID)
+		response, err := transferService.Import(localeCtx, &dto.ImportAccountRequest{Format: dto.FormatNDJSON, DryRun: true, Body: strings.NewReader(body)})
+		assert.Nil(t, err)
+		assert.True(t, response.DryRun)
+		assert.Equal(t, 4, response.Total)
+		assert.Equal(t, 1, response.Valid)
+		assert.Equal(t, 0, response.Created)
+		assert.Equal(t, 3, response.Failed)
+
+		assert.Equal(t, dto.ImportAccountRowResult{Line: 1, Email: "dry@gmail.com", Username: "dry", Status: dto.ImportStatusValid}, response.Rows[0])
+		assert.Equal(t, 3, response.Rows[1].Line)
+		assert.Equal(t, customError.CodeAccountEmailTaken, response.Rows[1].Code)
+		assert.Equal(t, "email sudah terdaftar", response.Rows[1].Message)
+		assert.Equal(t, 4, response.Rows[2].Line)
+		assert.Equal(t, customError.CodeImportRowInvalid, response.Rows[2].Code)
+		assert.Equal(t, "username", response.Rows[3].Errors[0].Field)
+
+		// nothing inserted in dry run
+		_, err = accountRepository.GetByEmail(ctx, "dry@gmail.com")
+		assert.Equal(t, customError.CodeAccountNotFound, customError.FromError(err).Code)
+	})
+	t.Run("rows after max not read", func(t *testing.T) {
+		limited := *cfg
+		limited.AccountTransfer = &config.AccountTransfer{ImportMaxRows: 1}
+		limitedService := service.NewAccountTransferService(transaction.NewTxManager(db), helper.NewValidator(), &limited, accountRepository, helperPasswordMock, logging.Discard())
+
+		body := "email,username,password\nmax1@gmail.com,max1,rahasia123\nmax2@gmail.com,max2,rahasia123\n"
+		response, err := limitedService.Import(ctx, &dto.ImportAccountRequest{Format: dto.FormatCSV, DryRun: true, Body: strings.NewReader(body)})
+		assert.Nil(t, err)
+		assert.True(t, response.Truncated)
+		assert.Equal(t, 1, response.Total)
+	})
+	t.Run("body after max byte not read", func(t *testing.T) {
+		header := "email,username,password\nbyte1@gmail.com,byte1,rahasia123\n"
+		limited := *cfg
+		limited.AccountTransfer = &config.AccountTransfer{ImportMaxBytes: int64(len(header))}
+		limitedService := service.NewAccountTransferService(transaction.NewTxManager(db), helper.NewValidator(), &limited, accountRepository, helperPasswordMock, logging.Discard())
+
+		response, err := limitedService.Import(ctx, &dto.ImportAccountRequest{Format: dto.FormatCSV, DryRun: true, Body: strings.NewReader(header + "byte2@gmail.com,byte2,rahasia123\n")})
+		assert.Nil(t, err)
+		assert.True(t, response.Truncated)
+		assert.Equal(t, 1, response.Total)
+		assert.Equal(t, dto.ImportStatusValid, response.Rows[0].Status)
+	})
+	t.Run("csv without required column", func(t *testing.T) {
+		_, err := transferService.Import(ctx, &dto.ImportAccountRequest{Format: dto.FormatCSV, Body: strings.NewReader("email,password\nx@gmail.com,rahasia123\n")})
+		assert.Equal(t, customError.CodeRequestBodyInvalid, customError.FromError(err).Code)
+
+		_, err = transferService.Import(ctx, &dto.ImportAccountRequest{Format: dto.FormatCSV, Body: strings.NewReader("")})
+		assert.Equal(t, customError.CodeRequestBodyInvalid, customError.FromError(err).Code)
+	})
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := transferService.Import(ctx, &dto.ImportAccountRequest{Format: "xml", Body: strings.NewReader("<a/>")})
+		assert.Equal(t, customError.CodeImportFormatUnsupported, customError.FromError(err).Code)
+		assert.Equal(t, http.StatusUnsupportedMediaType, customError.FromError(err).HttpStatus)
+	})
+	t.Run("export csv", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.Nil(t, transferService.Export(ctx, dto.FormatCSV, &buffer))
+		assert.NotContains(t, buffer.String(), "hashed")
+
+		records, err := csv.NewReader(&buffer).ReadAll()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"id", "email", "username", "verified", "created_at", "updated_at"}, records[0])
+
+		// taken, reo, budi and formula read in batch of 2
+		assert.Len(t, records, 5)
+		assert.Equal(t, "taken@gmail.com", records[1][1])
+		assert.Equal(t, "false", records[1][3])
+		assert.Equal(t, "'=SUM(A1)", records[4][2])
+	})
+	t.Run("export ndjson", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.Nil(t, transferService.Export(ctx, dto.FormatNDJSON, &buffer))
+		assert.NotContains(t, buffer.String(), "password")
+
+		var rows []dto.ExportAccountRow
+		scanner := bufio.NewScanner(&buffer)
+		for scanner.Scan() {
+			var row dto.ExportAccountRow
+			assert.Nil(t, json.Unmarshal(scanner.Bytes(), &row))
+			rows = append(rows, row)
+		}
+
+		assert.Len(t, rows, 4)
+		for i := 1; i < len(rows); i++ {
+			assert.Less(t, rows[i-1].Id, rows[i].Id)
+		}
+		assert.Equal(t, "=SUM(A1)", rows[3].Username)
+	})
+	t.Run("export unknown format", func(t *testing.T) {
+		err := transferService.Export(ctx, "xml", io.Discard)
+		assert.Equal(t, customError.CodeRequestQueryInvalid, customError.FromError(err).Code)
+	})
+}
+
+// unit test handler import and export
+func TestAccountTransferHandler(t *testing.T) {
+	newApp := func(transferService *mockService.AccountTransferServiceMock) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		transferHandler := handler.NewAccountTransferHandler(transferService)
+		app.Post("/accounts/import", transferHandler.Import)
+		app.Get("/accounts/export", transferHandler.Export)
+		return app
+	}
+
+	t.Run("format from content type", func(t *testing.T) {
+		transferService := mockService.NewAccountTransferServiceMock()
+		transferService.Mock.On("Import", mock.Anything, mock.MatchedBy(func(request *dto.ImportAccountRequest) bool {
+			body, _ := io.ReadAll(request.Body)
+			return request.Format == dto.FormatNDJSON && request.DryRun && string(body) == "{}"
+		})).Return(&dto.ImportAccountResponse{DryRun: true, Rows: []dto.ImportAccountRowResult{}}, nil)
+
+		request := httptest.NewRequest(http.MethodPost, "/accounts/import?dry_run=true", strings.NewReader("{}"))
+		request.Header.Set(fiber.HeaderContentType, "application/x-ndjson; charset=utf-8")
+
+		response, err := newApp(transferService).Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		transferService.Mock.AssertExpectations(t)
+	})
+	t.Run("query format override content type", func(t *testing.T) {
+		transferService := mockService.NewAccountTransferServiceMock()
+		transferService.Mock.On("Import", mock.Anything, mock.MatchedBy(func(request *dto.ImportAccountRequest) bool {
+			return request.Format == dto.FormatCSV && !request.DryRun
+		})).Return(&dto.ImportAccountResponse{Rows: []dto.ImportAccountRowResult{}}, nil)
+
+		request := httptest.NewRequest(http.MethodPost, "/accounts/import?format=csv", strings.NewReader("email,username,password\n"))
+		request.Header.Set(fiber.HeaderContentType, "text/plain")
+
+		response, err := newApp(transferService).Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		transferService.Mock.AssertExpectations(t)
+	})
+	t.Run("unsupported format", func(t *testing.T) {
+		transferService := mockService.NewAccountTransferServiceMock()
+		transferService.Mock.On("Import", mock.Anything, mock.MatchedBy(func(request *dto.ImportAccountRequest) bool {
+			return request.Format == ""
+		})).Return(nil, customError.New(customError.CodeImportFormatUnsupported))
+
+		request := httptest.NewRequest(http.MethodPost, "/accounts/import", strings.NewReader("<a/>"))
+		request.Header.Set(fiber.HeaderContentType, "application/xml")
+
+		response, err := newApp(transferService).Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnsupportedMediaType, response.StatusCode)
+	})
+	t.Run("export streamed", func(t *testing.T) {
+		transferService := mockService.NewAccountTransferServiceMock()
+		transferService.Mock.On("Export", mock.Anything, dto.FormatNDJSON, mock.Anything).
+			Run(func(args mock.Arguments) {
+				io.WriteString(args.Get(2).(io.Writer), "{\"id\":1}\n")
+			}).Return(nil)
+
+		response, err := newApp(transferService).Test(httptest.NewRequest(http.MethodGet, "/accounts/export?format=ndjson", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "application/x-ndjson", response.Header.Get(fiber.HeaderContentType))
+		assert.Equal(t, `attachment; filename="accounts.ndjson"`, response.Header.Get(fiber.HeaderContentDisposition))
+
+		body, _ := io.ReadAll(response.Body)
+		assert.Equal(t, "{\"id\":1}\n", string(body))
+		transferService.Mock.AssertExpectations(t)
+	})
+	t.Run("export run with own span and request value", func(t *testing.T) {
+		tracer := mocktracer.New()
+		previous := opentracing.GlobalTracer()
+		opentracing.SetGlobalTracer(tracer)
+		defer opentracing.SetGlobalTracer(previous)
+
+		// span of handler already finished when writer run
+		transferService := mockService.NewAccountTransferServiceMock()
+		transferService.Mock.On("Export", mock.MatchedBy(func(ctx context.Context) bool {
+			span, ok := opentracing.SpanFromContext(ctx).(*mocktracer.MockSpan)
+			return ok && span.OperationName == "AccountTransferHandler Export stream" && i18n.FromContext(ctx) == "id" && ctx.Value(logging.RequestIDKey) == "req-1"
+		}), dto.FormatCSV, mock.Anything).
+			Run(func(args mock.Arguments) {
+				io.WriteString(args.Get(2).(io.Writer), "id\n1\n")
+			}).Return(errors.New("database down"))
+
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Use(func(ctx *fiber.Ctx) error {
+			ctx.Locals(i18n.LocaleKey, "id")
+			ctx.Locals(logging.RequestIDKey, "req-1")
+			return ctx.Next()
+		})
+		app.Get("/accounts/export", handler.NewAccountTransferHandler(transferService).Export)
+
+		// failure after status sent end the body early
+		response, err := app.Test(httptest.NewRequest(http.MethodGet, "/accounts/export", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		body, _ := io.ReadAll(response.Body)
+		assert.Equal(t, "id\n1\n", string(body))
+		transferService.Mock.AssertExpectations(t)
+
+		spans := tracer.FinishedSpans()
+		assert.Len(t, spans, 2)
+		assert.Equal(t, true, spans[1].Tag("error"))
+	})
+	t.Run("export unknown format", func(t *testing.T) {
+		response, err := newApp(mockService.NewAccountTransferServiceMock()).Test(httptest.NewRequest(http.MethodGet, "/accounts/export?format=xml", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}
+
+// unit test body limit of route that not stream while import read streamed body
+func TestBodyLimitMiddleware(t *testing.T) {
+	transferService := mockService.NewAccountTransferServiceMock()
+	transferService.Mock.On("Import", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			body, _ := io.ReadAll(args.Get(1).(*dto.ImportAccountRequest).Body)
+			assert.Len(t, body, 100)
+		}).Return(&dto.ImportAccountResponse{Rows: []dto.ImportAccountRowResult{}}, nil)
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler, BodyLimit: 16, StreamRequestBody: true})
+	app.Use(middleware.BodyLimitMiddleware(func(ctx *fiber.Ctx) bool {
+		return ctx.Path() == "/accounts/import"
+	}))
+	app.Post("/accounts/import", handler.NewAccountTransferHandler(transferService).Import)
+	app.Post("/echo", func(ctx *fiber.Ctx) error {
+		return ctx.Send(ctx.Body())
+	})
+
+	post := func(path string, body string) (int, string) {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Set(fiber.HeaderContentType, "text/csv")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+
+		responseBody, _ := io.ReadAll(response.Body)
+		return response.StatusCode, string(responseBody)
+	}
+
+	t.Run("body within limit", func(t *testing.T) {
+		status, body := post("/echo", "0123456789")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "0123456789", body)
+	})
+	t.Run("body over limit rejected", func(t *testing.T) {
+		status, body := post("/echo", strings.Repeat("a", 100))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+		assert.Contains(t, body, "request body too large")
+	})
+	t.Run("import read body over limit", func(t *testing.T) {
+		status, _ := post("/accounts/import", strings.Repeat("a", 100))
+		assert.Equal(t, http.StatusOK, status)
+		transferService.Mock.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,268 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	jwtModel "cobaMetrics/app/model/jwt"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mockService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unit test generate and parse api key
+func TestApiKeyFormat(t *testing.T) {
+	key, prefix, secretHash, err := helper.NewApiKey()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(key, "cm_"+prefix+"_"))
+
+	parsedPrefix, secret, ok := helper.ParseApiKey(key)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, parsedPrefix)
+	assert.Equal(t, secretHash, helper.HashToken(secret))
+
+	for _, invalid := range []string{"", "cm_abc", "xx_" + prefix + "_secret", "cm_short_secret", "cm_" + prefix + "_"} {
+		_, _, ok := helper.ParseApiKey(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+// integration test create, authenticate and revoke api key with sqlite database
+func TestApiKeySQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.ApiKey = &config.ApiKey{TouchInterval: time.Minute}
+	cfg.Admin = &config.Admin{Emails: []string{"reoshby@gmail.com"}}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "hashed").Return(true)
+	helperPasswordMock.Mock.On("NeedsRehash", "hashed").Return(false)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	sessionRepository := repository.NewSessionRepository(cluster, sqliteDialect, logging.Discard())
+	apiKeyRepository := repository.NewApiKeyRepository(cluster, sqliteDialect, logging.Discard())
+	validate := helper.NewValidator()
+	accountService := service.NewAccountService(transaction.NewTxManager(db), validate, cfg, accountRepository, helperPasswordMock, newVerificationMock(), service.NewSessionService(cfg, sessionRepository, logging.Discard()), logging.Discard())
+	apiKeyService := service.NewApiKeyService(validate, apiKeyRepository, logging.Discard())
+	ctx := context.Background()
+
+	account, err := accountService.Add(ctx, &dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"})
+	assert.Nil(t, err)
+	// admin access require verified email
+	assert.Nil(t, accountRepository.MarkVerified(ctx, account.Id, time.Now()))
+	login, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+	assert.Nil(t, err)
+
+	// principal of request written as response, so test can compare token and api key
+	var principal *jwtModel.Claims
+	authMiddleware := middleware.AuthMiddleware(cfg, accountRepository, sessionRepository, apiKeyRepository, repository.NewOAuthClientRepository(cluster, sqliteDialect, logging.Discard()), logging.Discard())
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	capture := func(ctx *fiber.Ctx) error {
+		principal = ctx.Locals(middleware.ClaimsKey).(*jwtModel.Claims)
+		return ctx.SendStatus(http.StatusOK)
+	}
+	app.Get("/accounts", authMiddleware, middleware.ScopeMiddleware(logging.Discard())("accounts:read"), capture)
+	app.Get("/other", authMiddleware, middleware.ScopeMiddleware(logging.Discard())("other:read"), capture)
+	app.Get("/sessions", middleware.UserAuthMiddleware(cfg, accountRepository, sessionRepository, logging.Discard()), capture)
+	app.Get("/admin", authMiddleware, middleware.AdminMiddleware(cfg, logging.Discard()), capture)
+
+	// database caller of request written as body
+	callerApp := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	callerApp.Use(middleware.DatabaseCallerMiddleware())
+	sendCaller := func(ctx *fiber.Ctx) error {
+		return ctx.SendString(ctx.Locals(database.CallerKey).(string))
+	}
+	callerApp.Get("/anonymous", sendCaller)
+	callerApp.Get("/principal", authMiddleware, sendCaller)
+	caller := func(path string, header string, value string) string {
+		httpRequest := httptest.NewRequest(http.MethodGet, path, nil)
+		httpRequest.Header.Add(header, value)
+		response, err := callerApp.Test(httpRequest)
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(response.Body)
+		return string(body)
+	}
+
+	request := func(path string, header string, value string) int {
+		principal = nil
+		httpRequest := httptest.NewRequest(http.MethodGet, path, nil)
+		httpRequest.Header.Add(header, value)
+		response, err := app.Test(httpRequest)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	create := func(request dto.CreateApiKeyRequest) *dto.CreateApiKeyResponse {
+		response, err := apiKeyService.Create(ctx, &request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	accountKey := create(dto.CreateApiKeyRequest{Name: "batch", Scopes: []string{entity.ScopeAccountsRead}, AccountId: &account.Id})
+	serviceKey := create(dto.CreateApiKeyRequest{Name: "report", Scopes: []string{entity.ScopeAccountsRead}, ExpiresInDays: 30, Service: "report-job"})
+
+	t.Run("create validation", func(t *testing.T) {
+		for _, request := range []dto.CreateApiKeyRequest{
+			{Name: "no scope", AccountId: &account.Id},
+			{Name: "unknown scope", Scopes: []string{"accounts:delete"}, AccountId: &account.Id},
+			{Name: "account and service", Scopes: []string{entity.ScopeAccountsRead}, AccountId: &account.Id, Service: "job"},
+			{Name: "no owner", Scopes: []string{entity.ScopeAccountsRead}},
+		} {
+			_, err := apiKeyService.Create(ctx, &request)
+			_, ok := err.(validator.ValidationErrors)
+			assert.True(t, ok, request.Name)
+		}
+	})
+	t.Run("secret only shown on create", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(accountKey.Key, accountKey.Prefix+"_"))
+		assert.NotEmpty(t, serviceKey.ExpiresAt)
+
+		apiKeys, err := apiKeyService.GetAll(ctx, &account.Id)
+		assert.Nil(t, err)
+		assert.Len(t, apiKeys, 1)
+		assert.Equal(t, accountKey.ApiKeyResponse.Id, apiKeys[0].Id)
+
+		apiKeys, err = apiKeyService.GetAll(ctx, nil)
+		assert.Nil(t, err)
+		assert.Len(t, apiKeys, 1)
+		assert.Equal(t, "report-job", apiKeys[0].Service)
+	})
+	t.Run("token and api key of account produce same principal", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/accounts", "Authorization", "Bearer "+login.Token))
+		fromToken := *principal
+		assert.False(t, fromToken.IsApiKey())
+
+		assert.Equal(t, http.StatusOK, request("/accounts", middleware.HeaderApiKey, accountKey.Key))
+		assert.True(t, principal.IsApiKey())
+		assert.Equal(t, fromToken.Id, principal.Id)
+		assert.Equal(t, fromToken.Email, principal.Email)
+		assert.Empty(t, principal.Service)
+	})
+	t.Run("database caller is principal, ip only for anonymous", func(t *testing.T) {
+		assert.Equal(t, "ip:0.0.0.0", caller("/anonymous", "Authorization", ""))
+		assert.Equal(t, fmt.Sprintf("account:%v", account.Id), caller("/principal", "Authorization", "Bearer "+login.Token))
+		assert.Equal(t, fmt.Sprintf("account:%v", account.Id), caller("/principal", middleware.HeaderApiKey, accountKey.Key))
+		assert.Equal(t, "service:report-job", caller("/principal", middleware.HeaderApiKey, serviceKey.Key))
+	})
+	t.Run("api key of service", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/accounts", middleware.HeaderApiKey, serviceKey.Key))
+		assert.Equal(t, "report-job", principal.Service)
+		assert.Zero(t, principal.Id)
+	})
+	t.Run("last used tracked", func(t *testing.T) {
+		apiKeys, err := apiKeyService.GetAll(ctx, &account.Id)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, apiKeys[0].LastUsedAt)
+	})
+	t.Run("scope required only for api key", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("/other", middleware.HeaderApiKey, accountKey.Key))
+		assert.Equal(t, http.StatusOK, request("/other", "Authorization", "Bearer "+login.Token))
+	})
+	t.Run("api key rejected on user and admin endpoint", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("/sessions", middleware.HeaderApiKey, accountKey.Key))
+		assert.Equal(t, http.StatusForbidden, request("/admin", middleware.HeaderApiKey, accountKey.Key))
+		assert.Equal(t, http.StatusOK, request("/admin", "Authorization", "Bearer "+login.Token))
+	})
+	t.Run("invalid api key", func(t *testing.T) {
+		prefix, _, _ := helper.ParseApiKey(accountKey.Key)
+		for _, key := range []string{"bukan-api-key", "cm_" + prefix + "_salah", "cm_000000000000_salah"} {
+			assert.Equal(t, http.StatusUnauthorized, request("/accounts", middleware.HeaderApiKey, key))
+		}
+	})
+	t.Run("expired api key", func(t *testing.T) {
+		expiredKey := create(dto.CreateApiKeyRequest{Name: "expired", Scopes: []string{entity.ScopeAccountsRead}, ExpiresInDays: 1, AccountId: &account.Id})
+		_, err := db.Exec("UPDATE api_keys SET expires_at = ? WHERE id = ?", time.Now().Add(-time.Minute), expiredKey.ApiKeyResponse.Id)
+		assert.Nil(t, err)
+
+		assert.Equal(t, http.StatusUnauthorized, request("/accounts", middleware.HeaderApiKey, expiredKey.Key))
+	})
+	t.Run("revoke", func(t *testing.T) {
+		// owner must match
+		err := apiKeyService.Revoke(ctx, &account.Id, serviceKey.ApiKeyResponse.Id)
+		assert.Equal(t, customError.CodeApiKeyNotFound, customError.FromError(err).Code)
+		err = apiKeyService.Revoke(ctx, nil, accountKey.ApiKeyResponse.Id)
+		assert.Equal(t, customError.CodeApiKeyNotFound, customError.FromError(err).Code)
+
+		assert.Nil(t, apiKeyService.Revoke(ctx, &account.Id, accountKey.ApiKeyResponse.Id))
+		assert.Nil(t, apiKeyService.Revoke(ctx, nil, serviceKey.ApiKeyResponse.Id))
+
+		assert.Equal(t, http.StatusUnauthorized, request("/accounts", middleware.HeaderApiKey, accountKey.Key))
+		assert.Equal(t, http.StatusUnauthorized, request("/accounts", middleware.HeaderApiKey, serviceKey.Key))
+
+		err = apiKeyService.Revoke(ctx, &account.Id, accountKey.ApiKeyResponse.Id)
+		assert.Equal(t, customError.CodeApiKeyNotFound, customError.FromError(err).Code)
+	})
+}
+
+// unit test api key handler
+func TestApiKeyHandler(t *testing.T) {
+	newApp := func(apiKeyService *mockService.ApiKeyServiceMock) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		apiKeyHandler := handler.NewApiKeyHandler(apiKeyService)
+
+		// set claims like AuthMiddleware
+		app.Use(func(ctx *fiber.Ctx) error {
+			ctx.Locals(middleware.ClaimsKey, &jwtModel.Claims{Id: 7})
+			return ctx.Next()
+		})
+		app.Post("/api-keys", apiKeyHandler.Create)
+		app.Post("/admin/api-keys", apiKeyHandler.CreateService)
+		app.Delete("/admin/api-keys/:id", apiKeyHandler.RevokeService)
+		return app
+	}
+
+	send := func(app *fiber.App, method string, path string, body string) int {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Add("Content-Type", "application/json")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	t.Run("create api key of account of token", func(t *testing.T) {
+		accountId := 7
+		apiKeyService := mockService.NewApiKeyServiceMock()
+		apiKeyService.Mock.On("Create", mock.Anything, &dto.CreateApiKeyRequest{Name: "batch", Scopes: []string{"accounts:read"}, AccountId: &accountId}).
+			Return(&dto.CreateApiKeyResponse{Key: "cm_x_y"}, nil)
+
+		assert.Equal(t, http.StatusOK, send(newApp(apiKeyService), http.MethodPost, "/api-keys", `{"name":"batch","scopes":["accounts:read"]}`))
+		apiKeyService.Mock.AssertExpectations(t)
+	})
+	t.Run("create api key of service", func(t *testing.T) {
+		apiKeyService := mockService.NewApiKeyServiceMock()
+		apiKeyService.Mock.On("Create", mock.Anything, &dto.CreateApiKeyRequest{Name: "report", Scopes: []string{"accounts:read"}, Service: "report-job"}).
+			Return(&dto.CreateApiKeyResponse{Key: "cm_x_y"}, nil)
+
+		assert.Equal(t, http.StatusOK, send(newApp(apiKeyService), http.MethodPost, "/admin/api-keys", `{"name":"report","scopes":["accounts:read"],"service":"report-job"}`))
+		assert.Equal(t, http.StatusBadRequest, send(newApp(apiKeyService), http.MethodPost, "/admin/api-keys", `{`))
+		apiKeyService.Mock.AssertExpectations(t)
+	})
+	t.Run("revoke api key of service not found", func(t *testing.T) {
+		apiKeyService := mockService.NewApiKeyServiceMock()
+		apiKeyService.Mock.On("Revoke", mock.Anything, (*int)(nil), "lain").Return(customError.New(customError.CodeApiKeyNotFound))
+
+		assert.Equal(t, http.StatusNotFound, send(newApp(apiKeyService), http.MethodDelete, "/admin/api-keys/lain", ""))
+	})
+}
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/model/entity"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// unit test read replica routing
+func TestClusterReader(t *testing.T) {
+	primary, _, _ := sqlmock.New()
+	replicaOne, _, _ := sqlmock.New()
+	replicaTwo, _, _ := sqlmock.New()
+
+	t.Run("without replica read from primary", func(t *testing.T) {
+		cluster := database.NewCluster(primary, nil, database.PolicyRoundRobin, 1, time.Second, logging.Discard())
+		assert.Equal(t, primary, cluster.Reader(context.Background()))
+	})
+	t.Run("round robin between replica", func(t *testing.T) {
+		cluster := database.NewCluster(primary, []*sql.DB{replicaOne, replicaTwo}, database.PolicyRoundRobin, 1, time.Second, logging.Discard())
+
+		var selected []*sql.DB
+		for i := 0; i < 4; i++ {
+			selected = append(selected, cluster.Reader(context.Background()))
+		}
+
+		assert.Equal(t, []*sql.DB{replicaOne, replicaTwo, replicaOne, replicaTwo}, selected)
+	})
+	t.Run("least connections select replica with less connection in use", func(t *testing.T) {
+		busy, busyMock, _ := sqlmock.New()
+		busyMock.ExpectBegin()
+		tx, err := busy.Begin()
+		assert.Nil(t, err)
+		defer tx.Rollback()
+
+		cluster := database.NewCluster(primary, []*sql.DB{busy, replicaTwo}, database.PolicyLeastConnections, 1, time.Second, logging.Discard())
+		assert.Equal(t, replicaTwo, cluster.Reader(context.Background()))
+	})
+	t.Run("caller pinned to primary after write", func(t *testing.T) {
+		cluster := database.NewCluster(primary, []*sql.DB{replicaOne}, database.PolicyRoundRobin, 1, time.Second, logging.Discard())
+
+		ctx := context.WithValue(context.Background(), database.CallerKey, "127.0.0.1")
+		other := context.WithValue(context.Background(), database.CallerKey, "10.0.0.1")
+		cluster.MarkWrite(ctx)
+
+		assert.Equal(t, primary, cluster.Reader(ctx))
+		assert.Equal(t, replicaOne, cluster.Reader(other))
+	})
+	t.Run("read primary context read from primary", func(t *testing.T) {
+		cluster := database.NewCluster(primary, []*sql.DB{replicaOne}, database.PolicyRoundRobin, 1, time.Second, logging.Discard())
+		assert.Equal(t, primary, cluster.Reader(database.ReadPrimary(context.Background())))
+		assert.Equal(t, replicaOne, cluster.Reader(context.Background()))
+	})
+	t.Run("pin expired after window", func(t *testing.T) {
+		cluster := database.NewCluster(primary, []*sql.DB{replicaOne}, database.PolicyRoundRobin, 1, 10*time.Millisecond, logging.Discard())
+
+		ctx := context.WithValue(context.Background(), database.CallerKey, "127.0.0.1")
+		cluster.MarkWrite(ctx)
+		time.Sleep(20 * time.Millisecond)
+
+		assert.Equal(t, replicaOne, cluster.Reader(ctx))
+	})
+}
+
+// unit test health check eject unhealthy replica
+func TestClusterHealthCheck(t *testing.T) {
+	primary, _, _ := sqlmock.New()
+	replica, replicaMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	cluster := database.NewCluster(primary, []*sql.DB{replica}, database.PolicyRoundRobin, 2, time.Second, logging.Discard())
+
+	replicaMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	cluster.CheckHealth(context.Background(), time.Second)
+	assert.Equal(t, replica, cluster.Reader(context.Background()))
+
+	replicaMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	cluster.CheckHealth(context.Background(), time.Second)
+	assert.Equal(t, primary, cluster.Reader(context.Background()))
+
+	replicaMock.ExpectPing()
+	cluster.CheckHealth(context.Background(), time.Second)
+	assert.Equal(t, replica, cluster.Reader(context.Background()))
+	assert.Nil(t, replicaMock.ExpectationsWereMet())
+}
+
+// unit test replica that not ready at startup ejected without waiting for max failures
+func TestClusterCheckReady(t *testing.T) {
+	primary, _, _ := sqlmock.New()
+	down, downMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	up, upMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	cluster := database.NewCluster(primary, []*sql.DB{down, up}, database.PolicyRoundRobin, 3, time.Second, logging.Discard())
+
+	downMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	upMock.ExpectPing()
+	cluster.CheckReady(context.Background(), time.Second)
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, up, cluster.Reader(context.Background()))
+	}
+
+	// back after one success ping of health check
+	downMock.ExpectPing()
+	upMock.ExpectPing()
+	cluster.CheckHealth(context.Background(), time.Second)
+
+	readers := map[*sql.DB]bool{}
+	for i := 0; i < 4; i++ {
+		readers[cluster.Reader(context.Background())] = true
+	}
+	assert.True(t, readers[down])
+	assert.Nil(t, downMock.ExpectationsWereMet())
+	assert.Nil(t, upMock.ExpectationsWereMet())
+}
+
+// unit test account repository read from replica and write to primary
+func TestAccountRepositoryReplica(t *testing.T) {
+	primary, primaryMock, _ := sqlmock.New()
+	replica, replicaMock, _ := sqlmock.New()
+	mysqlDialect, _ := dialect.New(dialect.MySQL)
+	cluster := database.NewCluster(primary, []*sql.DB{replica}, database.PolicyRoundRobin, 1, time.Second, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, mysqlDialect, logging.Discard())
+
+	columns := []string{"id", "email", "username", "password", "created_at", "updated_at", "failed_login_count", "locked_until", "token_version", "verified_at", "totp_secret", "totp_enabled", "totp_last_step"}
+	ctx := context.WithValue(context.Background(), database.CallerKey, "127.0.0.1")
+
+	// read before write go to replica
+	replicaMock.ExpectQuery("SELECT id, email, username, password, created_at, updated_at, failed_login_count, locked_until, token_version, verified_at, totp_secret, totp_enabled, totp_last_step FROM accounts WHERE email = ?").
+		WithArgs("reoshby@gmail.com").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "reoshby@gmail.com", "rshby", "123456", time.Now(), time.Now(), 0, nil, 0, nil, "", false, 0))
+	_, err := accountRepository.GetByEmail(ctx, "reoshby@gmail.com")
+	assert.Nil(t, err)
+
+	// write go to primary
+	primaryMock.ExpectExec("INSERT INTO accounts").WillReturnResult(sqlmock.NewResult(2, 1))
+	_, err = accountRepository.Add(ctx, &entity.Account{Email: "reo@gmail.com", Username: "reo", Password: "123456"})
+	assert.Nil(t, err)
+
+	// read after write from same caller go to primary
+	primaryMock.ExpectQuery("SELECT id, email, username, password, created_at, updated_at, failed_login_count, locked_until, token_version, verified_at, totp_secret, totp_enabled, totp_last_step FROM accounts WHERE email = ?").
+		WithArgs("reo@gmail.com").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "reo@gmail.com", "reo", "123456", time.Now(), time.Now(), 0, nil, 0, nil, "", false, 0))
+	_, err = accountRepository.GetByEmail(ctx, "reo@gmail.com")
+	assert.Nil(t, err)
+
+	assert.Nil(t, primaryMock.ExpectationsWereMet())
+	assert.Nil(t, replicaMock.ExpectationsWereMet())
+}
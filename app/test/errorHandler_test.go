@@ -0,0 +1,276 @@
+package test
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	mockService "cobaMetrics/app/test/mock/service"
+	mockError "cobaMetrics/app/test/mock/validationErrors"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// unit test mapping error into AppError
+func TestFromError(t *testing.T) {
+	unknownError := errors.New("dial tcp 127.0.0.1:3306: connection refused")
+	emailTaken := customError.NewAppError(customError.CodeConflict, http.StatusConflict, "email already exist")
+
+	testCases := []struct {
+		name       string
+		err        error
+		httpStatus int
+		code       string
+		message    string
+	}{
+		{"app error", emailTaken, http.StatusConflict, customError.CodeConflict, "email already exist"},
+		{"wrapped app error", fmt.Errorf("add account : %w", emailTaken), http.StatusConflict, customError.CodeConflict, "email already exist"},
+		{"bad request error", customError.NewBadRequestError("password not match"), http.StatusBadRequest, customError.CodeBadRequest, "password not match"},
+		{"wrapped bad request error", fmt.Errorf("login : %w", customError.NewBadRequestError("password not match")), http.StatusBadRequest, customError.CodeBadRequest, "password not match"},
+		{"not found error", customError.NewNotFoundError("record not found"), http.StatusNotFound, customError.CodeNotFound, "record not found"},
+		{"internal server error", customError.NewInternalServerError("failed to insert new user"), http.StatusInternalServerError, customError.CodeInternal, "failed to insert new user"},
+		{"validation error", validator.ValidationErrors{
+			&mockError.FieldErrorMock{TagError: "email", FieldErr: "email"},
+			&mockError.FieldErrorMock{TagError: "min", FieldErr: "password", ParamErr: "6"},
+		}, http.StatusBadRequest, customError.CodeValidation, "validation failed"},
+		{"fiber not found error", fiber.ErrNotFound, http.StatusNotFound, customError.CodeNotFound, "Not Found"},
+		{"fiber method not allowed error", fiber.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method Not Allowed"},
+		{"fiber request entity too large error", fiber.ErrRequestEntityTooLarge, http.StatusRequestEntityTooLarge, "REQUEST_ENTITY_TOO_LARGE", "Request Entity Too Large"},
+		{"fiber service unavailable error", fiber.ErrServiceUnavailable, http.StatusServiceUnavailable, customError.CodeInternal, "Service Unavailable"},
+		{"unknown error hide internal message", unknownError, http.StatusInternalServerError, customError.CodeInternal, "internal server error"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			appError := customError.FromError(testCase.err)
+			assert.Equal(t, testCase.httpStatus, appError.HttpStatus)
+			assert.Equal(t, testCase.code, appError.Code)
+			assert.Equal(t, testCase.message, appError.Message)
+		})
+	}
+
+	t.Run("nil error", func(t *testing.T) {
+		assert.Nil(t, customError.FromError(nil))
+	})
+	t.Run("unknown error kept as cause", func(t *testing.T) {
+		appError := customError.FromError(unknownError)
+		assert.True(t, errors.Is(appError, unknownError))
+	})
+	t.Run("errors is match by code", func(t *testing.T) {
+		err := fmt.Errorf("add account : %w", emailTaken.Wrap(unknownError))
+		assert.True(t, errors.Is(err, emailTaken))
+		assert.True(t, errors.Is(err, unknownError))
+		assert.False(t, errors.Is(err, customError.NewAppError(customError.CodeNotFound, http.StatusNotFound, "")))
+
+		var appError *customError.AppError
+		assert.True(t, errors.As(err, &appError))
+		assert.Equal(t, http.StatusConflict, appError.HttpStatus)
+	})
+	t.Run("with details not change original", func(t *testing.T) {
+		detailed := emailTaken.WithDetails(map[string]string{"email": "reoshby@gmail.com"})
+		assert.Nil(t, emailTaken.Details)
+		assert.NotNil(t, detailed.Details)
+	})
+}
+
+// unit test fiber error handler
+func TestErrorHandler(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		httpStatus int
+		status     string
+		message    string
+	}{
+		{"unknown error return 500 instead of 0", errors.New("unexpected"), http.StatusInternalServerError, "internal server error", "internal server error"},
+		{"app error with details", customError.NewAppError(customError.CodeConflict, http.StatusConflict, "email already exist").WithDetails("reoshby@gmail.com"), http.StatusConflict, "conflict", "email already exist"},
+		{"not found error", customError.NewNotFoundError("record not found"), http.StatusNotFound, "not found", "record not found"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			accountService := mockService.NewAccountServiceMock()
+			accountService.Mock.On("GetByEmail", mock.Anything, mock.Anything).Return(nil, testCase.err)
+			accountHandler := handler.NewAccountHandler(accountService)
+
+			app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+			app.Get("/", accountHandler.GetByEmail)
+
+			response, err := app.Test(httptest.NewRequest(http.MethodGet, "/?email=reoshby@gmail.com", nil))
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.httpStatus, response.StatusCode)
+
+			responseBody := map[string]any{}
+			body, _ := io.ReadAll(response.Body)
+			json.Unmarshal(body, &responseBody)
+
+			assert.Equal(t, testCase.httpStatus, int(responseBody["status_code"].(float64)))
+			assert.Equal(t, testCase.status, responseBody["status"])
+			assert.Equal(t, testCase.message, responseBody["message"])
+		})
+	}
+
+	t.Run("route not found", func(t *testing.T) {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+
+		response, err := app.Test(httptest.NewRequest(http.MethodGet, "/not-found", strings.NewReader("")))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+}
+
+// unit test problem+json negotiation in error handler
+func TestErrorHandlerProblemJson(t *testing.T) {
+	testCases := []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{"no accept header get api response", "", fiber.MIMEApplicationJSON},
+		{"accept json get api response", "application/json", fiber.MIMEApplicationJSON},
+		{"accept any get api response", "*/*", fiber.MIMEApplicationJSON},
+		{"accept problem json", "application/problem+json", handler.MIMEApplicationProblemJSON},
+		{"prefer problem json", "application/json;q=0.5, application/problem+json", handler.MIMEApplicationProblemJSON},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			accountService := mockService.NewAccountServiceMock()
+			accountService.Mock.On("GetByEmail", mock.Anything, mock.Anything).Return(nil, customError.New(customError.CodeAccountNotFound))
+			accountHandler := handler.NewAccountHandler(accountService)
+
+			app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+			app.Get("/accounts", accountHandler.GetByEmail)
+
+			request := httptest.NewRequest(http.MethodGet, "/accounts?email=reoshby@gmail.com", nil)
+			if testCase.accept != "" {
+				request.Header.Set(fiber.HeaderAccept, testCase.accept)
+			}
+
+			response, err := app.Test(request)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusNotFound, response.StatusCode)
+			assert.Equal(t, testCase.contentType, response.Header.Get(fiber.HeaderContentType))
+
+			responseBody := map[string]any{}
+			body, _ := io.ReadAll(response.Body)
+			json.Unmarshal(body, &responseBody)
+
+			if testCase.contentType == fiber.MIMEApplicationJSON {
+				assert.Equal(t, "not found", responseBody["status"])
+				assert.Equal(t, "record not found", responseBody["message"])
+				return
+			}
+
+			assert.Equal(t, "/problems/account-not-found", responseBody["type"])
+			assert.Equal(t, "Account not found", responseBody["title"])
+			assert.Equal(t, float64(http.StatusNotFound), responseBody["status"])
+			assert.Equal(t, "record not found", responseBody["detail"])
+			assert.Equal(t, "/accounts?email=reoshby@gmail.com", responseBody["instance"])
+			assert.Equal(t, customError.CodeAccountNotFound, responseBody["code"])
+		})
+	}
+}
+
+// every code in catalog must be complete, and New must keep code and status
+func TestErrorCatalog(t *testing.T) {
+	for _, code := range customError.Codes() {
+		t.Run(code, func(t *testing.T) {
+			definition, ok := customError.Lookup(code)
+			assert.True(t, ok)
+			assert.Equal(t, code, definition.Code)
+			assert.NotEmpty(t, definition.Title)
+			for _, locale := range i18n.Locales() {
+				assert.True(t, i18n.Has(locale, code), "message of %v not in catalog %v", code, locale)
+			}
+			assert.NotEmpty(t, http.StatusText(definition.HttpStatus))
+
+			appError := customError.FromError(customError.New(code))
+			assert.Equal(t, code, appError.Code)
+			assert.Equal(t, definition.HttpStatus, appError.HttpStatus)
+			assert.Equal(t, i18n.Translate(i18n.DefaultLocale, code), appError.Message)
+		})
+	}
+
+	t.Run("unknown code", func(t *testing.T) {
+		_, ok := customError.Lookup("NOT_EXIST")
+		assert.False(t, ok)
+		assert.Equal(t, "Method Not Allowed", customError.Title("NOT_EXIST", http.StatusMethodNotAllowed))
+	})
+}
+
+// unit test validation error converted into per field errors with json name
+func TestValidationFieldErrors(t *testing.T) {
+	t.Run("field errors from mock", func(t *testing.T) {
+		err := validator.ValidationErrors{
+			&mockError.FieldErrorMock{TagError: "email", FieldErr: "email"},
+			&mockError.FieldErrorMock{TagError: "min", FieldErr: "password", ParamErr: "6"},
+		}
+
+		appError := customError.FromError(err)
+		assert.Equal(t, &customError.ValidationDetails{Errors: []customError.FieldError{
+			{Field: "email", Rule: "email", Param: "", Message: "error on field [email] with tag [email]"},
+			{Field: "password", Rule: "min", Param: "6", Message: "error on field [password] with tag [min]"},
+		}}, appError.Details)
+	})
+	t.Run("field use json name", func(t *testing.T) {
+		err := helper.NewValidator().Struct(dto.UpdateAccountRequest{
+			Id:              1,
+			Email:           "reoshby@gmail.com",
+			Username:        "rshby",
+			Password:        "123456",
+			ConfirmPassword: "654321",
+		})
+
+		fieldErrors := customError.FieldErrors(err.(validator.ValidationErrors), i18n.EN)
+		assert.Equal(t, []customError.FieldError{
+			{Field: "confirm_password", Rule: "eqfield", Param: "Password", Message: "confirm_password must be equal to Password"},
+		}, fieldErrors)
+	})
+	t.Run("same response shape for every endpoint", func(t *testing.T) {
+		validate := helper.NewValidator()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Post("/register", func(ctx *fiber.Ctx) error {
+			return validate.Struct(dto.AddUserRequest{Email: "reo", Username: "rshby", Password: "123"})
+		})
+		app.Post("/login", func(ctx *fiber.Ctx) error {
+			return validate.Struct(dto.LoginRequest{Email: "reo", Password: "123"})
+		})
+
+		// register check password policy, login only check length
+		passwordErrors := map[string]customError.FieldError{
+			"/register": {Field: "password", Rule: "password", Param: "", Message: "password must be at least 6 characters long"},
+			"/login":    {Field: "password", Rule: "min", Param: "6", Message: "password must be at least 6 characters in length"},
+		}
+
+		for path, passwordError := range passwordErrors {
+			response, err := app.Test(httptest.NewRequest(http.MethodPost, path, nil))
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+			var responseBody struct {
+				Message string                        `json:"message"`
+				Data    customError.ValidationDetails `json:"data"`
+			}
+			body, _ := io.ReadAll(response.Body)
+			assert.Nil(t, json.Unmarshal(body, &responseBody))
+
+			assert.Equal(t, "validation failed", responseBody.Message)
+			assert.Equal(t, []customError.FieldError{
+				{Field: "email", Rule: "email", Param: "", Message: "email must be a valid email address"},
+				passwordError,
+			}, responseBody.Data.Errors)
+		}
+	})
+}
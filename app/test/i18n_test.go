@@ -0,0 +1,186 @@
+package test
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	mockService "cobaMetrics/app/test/mock/service"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// every key must exist in catalog of every locale
+func TestI18nCatalogComplete(t *testing.T) {
+	for _, locale := range i18n.Locales() {
+		assert.NotEmpty(t, i18n.Keys(locale))
+
+		for _, other := range i18n.Locales() {
+			for _, key := range i18n.Keys(other) {
+				assert.True(t, i18n.Has(locale, key), "key %v exist in %v but not in %v", key, other, locale)
+			}
+		}
+	}
+
+	t.Run("every error code has message", func(t *testing.T) {
+		for _, code := range customError.Codes() {
+			for _, locale := range i18n.Locales() {
+				assert.True(t, i18n.Has(locale, code), "code %v not in catalog %v", code, locale)
+			}
+		}
+	})
+}
+
+// unit test translate with fallback chain
+func TestI18nTranslate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		locale  string
+		key     string
+		message string
+	}{
+		{"english", "en", customError.CodeAccountNotFound, "record not found"},
+		{"indonesian", "id", customError.CodeAccountNotFound, "data tidak ditemukan"},
+		{"region fallback to language", "id-ID", customError.CodeAccountNotFound, "data tidak ditemukan"},
+		{"underscore and upper case", "ID_id", i18n.MessageAccountLogin, "berhasil login"},
+		{"unsupported fallback to default", "fr", customError.CodeAccountNotFound, "record not found"},
+		{"empty fallback to default", "", i18n.MessageAccountAdded, "success add new account"},
+		{"unknown key return key", "id", "unknown.key", "unknown.key"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.message, i18n.Translate(testCase.locale, testCase.key))
+		})
+	}
+
+	assert.Equal(t, []string{"id-id", "id", "en"}, i18n.Fallbacks("id-ID"))
+	assert.Equal(t, []string{"en"}, i18n.Fallbacks("en"))
+	assert.Equal(t, i18n.DefaultLocale, i18n.FromContext(context.Background()))
+	assert.Equal(t, i18n.ID, i18n.FromContext(i18n.WithLocale(context.Background(), i18n.ID)))
+}
+
+// unit test select locale from Accept-Language
+func TestI18nMatch(t *testing.T) {
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		locale         string
+	}{
+		{"empty", "", i18n.EN},
+		{"indonesian with region", "id-ID", i18n.ID},
+		{"ordered by quality", "en;q=0.5, id;q=0.9", i18n.ID},
+		{"skip unsupported", "fr-FR, fr;q=0.9, id;q=0.8, en;q=0.7", i18n.ID},
+		{"quality zero ignored", "id;q=0, en", i18n.EN},
+		{"wildcard", "*", i18n.EN},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.locale, i18n.Match(i18n.ParseAcceptLanguage(testCase.acceptLanguage)...))
+		})
+	}
+}
+
+// unit test localized response from handler and error handler
+func TestLocalizedResponse(t *testing.T) {
+	testCases := []struct {
+		name           string
+		url            string
+		acceptLanguage string
+		cookie         string
+		message        string
+	}{
+		{"default english", "/accounts?email=reoshby@gmail.com", "", "", "record not found"},
+		{"accept language indonesian", "/accounts?email=reoshby@gmail.com", "id-ID,id;q=0.9", "", "data tidak ditemukan"},
+		{"query preference win over accept language", "/accounts?email=reoshby@gmail.com&lang=en", "id", "", "record not found"},
+		{"cookie preference win over accept language", "/accounts?email=reoshby@gmail.com", "en", "id", "data tidak ditemukan"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			accountService := mockService.NewAccountServiceMock()
+			accountService.Mock.On("GetByEmail", mock.Anything, mock.Anything).Return(nil, customError.New(customError.CodeAccountNotFound))
+			accountHandler := handler.NewAccountHandler(accountService)
+
+			app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+			app.Use(middleware.LocaleMiddleware())
+			app.Get("/accounts", accountHandler.GetByEmail)
+
+			request := httptest.NewRequest(http.MethodGet, testCase.url, nil)
+			request.Header.Set(fiber.HeaderAcceptLanguage, testCase.acceptLanguage)
+			if testCase.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: helper.LocaleParam, Value: testCase.cookie})
+			}
+
+			response, err := app.Test(request)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+			responseBody := map[string]any{}
+			body, _ := io.ReadAll(response.Body)
+			json.Unmarshal(body, &responseBody)
+			assert.Equal(t, testCase.message, responseBody["message"])
+		})
+	}
+
+	t.Run("validation message in indonesian", func(t *testing.T) {
+		validate := helper.NewValidator()
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Post("/login", func(ctx *fiber.Ctx) error {
+			return validate.Struct(dto.LoginRequest{Email: "reo", Password: "123456"})
+		})
+
+		request := httptest.NewRequest(http.MethodPost, "/login", nil)
+		request.Header.Set(fiber.HeaderAcceptLanguage, "id")
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		var responseBody struct {
+			Message string                        `json:"message"`
+			Data    customError.ValidationDetails `json:"data"`
+		}
+		body, _ := io.ReadAll(response.Body)
+		assert.Nil(t, json.Unmarshal(body, &responseBody))
+
+		assert.Equal(t, "validasi gagal", responseBody.Message)
+		assert.Equal(t, []customError.FieldError{
+			{Field: "email", Rule: "email", Param: "", Message: "email harus berupa alamat email yang valid"},
+		}, responseBody.Data.Errors)
+	})
+	t.Run("success message in indonesian", func(t *testing.T) {
+		accountService := mockService.NewAccountServiceMock()
+		accountService.Mock.On("Login", mock.Anything, mock.Anything).Return(&dto.LoginResponse{Token: "qwertyuiop"}, nil)
+		accountHandler := handler.NewAccountHandler(accountService)
+
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Use(middleware.LocaleMiddleware())
+		app.Post("/login", accountHandler.Login)
+
+		reqJson, _ := json.Marshal(&dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		request := httptest.NewRequest(http.MethodPost, "/login?lang=id", strings.NewReader(string(reqJson)))
+		request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, i18n.ID, response.Header.Get(fiber.HeaderContentLanguage))
+
+		responseBody := map[string]any{}
+		body, _ := io.ReadAll(response.Body)
+		json.Unmarshal(body, &responseBody)
+		assert.Equal(t, "berhasil login", responseBody["message"])
+	})
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"bytes"
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// unit test logger created from config
+func TestNewLogger(t *testing.T) {
+	t.Run("json handler with request scoped attribute", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		logger := logging.NewLogger(&config.Log{Level: "info", Format: "json"}, buffer)
+
+		tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+		defer closer.Close()
+
+		span := tracer.StartSpan("test")
+		defer span.Finish()
+
+		ctx := opentracing.ContextWithSpan(context.Background(), span)
+		ctx = context.WithValue(ctx, logging.RequestIDKey, "request-1")
+		ctx = context.WithValue(ctx, logging.RouteKey, "/api/v1/accounts")
+		ctx = context.WithValue(ctx, logging.AccountIDKey, 7)
+
+		logger.InfoContext(ctx, "account created")
+
+		record := map[string]any{}
+		assert.Nil(t, json.Unmarshal(buffer.Bytes(), &record))
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "account created", record["msg"])
+		assert.Equal(t, "request-1", record["request_id"])
+		assert.Equal(t, "/api/v1/accounts", record["route"])
+		assert.Equal(t, float64(7), record["account_id"])
+
+		spanContext := span.Context().(jaeger.SpanContext)
+		assert.Equal(t, spanContext.TraceID().String(), record["trace_id"])
+		assert.Equal(t, spanContext.SpanID().String(), record["span_id"])
+	})
+	t.Run("text handler and level", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		logger := logging.NewLogger(&config.Log{Level: "warn", Format: "text"}, buffer)
+
+		logger.Info("not written")
+		logger.With("replica", "replica-0").Warn("replica ejected")
+
+		assert.NotContains(t, buffer.String(), "not written")
+		assert.Contains(t, buffer.String(), "level=WARN")
+		assert.Contains(t, buffer.String(), `msg="replica ejected" replica=replica-0`)
+	})
+	t.Run("invalid level fallback to info", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		logger := logging.NewLogger(&config.Log{Level: "verbose", Format: "json"}, buffer)
+
+		logger.Debug("not written")
+		logger.Info("written")
+
+		assert.Equal(t, 1, strings.Count(buffer.String(), "\n"))
+	})
+}
+
+// route resolved from fiber after routing
+func TestLogContextMiddleware(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logging.NewLogger(&config.Log{Level: "info", Format: "json"}, buffer)
+
+	app := fiber.New()
+	app.Use(middleware.LogContextMiddleware())
+	app.Get("/accounts/:email", func(ctx *fiber.Ctx) error {
+		logger.InfoContext(ctx.Context(), "handled")
+		return ctx.SendStatus(http.StatusOK)
+	})
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/accounts/reoshby@gmail.com", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	record := map[string]any{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &record))
+	assert.Equal(t, "/accounts/:email", record["route"])
+}
@@ -0,0 +1,261 @@
+package test
+
+import (
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/migration"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// unit test load migration file
+func TestLoadMigration(t *testing.T) {
+	t.Run("load migration sorted by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0002_add_index.up.sql":               {Data: []byte("CREATE INDEX idx ON accounts(email);")},
+			"0002_add_index.down.sql":             {Data: []byte("DROP INDEX idx ON accounts;")},
+			"0001_create_accounts_table.up.sql":   {Data: []byte("CREATE TABLE accounts(id INT);")},
+			"0001_create_accounts_table.down.sql": {Data: []byte("DROP TABLE accounts;")},
+		}
+
+		migrations, err := migration.Load(fsys)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(migrations))
+		assert.Equal(t, int64(1), migrations[0].Version)
+		assert.Equal(t, "create_accounts_table", migrations[0].Name)
+		assert.Equal(t, migration.Checksum("CREATE TABLE accounts(id INT);"), migrations[0].Checksum)
+		assert.Equal(t, int64(2), migrations[1].Version)
+	})
+	t.Run("load migration error without down file", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0001_create_accounts_table.up.sql": {Data: []byte("CREATE TABLE accounts(id INT);")},
+		}
+
+		migrations, err := migration.Load(fsys)
+		assert.Nil(t, migrations)
+		assert.NotNil(t, err)
+	})
+	t.Run("load migration error duplicate version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"0001_b.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		}
+
+		_, err := migration.Load(fsys)
+		assert.NotNil(t, err)
+	})
+	t.Run("load embedded migration", func(t *testing.T) {
+		mysqlMigrations, err := migration.Embedded(dialect.MySQL)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, mysqlMigrations)
+
+		// every dialect must have same migration version
+		for _, dialectName := range []string{dialect.Postgres, dialect.SQLite} {
+			migrations, err := migration.Embedded(dialectName)
+			assert.Nil(t, err)
+			assert.Equal(t, len(mysqlMigrations), len(migrations))
+			for i := range migrations {
+				assert.Equal(t, mysqlMigrations[i].Version, migrations[i].Version)
+				assert.Equal(t, mysqlMigrations[i].Name, migrations[i].Name)
+			}
+		}
+	})
+}
+
+// unit test create new migration file
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "0001_init.up.sql"), []byte("SELECT 1;"), 0644)
+	os.WriteFile(filepath.Join(dir, "0001_init.down.sql"), []byte("SELECT 1;"), 0644)
+
+	files, err := migration.Create(dir, "Add Phone Number")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "0002_add_phone_number.up.sql"),
+		filepath.Join(dir, "0002_add_phone_number.down.sql"),
+	}, files)
+
+	migrations, err := migration.Load(os.DirFS(dir))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(migrations))
+
+	_, err = migration.Create(dir, "drop;table")
+	assert.NotNil(t, err)
+}
+
+// unit test migrator
+func TestMigrator(t *testing.T) {
+	mysqlDialect, _ := dialect.New(dialect.MySQL)
+	migrations := []migration.Migration{
+		{Version: 1, Name: "create_accounts_table", Up: "CREATE TABLE accounts(id INT);", Down: "DROP TABLE accounts;", Checksum: migration.Checksum("CREATE TABLE accounts(id INT);")},
+		{Version: 2, Name: "add_index", Up: "-- add index\nCREATE INDEX a ON accounts(id);\nCREATE INDEX b ON accounts(id);\n", Down: "DROP INDEX b ON accounts;", Checksum: migration.Checksum("x")},
+	}
+
+	t.Run("migrate up apply pending migration", func(t *testing.T) {
+		db, dbMock, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		dbMock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+		dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+				AddRow(1, "create_accounts_table", migrations[0].Checksum, time.Now()))
+		dbMock.ExpectExec("INSERT INTO schema_migrations").WithArgs(2, "add_index", "dirty", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec("CREATE INDEX a ON accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec("CREATE INDEX b ON accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec("UPDATE schema_migrations SET checksum").WithArgs(migrations[1].Checksum, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := migration.NewMigrator(db, mysqlDialect, migrations).Up(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(applied))
+		assert.Equal(t, int64(2), applied[0].Version)
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("migrate up failed in the middle leave version dirty", func(t *testing.T) {
+		db, dbMock, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		dbMock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+		dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+				AddRow(1, "create_accounts_table", migrations[0].Checksum, time.Now()))
+		dbMock.ExpectExec("INSERT INTO schema_migrations").WithArgs(2, "add_index", "dirty", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec("CREATE INDEX a ON accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec("CREATE INDEX b ON accounts").WillReturnError(errors.New("duplicate key name"))
+		dbMock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := migration.NewMigrator(db, mysqlDialect, migrations).Up(context.Background())
+		assert.Nil(t, applied)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "marked dirty")
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("migrate refused while version dirty", func(t *testing.T) {
+		db, dbMock, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		history := func() *sqlmock.Rows {
+			return sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+				AddRow(1, "create_accounts_table", migrations[0].Checksum, time.Now()).
+				AddRow(2, "add_index", "dirty", time.Now())
+		}
+
+		migrator := migration.NewMigrator(db, mysqlDialect, migrations)
+		for _, run := range []func() error{
+			func() error { _, err := migrator.Up(context.Background()); return err },
+			func() error { _, err := migrator.Down(context.Background(), 1); return err },
+		} {
+			dbMock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+			dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+			dbMock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").WillReturnRows(history())
+			dbMock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			err := run()
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "2_add_index is dirty")
+		}
+
+		dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").WillReturnRows(history())
+
+		statuses, err := migrator.Status(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, migration.StatusApplied, statuses[0].Status)
+		assert.Equal(t, migration.StatusDirty, statuses[1].Status)
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("migrate up error checksum mismatch", func(t *testing.T) {
+		db, dbMock, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		dbMock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+		dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+				AddRow(1, "create_accounts_table", "edited", time.Now()))
+		dbMock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := migration.NewMigrator(db, mysqlDialect, migrations).Up(context.Background())
+		assert.Nil(t, applied)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch")
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("migrate up error lock not acquired", func(t *testing.T) {
+		db, dbMock, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		dbMock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+
+		_, err = migration.NewMigrator(db, mysqlDialect, migrations).Up(context.Background())
+		assert.NotNil(t, err)
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("migrate down rollback last migration", func(t *testing.T) {
+		db, dbMock, err := sqlmock.New()
+		assert.Nil(t, err)
+
+		dbMock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+		dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+				AddRow(1, "create_accounts_table", migrations[0].Checksum, time.Now()).
+				AddRow(2, "add_index", migrations[1].Checksum, time.Now()))
+		dbMock.ExpectExec("UPDATE schema_migrations SET checksum").WithArgs("dirty", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec("DROP INDEX b ON accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec("DELETE FROM schema_migrations").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		rolledBack, err := migration.NewMigrator(db, mysqlDialect, migrations).Down(context.Background(), 1)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(rolledBack))
+		assert.Equal(t, int64(2), rolledBack[0].Version)
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+// integration test migration on sqlite run with its history in one transaction
+func TestMigratorTransactionSQLite(t *testing.T) {
+	sqliteDialect, _ := dialect.New(dialect.SQLite)
+	db, err := sql.Open(sqliteDialect.DriverName(), filepath.Join(t.TempDir(), "migration.db"))
+	assert.Nil(t, err)
+	defer db.Close()
+
+	migrations := []migration.Migration{
+		{Version: 1, Name: "create_accounts_table", Up: "CREATE TABLE accounts(id INT);", Down: "DROP TABLE accounts;", Checksum: migration.Checksum("1")},
+		{Version: 2, Name: "create_sessions_table", Up: "CREATE TABLE sessions(id INT);\nCREATE TABLE accounts(id INT);", Down: "DROP TABLE sessions;", Checksum: migration.Checksum("2")},
+	}
+
+	tableExist := func(name string) bool {
+		var count int
+		assert.Nil(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count))
+		return count == 1
+	}
+
+	// second statement of version 2 failed, table of first statement rolled back with it
+	applied, err := migration.NewMigrator(db, sqliteDialect, migrations).Up(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "2_create_sessions_table")
+	assert.Equal(t, 1, len(applied))
+	assert.True(t, tableExist("accounts"))
+	assert.False(t, tableExist("sessions"))
+
+	statuses, err := migration.NewMigrator(db, sqliteDialect, migrations).Status(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, migration.StatusApplied, statuses[0].Status)
+	assert.Equal(t, migration.StatusPending, statuses[1].Status)
+}
@@ -25,3 +25,8 @@ func (h *HelperPasswordMock) CheckPasswordHash(password, hash string) bool {
 	args := h.Mock.Called(password, hash)
 	return args.Get(0).(bool)
 }
+
+func (h *HelperPasswordMock) NeedsRehash(hash string) bool {
+	args := h.Mock.Called(hash)
+	return args.Get(0).(bool)
+}
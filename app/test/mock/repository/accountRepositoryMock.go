@@ -3,8 +3,8 @@ package mock
 import (
 	"cobaMetrics/app/model/entity"
 	"context"
-	"database/sql"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 type AccountRepositoryMock struct {
@@ -15,8 +15,8 @@ func NewAccountRepository() *AccountRepositoryMock {
 	return &AccountRepositoryMock{&mock.Mock{}}
 }
 
-func (a *AccountRepositoryMock) Add(ctx context.Context, tx *sql.Tx, input *entity.Account) (*entity.Account, error) {
-	args := a.Mock.Called(ctx, tx, input)
+func (a *AccountRepositoryMock) Add(ctx context.Context, input *entity.Account) (*entity.Account, error) {
+	args := a.Mock.Called(ctx, input)
 
 	value := args.Get(0)
 	if value == nil {
@@ -27,8 +27,8 @@ func (a *AccountRepositoryMock) Add(ctx context.Context, tx *sql.Tx, input *enti
 	return value.(*entity.Account), nil
 }
 
-func (a *AccountRepositoryMock) GetByEmail(ctx context.Context, tx *sql.Tx, email string) (*entity.Account, error) {
-	args := a.Mock.Called(ctx, tx, email)
+func (a *AccountRepositoryMock) GetByEmail(ctx context.Context, email string) (*entity.Account, error) {
+	args := a.Mock.Called(ctx, email)
 
 	value := args.Get(0)
 	if value == nil {
@@ -38,8 +38,8 @@ func (a *AccountRepositoryMock) GetByEmail(ctx context.Context, tx *sql.Tx, emai
 	return value.(*entity.Account), nil
 }
 
-func (a *AccountRepositoryMock) Update(ctx context.Context, tx *sql.Tx, input *entity.Account) (*entity.Account, error) {
-	args := a.Mock.Called(ctx, tx, input)
+func (a *AccountRepositoryMock) GetById(ctx context.Context, id int) (*entity.Account, error) {
+	args := a.Mock.Called(ctx, id)
 
 	value := args.Get(0)
 	if value == nil {
@@ -49,13 +49,65 @@ func (a *AccountRepositoryMock) Update(ctx context.Context, tx *sql.Tx, input *e
 	return value.(*entity.Account), nil
 }
 
-func (a *AccountRepositoryMock) DeleteByEmail(ctx context.Context, tx *sql.Tx, email string) error {
+func (a *AccountRepositoryMock) Update(ctx context.Context, input *entity.Account) (*entity.Account, error) {
+	args := a.Mock.Called(ctx, input)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*entity.Account), nil
+}
+
+func (a *AccountRepositoryMock) ResetPassword(ctx context.Context, id int, password string) error {
+	args := a.Mock.Called(ctx, id, password)
+
+	return args.Error(0)
+}
+
+func (a *AccountRepositoryMock) UpdatePassword(ctx context.Context, id int, password string) error {
+	args := a.Mock.Called(ctx, id, password)
+
+	return args.Error(0)
+}
+
+func (a *AccountRepositoryMock) MarkVerified(ctx context.Context, id int, verifiedAt time.Time) error {
+	args := a.Mock.Called(ctx, id, verifiedAt)
+
+	return args.Error(0)
+}
+
+func (a *AccountRepositoryMock) UpdateTotp(ctx context.Context, id int, secret string, enabled bool) error {
+	args := a.Mock.Called(ctx, id, secret, enabled)
+
+	return args.Error(0)
+}
+
+func (a *AccountRepositoryMock) UseTotpStep(ctx context.Context, id int, step int64) (bool, error) {
+	args := a.Mock.Called(ctx, id, step)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (a *AccountRepositoryMock) UpdateLoginState(ctx context.Context, id int, failedLoginCount int, lockedUntil *time.Time) error {
+	args := a.Mock.Called(ctx, id, failedLoginCount, lockedUntil)
+
+	return args.Error(0)
+}
+
+func (a *AccountRepositoryMock) IncrementFailedLogin(ctx context.Context, id int) (int, error) {
+	args := a.Mock.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (a *AccountRepositoryMock) DeleteByEmail(ctx context.Context, email string) error {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (a *AccountRepositoryMock) GetAll(ctx context.Context, tx *sql.Tx, limit int, offset int) ([]entity.Account, error) {
-	args := a.Mock.Called(ctx, tx, limit, offset)
+func (a *AccountRepositoryMock) GetAll(ctx context.Context, query *entity.AccountListQuery) ([]entity.Account, error) {
+	args := a.Mock.Called(ctx, query)
 
 	value := args.Get(0)
 	if value == nil {
@@ -64,3 +116,35 @@ func (a *AccountRepositoryMock) GetAll(ctx context.Context, tx *sql.Tx, limit in
 
 	return value.([]entity.Account), nil
 }
+
+func (a *AccountRepositoryMock) Count(ctx context.Context, query *entity.AccountListQuery) (int, error) {
+	args := a.Mock.Called(ctx, query)
+	return args.Int(0), args.Error(1)
+}
+
+func (a *AccountRepositoryMock) Search(ctx context.Context, query *entity.AccountSearchQuery) ([]entity.AccountSearchResult, error) {
+	args := a.Mock.Called(ctx, query)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.([]entity.AccountSearchResult), nil
+}
+
+func (a *AccountRepositoryMock) GetByEmailsOrUsernames(ctx context.Context, emails []string, usernames []string) ([]entity.Account, error) {
+	args := a.Mock.Called(ctx, emails, usernames)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.([]entity.Account), nil
+}
+
+func (a *AccountRepositoryMock) AddBatch(ctx context.Context, accounts []entity.Account) error {
+	args := a.Mock.Called(ctx, accounts)
+	return args.Error(0)
+}
@@ -0,0 +1,26 @@
+package mock
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type AccountSearchServiceMock struct {
+	Mock *mock.Mock
+}
+
+func NewAccountSearchServiceMock() *AccountSearchServiceMock {
+	return &AccountSearchServiceMock{&mock.Mock{}}
+}
+
+func (a *AccountSearchServiceMock) Search(ctx context.Context, request *dto.SearchAccountRequest) (*dto.AccountSearchResponse, error) {
+	args := a.Mock.Called(ctx, request)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.AccountSearchResponse), nil
+}
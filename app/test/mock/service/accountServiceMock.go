@@ -58,13 +58,19 @@ func (a *AccountServiceMock) Login(ctx context.Context, request *dto.LoginReques
 	return value.(*dto.LoginResponse), nil
 }
 
-func (a *AccountServiceMock) GetAll(ctx context.Context, limit int, page int) ([]dto.AccountDetailResponse, error) {
-	args := a.Mock.Called(ctx, limit, page)
+func (a *AccountServiceMock) GetAll(ctx context.Context, request *dto.GetAllAccountRequest) (*dto.AccountPageResponse, error) {
+	args := a.Mock.Called(ctx, request)
 
 	value := args.Get(0)
 	if value == nil {
 		return nil, args.Error(1)
 	}
 
-	return value.([]dto.AccountDetailResponse), nil
+	return value.(*dto.AccountPageResponse), nil
+}
+
+func (a *AccountServiceMock) Unlock(ctx context.Context, request *dto.UnlockAccountRequest) error {
+	args := a.Mock.Called(ctx, request)
+
+	return args.Error(0)
 }
@@ -0,0 +1,32 @@
+package mock
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+	"github.com/stretchr/testify/mock"
+	"io"
+)
+
+type AccountTransferServiceMock struct {
+	Mock *mock.Mock
+}
+
+func NewAccountTransferServiceMock() *AccountTransferServiceMock {
+	return &AccountTransferServiceMock{&mock.Mock{}}
+}
+
+func (a *AccountTransferServiceMock) Import(ctx context.Context, request *dto.ImportAccountRequest) (*dto.ImportAccountResponse, error) {
+	args := a.Mock.Called(ctx, request)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.ImportAccountResponse), nil
+}
+
+func (a *AccountTransferServiceMock) Export(ctx context.Context, format string, writer io.Writer) error {
+	args := a.Mock.Called(ctx, format, writer)
+	return args.Error(0)
+}
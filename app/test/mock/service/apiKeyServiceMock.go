@@ -0,0 +1,42 @@
+package mock
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type ApiKeyServiceMock struct {
+	Mock *mock.Mock
+}
+
+func NewApiKeyServiceMock() *ApiKeyServiceMock {
+	return &ApiKeyServiceMock{&mock.Mock{}}
+}
+
+func (a *ApiKeyServiceMock) Create(ctx context.Context, request *dto.CreateApiKeyRequest) (*dto.CreateApiKeyResponse, error) {
+	args := a.Mock.Called(ctx, request)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.CreateApiKeyResponse), nil
+}
+
+func (a *ApiKeyServiceMock) GetAll(ctx context.Context, accountId *int) ([]dto.ApiKeyResponse, error) {
+	args := a.Mock.Called(ctx, accountId)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.([]dto.ApiKeyResponse), nil
+}
+
+func (a *ApiKeyServiceMock) Revoke(ctx context.Context, accountId *int, id string) error {
+	args := a.Mock.Called(ctx, accountId, id)
+	return args.Error(0)
+}
@@ -0,0 +1,64 @@
+package mock
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type OAuthServiceMock struct {
+	Mock *mock.Mock
+}
+
+func NewOAuthServiceMock() *OAuthServiceMock {
+	return &OAuthServiceMock{&mock.Mock{}}
+}
+
+func (o *OAuthServiceMock) Token(ctx context.Context, request *dto.OAuthTokenRequest) (*dto.OAuthTokenResponse, error) {
+	args := o.Mock.Called(ctx, request)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.OAuthTokenResponse), nil
+}
+
+func (o *OAuthServiceMock) Introspect(ctx context.Context, request *dto.OAuthIntrospectRequest) (*dto.OAuthIntrospectResponse, error) {
+	args := o.Mock.Called(ctx, request)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.OAuthIntrospectResponse), nil
+}
+
+func (o *OAuthServiceMock) CreateClient(ctx context.Context, request *dto.CreateOAuthClientRequest) (*dto.CreateOAuthClientResponse, error) {
+	args := o.Mock.Called(ctx, request)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.CreateOAuthClientResponse), nil
+}
+
+func (o *OAuthServiceMock) GetAllClients(ctx context.Context) ([]dto.OAuthClientResponse, error) {
+	args := o.Mock.Called(ctx)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.([]dto.OAuthClientResponse), nil
+}
+
+func (o *OAuthServiceMock) RevokeClient(ctx context.Context, clientId string) error {
+	args := o.Mock.Called(ctx, clientId)
+	return args.Error(0)
+}
@@ -0,0 +1,25 @@
+package mock
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type PasswordServiceMock struct {
+	Mock *mock.Mock
+}
+
+func NewPasswordServiceMock() *PasswordServiceMock {
+	return &PasswordServiceMock{&mock.Mock{}}
+}
+
+func (p *PasswordServiceMock) Forgot(ctx context.Context, request *dto.ForgotPasswordRequest) error {
+	args := p.Mock.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (p *PasswordServiceMock) Reset(ctx context.Context, request *dto.ResetPasswordRequest) error {
+	args := p.Mock.Called(ctx, request)
+	return args.Error(0)
+}
@@ -0,0 +1,48 @@
+package mock
+
+import (
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionServiceMock struct {
+	Mock *mock.Mock
+}
+
+func NewSessionServiceMock() *SessionServiceMock {
+	return &SessionServiceMock{&mock.Mock{}}
+}
+
+func (s *SessionServiceMock) Start(ctx context.Context, account *entity.Account, client *dto.SessionClient) (*dto.LoginResponse, error) {
+	args := s.Mock.Called(ctx, account, client)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.LoginResponse), nil
+}
+
+func (s *SessionServiceMock) GetAll(ctx context.Context, accountId int, currentId string) ([]dto.SessionResponse, error) {
+	args := s.Mock.Called(ctx, accountId, currentId)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.([]dto.SessionResponse), nil
+}
+
+func (s *SessionServiceMock) Revoke(ctx context.Context, accountId int, sessionId string) error {
+	args := s.Mock.Called(ctx, accountId, sessionId)
+	return args.Error(0)
+}
+
+func (s *SessionServiceMock) RevokeAll(ctx context.Context, accountId int) error {
+	args := s.Mock.Called(ctx, accountId)
+	return args.Error(0)
+}
@@ -0,0 +1,53 @@
+package mock
+
+import (
+	"cobaMetrics/app/model/dto"
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type TwoFactorServiceMock struct {
+	Mock *mock.Mock
+}
+
+func NewTwoFactorServiceMock() *TwoFactorServiceMock {
+	return &TwoFactorServiceMock{&mock.Mock{}}
+}
+
+func (t *TwoFactorServiceMock) Enroll(ctx context.Context, accountId int) (*dto.TwoFactorEnrollResponse, error) {
+	args := t.Mock.Called(ctx, accountId)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.TwoFactorEnrollResponse), nil
+}
+
+func (t *TwoFactorServiceMock) Confirm(ctx context.Context, accountId int, request *dto.TwoFactorCodeRequest) (*dto.TwoFactorRecoveryCodesResponse, error) {
+	args := t.Mock.Called(ctx, accountId, request)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.TwoFactorRecoveryCodesResponse), nil
+}
+
+func (t *TwoFactorServiceMock) Disable(ctx context.Context, accountId int, request *dto.TwoFactorDisableRequest) error {
+	args := t.Mock.Called(ctx, accountId, request)
+	return args.Error(0)
+}
+
+func (t *TwoFactorServiceMock) Login(ctx context.Context, request *dto.TwoFactorLoginRequest) (*dto.LoginResponse, error) {
+	args := t.Mock.Called(ctx, request)
+
+	value := args.Get(0)
+	if value == nil {
+		return nil, args.Error(1)
+	}
+
+	return value.(*dto.LoginResponse), nil
+}
@@ -0,0 +1,31 @@
+package mock
+
+import (
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type VerificationServiceMock struct {
+	Mock *mock.Mock
+}
+
+func NewVerificationServiceMock() *VerificationServiceMock {
+	return &VerificationServiceMock{&mock.Mock{}}
+}
+
+func (v *VerificationServiceMock) Send(ctx context.Context, account *entity.Account) error {
+	args := v.Mock.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (v *VerificationServiceMock) Verify(ctx context.Context, token string) error {
+	args := v.Mock.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (v *VerificationServiceMock) Resend(ctx context.Context, request *dto.ResendVerificationRequest) error {
+	args := v.Mock.Called(ctx, request)
+	return args.Error(0)
+}
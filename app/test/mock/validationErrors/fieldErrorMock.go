@@ -2,19 +2,28 @@ package mock
 
 import (
 	"fmt"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	"reflect"
 )
 
 type FieldErrorMock struct {
 	validator.FieldError
 	TagError string
 	FieldErr string
+	ParamErr string
 }
 
 func (e *FieldErrorMock) Tag() string { return e.TagError }
 
 func (e *FieldErrorMock) Field() string { return e.FieldErr }
 
+func (e *FieldErrorMock) Param() string { return e.ParamErr }
+
+func (e *FieldErrorMock) Kind() reflect.Kind { return reflect.String }
+
 func (e *FieldErrorMock) Error() string {
 	return fmt.Sprintf("error on field [%v] with tag [%v]", e.FieldErr, e.TagError)
 }
+
+func (e *FieldErrorMock) Translate(ut.Translator) string { return e.Error() }
@@ -0,0 +1,262 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/model/entity"
+	jwtModel "cobaMetrics/app/model/jwt"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mockService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// integration test client_credentials token and introspection with sqlite database
+func TestOAuthSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.OAuth = &config.OAuth{TokenTTL: time.Hour}
+	cfg.Admin = &config.Admin{Emails: []string{"reoshby@gmail.com"}}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "hashed").Return(true)
+	helperPasswordMock.Mock.On("NeedsRehash", "hashed").Return(false)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	sessionRepository := repository.NewSessionRepository(cluster, sqliteDialect, logging.Discard())
+	apiKeyRepository := repository.NewApiKeyRepository(cluster, sqliteDialect, logging.Discard())
+	oauthClientRepository := repository.NewOAuthClientRepository(cluster, sqliteDialect, logging.Discard())
+	validate := helper.NewValidator()
+	sessionService := service.NewSessionService(cfg, sessionRepository, logging.Discard())
+	accountService := service.NewAccountService(transaction.NewTxManager(db), validate, cfg, accountRepository, helperPasswordMock, newVerificationMock(), sessionService, logging.Discard())
+	oauthService := service.NewOAuthService(validate, cfg, oauthClientRepository, accountRepository, sessionRepository, logging.Discard())
+	ctx := context.Background()
+
+	_, err := accountService.Add(ctx, &dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"})
+	assert.Nil(t, err)
+	login, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+	assert.Nil(t, err)
+
+	client, err := oauthService.CreateClient(ctx, &dto.CreateOAuthClientRequest{Name: "gateway", Scopes: []string{entity.ScopeAccountsRead}})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, client.ClientSecret)
+
+	oauthHandler := handler.NewOAuthHandler(oauthService)
+	authMiddleware := middleware.AuthMiddleware(cfg, accountRepository, sessionRepository, apiKeyRepository, oauthClientRepository, logging.Discard())
+	ok := func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(http.StatusOK)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/oauth/token", oauthHandler.Token)
+	app.Post("/oauth/introspect", oauthHandler.Introspect)
+	app.Get("/accounts", authMiddleware, middleware.ScopeMiddleware(logging.Discard())(entity.ScopeAccountsRead), ok)
+	app.Get("/other", authMiddleware, middleware.ScopeMiddleware(logging.Discard())("other:read"), ok)
+	app.Get("/sessions", middleware.UserAuthMiddleware(cfg, accountRepository, sessionRepository, logging.Discard()), ok)
+	app.Get("/admin", authMiddleware, middleware.AdminMiddleware(cfg, logging.Discard()), ok)
+
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte(url.QueryEscape(client.ClientId)+":"+url.QueryEscape(client.ClientSecret)))
+
+	// post form body, response decoded into out
+	post := func(path string, authorization string, form url.Values, out any) int {
+		httpRequest := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		httpRequest.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		if authorization != "" {
+			httpRequest.Header.Add("Authorization", authorization)
+		}
+		response, err := app.Test(httpRequest)
+		assert.Nil(t, err)
+		assert.Equal(t, "no-store", response.Header.Get("Cache-Control"))
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(out))
+		return response.StatusCode
+	}
+
+	get := func(path string, token string) int {
+		httpRequest := httptest.NewRequest(http.MethodGet, path, nil)
+		httpRequest.Header.Add("Authorization", "Bearer "+token)
+		response, err := app.Test(httpRequest)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	token := func() string {
+		var response dto.OAuthTokenResponse
+		assert.Equal(t, http.StatusOK, post("/oauth/token", basic, url.Values{"grant_type": {"client_credentials"}}, &response))
+		return response.AccessToken
+	}
+
+	introspect := func(token string) dto.OAuthIntrospectResponse {
+		var response dto.OAuthIntrospectResponse
+		assert.Equal(t, http.StatusOK, post("/oauth/introspect", basic, url.Values{"token": {token}}, &response))
+		return response
+	}
+
+	t.Run("token with basic authorization", func(t *testing.T) {
+		var response dto.OAuthTokenResponse
+		assert.Equal(t, http.StatusOK, post("/oauth/token", basic, url.Values{"grant_type": {"client_credentials"}, "scope": {entity.ScopeAccountsRead}}, &response))
+		assert.NotEmpty(t, response.AccessToken)
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.Equal(t, 3600, response.ExpiresIn)
+		assert.Equal(t, entity.ScopeAccountsRead, response.Scope)
+	})
+	t.Run("token with credential in form body", func(t *testing.T) {
+		var response dto.OAuthTokenResponse
+		form := url.Values{"grant_type": {"client_credentials"}, "client_id": {client.ClientId}, "client_secret": {client.ClientSecret}}
+		assert.Equal(t, http.StatusOK, post("/oauth/token", "", form, &response))
+		assert.Equal(t, entity.ScopeAccountsRead, response.Scope)
+	})
+	t.Run("token error", func(t *testing.T) {
+		wrongSecret := "Basic " + base64.StdEncoding.EncodeToString([]byte(client.ClientId+":salah"))
+		for _, test := range []struct {
+			authorization string
+			form          url.Values
+			status        int
+			error         string
+		}{
+			{basic, url.Values{}, http.StatusBadRequest, "invalid_request"},
+			{wrongSecret, url.Values{"grant_type": {"client_credentials"}}, http.StatusUnauthorized, "invalid_client"},
+			{"", url.Values{"grant_type": {"client_credentials"}}, http.StatusUnauthorized, "invalid_client"},
+			{basic, url.Values{"grant_type": {"client_credentials"}, "client_id": {client.ClientId}}, http.StatusBadRequest, "invalid_request"},
+			{basic, url.Values{"grant_type": {"password"}}, http.StatusBadRequest, "unsupported_grant_type"},
+			{basic, url.Values{"grant_type": {"client_credentials"}, "scope": {"accounts:write"}}, http.StatusBadRequest, "invalid_scope"},
+		} {
+			var response dto.OAuthErrorResponse
+			assert.Equal(t, test.status, post("/oauth/token", test.authorization, test.form, &response))
+			assert.Equal(t, test.error, response.Error)
+			assert.NotEmpty(t, response.ErrorDescription)
+		}
+	})
+	t.Run("client token limited to its scope", func(t *testing.T) {
+		clientToken := token()
+		assert.Equal(t, http.StatusOK, get("/accounts", clientToken))
+		assert.Equal(t, http.StatusForbidden, get("/other", clientToken))
+		assert.Equal(t, http.StatusForbidden, get("/sessions", clientToken))
+		assert.Equal(t, http.StatusForbidden, get("/admin", clientToken))
+	})
+	t.Run("introspect", func(t *testing.T) {
+		response := introspect(token())
+		assert.True(t, response.Active)
+		assert.Equal(t, client.ClientId, response.ClientId)
+		assert.Equal(t, entity.ScopeAccountsRead, response.Scope)
+		assert.NotZero(t, response.Exp)
+
+		response = introspect(login.Token)
+		assert.True(t, response.Active)
+		assert.Equal(t, "reoshby@gmail.com", response.Username)
+		assert.Empty(t, response.ClientId)
+
+		assert.False(t, introspect("bukan-token").Active)
+
+		var errorResponse dto.OAuthErrorResponse
+		assert.Equal(t, http.StatusBadRequest, post("/oauth/introspect", basic, url.Values{}, &errorResponse))
+		assert.Equal(t, "invalid_request", errorResponse.Error)
+		assert.Equal(t, http.StatusUnauthorized, post("/oauth/introspect", "", url.Values{"token": {login.Token}}, &errorResponse))
+		assert.Equal(t, "invalid_client", errorResponse.Error)
+	})
+	t.Run("token signed with other algorithm rejected", func(t *testing.T) {
+		var claims jwtModel.Claims
+		_, err := jwt.ParseWithClaims(login.Token, &claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(cfg.Jwt.SecretKey), nil
+		})
+		assert.Nil(t, err)
+
+		// same claims and secret, only algorithm different from the one server sign with
+		forged, err := jwt.NewWithClaims(jwt.SigningMethodHS512, &claims).SignedString([]byte(cfg.Jwt.SecretKey))
+		assert.Nil(t, err)
+
+		assert.False(t, introspect(forged).Active)
+		assert.Equal(t, http.StatusUnauthorized, get("/accounts", forged))
+		assert.Equal(t, http.StatusOK, get("/accounts", login.Token))
+	})
+	t.Run("revoked client", func(t *testing.T) {
+		clientToken := token()
+
+		other, err := oauthService.CreateClient(ctx, &dto.CreateOAuthClientRequest{Name: "other", Scopes: []string{entity.ScopeAccountsRead}})
+		assert.Nil(t, err)
+		assert.Nil(t, oauthService.RevokeClient(ctx, other.ClientId))
+		err = oauthService.RevokeClient(ctx, other.ClientId)
+		assert.Equal(t, customError.CodeOAuthClientNotFound, customError.FromError(err).Code)
+
+		clients, err := oauthService.GetAllClients(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, clients, 1)
+
+		assert.Nil(t, oauthService.RevokeClient(ctx, client.ClientId))
+		assert.Equal(t, http.StatusUnauthorized, get("/accounts", clientToken))
+
+		var response dto.OAuthErrorResponse
+		assert.Equal(t, http.StatusUnauthorized, post("/oauth/token", basic, url.Values{"grant_type": {"client_credentials"}}, &response))
+		assert.Equal(t, "invalid_client", response.Error)
+	})
+}
+
+// unit test oauth handler
+func TestOAuthHandler(t *testing.T) {
+	newApp := func(oauthService *mockService.OAuthServiceMock) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		oauthHandler := handler.NewOAuthHandler(oauthService)
+		app.Post("/oauth/token", oauthHandler.Token)
+		app.Delete("/admin/oauth/clients/:id", oauthHandler.RevokeClient)
+		return app
+	}
+
+	t.Run("basic authorization decoded", func(t *testing.T) {
+		oauthService := mockService.NewOAuthServiceMock()
+		oauthService.Mock.On("Token", mock.Anything, &dto.OAuthTokenRequest{GrantType: "client_credentials", ClientId: "id:x", ClientSecret: "rahasia"}).
+			Return(&dto.OAuthTokenResponse{AccessToken: "token", TokenType: "Bearer"}, nil)
+
+		request := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader("grant_type=client_credentials"))
+		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("id%3Ax:rahasia")))
+		response, err := newApp(oauthService).Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "no-cache", response.Header.Get("Pragma"))
+		oauthService.Mock.AssertExpectations(t)
+	})
+	t.Run("invalid client challenged", func(t *testing.T) {
+		oauthService := mockService.NewOAuthServiceMock()
+		oauthService.Mock.On("Token", mock.Anything, mock.Anything).Return(nil, customError.New(customError.CodeOAuthInvalidClient))
+
+		request := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader("grant_type=client_credentials&client_id=x&client_secret=y"))
+		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		response, err := newApp(oauthService).Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		assert.Equal(t, `Basic realm="oauth"`, response.Header.Get("WWW-Authenticate"))
+
+		var body map[string]any
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+		assert.Equal(t, "invalid_client", body["error"])
+		assert.NotEmpty(t, body["error_description"])
+	})
+	t.Run("client not found use api response", func(t *testing.T) {
+		oauthService := mockService.NewOAuthServiceMock()
+		oauthService.Mock.On("RevokeClient", mock.Anything, "lain").Return(customError.New(customError.CodeOAuthClientNotFound))
+
+		response, err := newApp(oauthService).Test(httptest.NewRequest(http.MethodDelete, "/admin/oauth/clients/lain", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+}
@@ -0,0 +1,153 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/helper"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+// newPasswordConfig create password config with cheap parameter, so test run fast
+func newPasswordConfig(algorithm string) *config.Password {
+	return &config.Password{
+		Algorithm: algorithm,
+		Argon2id: &config.Argon2idParam{
+			Memory:      1024,
+			Iterations:  1,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		Bcrypt: &config.BcryptParam{Cost: 4},
+	}
+}
+
+// unit test hash and verify password of every algorithm
+func TestHelperPassword(t *testing.T) {
+	testCases := []struct {
+		algorithm string
+		format    *regexp.Regexp
+	}{
+		{helper.PasswordArgon2id, regexp.MustCompile(`^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`)},
+		{helper.PasswordBcrypt, regexp.MustCompile(`^\$2a\$04\$[./A-Za-z0-9]{53}$`)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.algorithm, func(t *testing.T) {
+			helperPassword, err := helper.NewHelperPassword(newPasswordConfig(testCase.algorithm))
+			assert.Nil(t, err)
+
+			hash, err := helperPassword.HashPassword("123456")
+			assert.Nil(t, err)
+			assert.Regexp(t, testCase.format, hash)
+
+			// salt random, same password has different hash
+			otherHash, _ := helperPassword.HashPassword("123456")
+			assert.NotEqual(t, hash, otherHash)
+
+			assert.True(t, helperPassword.CheckPasswordHash("123456", hash))
+			assert.False(t, helperPassword.CheckPasswordHash("1234567", hash))
+			assert.False(t, helperPassword.NeedsRehash(hash))
+		})
+	}
+
+	t.Run("verify hash of other algorithm and rehash it", func(t *testing.T) {
+		bcryptHelper, _ := helper.NewHelperPassword(newPasswordConfig(helper.PasswordBcrypt))
+		argon2idHelper, _ := helper.NewHelperPassword(newPasswordConfig(helper.PasswordArgon2id))
+
+		bcryptHash, _ := bcryptHelper.HashPassword("123456")
+		assert.True(t, argon2idHelper.CheckPasswordHash("123456", bcryptHash))
+		assert.True(t, argon2idHelper.NeedsRehash(bcryptHash))
+
+		argon2idHash, _ := argon2idHelper.HashPassword("123456")
+		assert.True(t, bcryptHelper.CheckPasswordHash("123456", argon2idHash))
+		assert.True(t, bcryptHelper.NeedsRehash(argon2idHash))
+	})
+	t.Run("rehash when parameter changed", func(t *testing.T) {
+		oldHelper, _ := helper.NewHelperPassword(newPasswordConfig(helper.PasswordArgon2id))
+		hash, _ := oldHelper.HashPassword("123456")
+
+		newConfig := newPasswordConfig(helper.PasswordArgon2id)
+		newConfig.Argon2id.Iterations = 2
+		newHelper, _ := helper.NewHelperPassword(newConfig)
+		assert.True(t, newHelper.CheckPasswordHash("123456", hash))
+		assert.True(t, newHelper.NeedsRehash(hash))
+
+		bcryptHelper, _ := helper.NewHelperPassword(newPasswordConfig(helper.PasswordBcrypt))
+		bcryptHash, _ := bcryptHelper.HashPassword("123456")
+
+		newConfig.Algorithm = helper.PasswordBcrypt
+		newConfig.Bcrypt.Cost = 5
+		newHelper, _ = helper.NewHelperPassword(newConfig)
+		assert.True(t, newHelper.NeedsRehash(bcryptHash))
+	})
+	t.Run("reject unknown or malformed hash", func(t *testing.T) {
+		helperPassword, _ := helper.NewHelperPassword(newPasswordConfig(helper.PasswordArgon2id))
+
+		for _, hash := range []string{"", "123456", "$argon2id$v=19$m=1024,t=1,p=1$salt", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5a2V5"} {
+			assert.False(t, helperPassword.CheckPasswordHash("123456", hash))
+			assert.True(t, helperPassword.NeedsRehash(hash))
+		}
+	})
+	t.Run("error invalid config", func(t *testing.T) {
+		for _, update := range []func(*config.Password){
+			func(c *config.Password) { c.Algorithm = "md5" },
+			func(c *config.Password) { c.Bcrypt.Cost = 32 },
+			func(c *config.Password) { c.Argon2id.Iterations = 0 },
+			func(c *config.Password) { c.Argon2id.Parallelism = 0 },
+			func(c *config.Password) { c.Argon2id.KeyLength = 8 },
+			func(c *config.Password) { c.Argon2id = nil },
+		} {
+			passwordConfig := newPasswordConfig(helper.PasswordArgon2id)
+			update(passwordConfig)
+
+			helperPassword, err := helper.NewHelperPassword(passwordConfig)
+			assert.Nil(t, helperPassword)
+			assert.NotNil(t, err)
+		}
+	})
+}
+
+// benchmark hash password, used to choose parameter in config. run with : go test ./app/test -run ^$ -bench HashPassword
+func BenchmarkHashPassword(b *testing.B) {
+	argon2idParams := []config.Argon2idParam{
+		{Memory: 19456, Iterations: 2, Parallelism: 1},
+		{Memory: 47104, Iterations: 1, Parallelism: 1},
+		{Memory: 65536, Iterations: 3, Parallelism: 2},
+	}
+	for _, param := range argon2idParams {
+		param.SaltLength, param.KeyLength = 16, 32
+		passwordConfig := newPasswordConfig(helper.PasswordArgon2id)
+		passwordConfig.Argon2id = &param
+
+		b.Run(fmt.Sprintf("argon2id m=%v,t=%v,p=%v", param.Memory, param.Iterations, param.Parallelism), func(b *testing.B) {
+			benchmarkHashPassword(b, passwordConfig)
+		})
+	}
+
+	for _, cost := range []int{10, 12, 14} {
+		passwordConfig := newPasswordConfig(helper.PasswordBcrypt)
+		passwordConfig.Bcrypt.Cost = cost
+
+		b.Run(fmt.Sprintf("bcrypt cost=%v", cost), func(b *testing.B) {
+			benchmarkHashPassword(b, passwordConfig)
+		})
+	}
+}
+
+func benchmarkHashPassword(b *testing.B, passwordConfig *config.Password) {
+	helperPassword, err := helper.NewHelperPassword(passwordConfig)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := helperPassword.HashPassword("sangatrahasia123"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/passwordpolicy"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const breachedListContent = `# sha1 of breached password
+7C4A8D09CA3762AF61E59520943DC26494F8941B:24230577
+70ccd9007338d6d81dd3b6271621b9cf9a97ea00
+
+`
+
+// newStrictPolicy create policy with every rule enabled, breached list contain 123456 and Password1
+func newStrictPolicy(t *testing.T) *passwordpolicy.Policy {
+	breached, err := passwordpolicy.ParseBreachedList(strings.NewReader(breachedListContent))
+	assert.Nil(t, err)
+
+	return &passwordpolicy.Policy{
+		MinLength:        8,
+		MaxLength:        72,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowIdentity: true,
+		Breached:         breached,
+	}
+}
+
+// unit test every rule of password policy
+func TestPasswordPolicy(t *testing.T) {
+	policy := newStrictPolicy(t)
+	identities := []string{"reoshby@gmail.com", "rshby"}
+
+	testCases := []struct {
+		name     string
+		password string
+		rule     string
+	}{
+		{"too short", "Ab1!", passwordpolicy.RuleMinLength},
+		{"too long for bcrypt", "Ab1!" + strings.Repeat("a", 69), passwordpolicy.RuleMaxLength},
+		{"max length counted in byte", "Ab1!" + strings.Repeat("é", 35), passwordpolicy.RuleMaxLength},
+		{"without uppercase", "abcdef1!", passwordpolicy.RuleUpper},
+		{"without lowercase", "ABCDEF1!", passwordpolicy.RuleLower},
+		{"without digit", "Abcdefg!", passwordpolicy.RuleDigit},
+		{"without symbol", "Abcdefg1", passwordpolicy.RuleSymbol},
+		{"contain username", "My-RSHBY-2024", passwordpolicy.RuleIdentity},
+		{"contain local part of email", "ReoShby#2024", passwordpolicy.RuleIdentity},
+		{"allowed", "Kuda-Lari#2024", ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.rule, policy.Check(testCase.password, identities...))
+		})
+	}
+
+	t.Run("breached password", func(t *testing.T) {
+		policy := &passwordpolicy.Policy{MinLength: 6, Breached: policy.Breached}
+		assert.Equal(t, passwordpolicy.RuleBreached, policy.Check("123456"))
+		assert.Equal(t, passwordpolicy.RuleBreached, policy.Check("Password1"))
+		assert.Equal(t, "", policy.Check("1234567"))
+	})
+	t.Run("short identity not checked", func(t *testing.T) {
+		assert.Equal(t, "", policy.Check("Kuda-Lari#2024", "ku@gmail.com", "la"))
+	})
+	t.Run("default policy same as old min rule", func(t *testing.T) {
+		assert.Equal(t, passwordpolicy.RuleMinLength, passwordpolicy.Default().Check("12345"))
+		assert.Equal(t, "", passwordpolicy.Default().Check("123456"))
+	})
+}
+
+// unit test load breached list grouped by hash prefix
+func TestBreachedList(t *testing.T) {
+	t.Run("parse hash with count, comment and lowercase", func(t *testing.T) {
+		breached, err := passwordpolicy.ParseBreachedList(strings.NewReader(breachedListContent))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, breached.Len())
+		assert.Equal(t, []string{"D09CA3762AF61E59520943DC26494F8941B"}, breached.Range("7c4a8"))
+		assert.Empty(t, breached.Range("00000"))
+	})
+	t.Run("error invalid hash", func(t *testing.T) {
+		breached, err := passwordpolicy.ParseBreachedList(strings.NewReader("7C4A8D09CA3762AF61E59520943DC26494F8941B\nnot-a-hash\n"))
+		assert.Nil(t, breached)
+		assert.ErrorContains(t, err, "line 2")
+	})
+	t.Run("load breached list of repository", func(t *testing.T) {
+		policy, err := passwordpolicy.NewPolicy(&config.PasswordPolicy{MinLength: 8, BreachedFile: "../../breached_passwords.txt"})
+		assert.Nil(t, err)
+		assert.NotZero(t, policy.Breached.Len())
+		assert.True(t, policy.Breached.Contains("Password1"))
+		assert.False(t, policy.Breached.Contains("Kuda-Lari#2024"))
+	})
+	t.Run("error file not found", func(t *testing.T) {
+		policy, err := passwordpolicy.NewPolicy(&config.PasswordPolicy{BreachedFile: "not_found.txt"})
+		assert.Nil(t, policy)
+		assert.NotNil(t, err)
+	})
+}
+
+// unit test password validator tag on add and update request
+func TestPasswordValidator(t *testing.T) {
+	validate := helper.NewValidator()
+	assert.Nil(t, helper.RegisterPasswordPolicy(validate, newStrictPolicy(t)))
+
+	passwordError := func(request any, locale string) string {
+		err := validate.Struct(request)
+		if err == nil {
+			return ""
+		}
+
+		for _, fieldError := range customError.FieldErrors(err.(validator.ValidationErrors), locale) {
+			if fieldError.Field == "password" {
+				assert.Equal(t, helper.PasswordTag, fieldError.Rule)
+				return fieldError.Message
+			}
+		}
+
+		return ""
+	}
+
+	t.Run("add request", func(t *testing.T) {
+		request := dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby"}
+
+		request.Password = "Ab1!"
+		assert.Equal(t, "password must be at least 8 characters long", passwordError(request, i18n.EN))
+		assert.Equal(t, "password minimal 8 karakter", passwordError(request, i18n.ID))
+
+		request.Password = "Rshby-2024"
+		assert.Equal(t, "password must not contain your email or username", passwordError(request, i18n.EN))
+
+		request.Password = "Kuda-Lari#2024"
+		assert.Equal(t, "", passwordError(request, i18n.EN))
+	})
+	t.Run("update request", func(t *testing.T) {
+		request := dto.UpdateAccountRequest{Id: 1, Email: "reoshby@gmail.com", Username: "rshby", Password: "abcdefg1!", ConfirmPassword: "abcdefg1!"}
+		assert.Equal(t, "password must contain an uppercase letter", passwordError(&request, i18n.EN))
+		assert.Equal(t, "password harus mengandung huruf besar", passwordError(&request, i18n.ID))
+	})
+	t.Run("breached password", func(t *testing.T) {
+		validate := helper.NewValidator()
+		breached, _ := passwordpolicy.ParseBreachedList(strings.NewReader(breachedListContent))
+		assert.Nil(t, helper.RegisterPasswordPolicy(validate, &passwordpolicy.Policy{MinLength: 6, Breached: breached}))
+
+		err := validate.Struct(dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"})
+		fieldErrors := customError.FieldErrors(err.(validator.ValidationErrors), i18n.EN)
+		assert.Equal(t, "password has appeared in a data breach, choose another one", fieldErrors[0].Message)
+	})
+}
@@ -0,0 +1,278 @@
+package test
+
+import (
+	"bytes"
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/i18n"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/mailer"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	mockService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// integration test forgot and reset password with sqlite database
+func TestPasswordResetSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.PasswordReset = &config.PasswordReset{TokenTTL: 30 * time.Minute, URL: "http://localhost:3000/reset-password"}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPassword, err := helper.NewHelperPassword(&config.Password{
+		Algorithm: helper.PasswordBcrypt,
+		Argon2id:  &config.Argon2idParam{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		Bcrypt:    &config.BcryptParam{Cost: 4},
+	})
+	assert.Nil(t, err)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	resetRepository := repository.NewPasswordResetRepository(cluster, sqliteDialect, logging.Discard())
+	sessionRepository := repository.NewSessionRepository(cluster, sqliteDialect, logging.Discard())
+	txManager := transaction.NewTxManager(db)
+	validate := helper.NewValidator()
+	mailBuffer := &bytes.Buffer{}
+
+	accountService := service.NewAccountService(txManager, validate, cfg, accountRepository, helperPassword, newVerificationMock(), service.NewSessionService(cfg, sessionRepository, logging.Discard()), logging.Discard())
+	passwordService := service.NewPasswordService(txManager, validate, cfg, accountRepository, resetRepository, sessionRepository, helperPassword, mailer.NewLogMailer(mailBuffer, "noreply@coba-metrics.local", logging.Discard()), logging.Discard())
+	ctx := context.Background()
+
+	_, err = accountService.Add(ctx, &dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"})
+	assert.Nil(t, err)
+
+	login, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+	assert.Nil(t, err)
+
+	tokenPattern := regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+	forgot := func() string {
+		mailBuffer.Reset()
+		assert.Nil(t, passwordService.Forgot(ctx, &dto.ForgotPasswordRequest{Email: "reoshby@gmail.com"}))
+		passwordService.(*service.PasswordService).Wait()
+
+		match := tokenPattern.FindStringSubmatch(mailBuffer.String())
+		assert.Len(t, match, 2)
+		return match[1]
+	}
+
+	reset := func(token string, password string) string {
+		err := passwordService.Reset(ctx, &dto.ResetPasswordRequest{Token: token, Password: password, ConfirmPassword: password})
+		if err != nil {
+			return customError.FromError(err).Code
+		}
+
+		return ""
+	}
+
+	t.Run("unknown email not send mail", func(t *testing.T) {
+		mailBuffer.Reset()
+		assert.Nil(t, passwordService.Forgot(ctx, &dto.ForgotPasswordRequest{Email: "notfound@gmail.com"}))
+		passwordService.(*service.PasswordService).Wait()
+		assert.Zero(t, mailBuffer.Len())
+	})
+	t.Run("mail contain reset link", func(t *testing.T) {
+		forgot()
+		assert.Contains(t, mailBuffer.String(), "To: reoshby@gmail.com")
+		assert.Contains(t, mailBuffer.String(), "http://localhost:3000/reset-password?token=")
+	})
+	t.Run("invalid token", func(t *testing.T) {
+		assert.Equal(t, customError.CodePasswordResetTokenInvalid, reset("tidak-ada", "rahasia123"))
+	})
+	t.Run("new token invalidate previous token", func(t *testing.T) {
+		previous := forgot()
+		forgot()
+		assert.Equal(t, customError.CodePasswordResetTokenInvalid, reset(previous, "rahasia123"))
+	})
+	t.Run("password not pass policy", func(t *testing.T) {
+		assert.Equal(t, customError.CodeValidation, reset(forgot(), "123"))
+	})
+	t.Run("reset password once", func(t *testing.T) {
+		token := forgot()
+		assert.Empty(t, reset(token, "rahasia123"))
+		assert.Equal(t, customError.CodePasswordResetTokenInvalid, reset(token, "rahasia456"))
+
+		_, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		assert.NotNil(t, err)
+
+		_, err = accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "rahasia123"})
+		assert.Nil(t, err)
+
+		account, err := accountRepository.GetByEmail(ctx, "reoshby@gmail.com")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, account.TokenVersion)
+	})
+	t.Run("expired token", func(t *testing.T) {
+		cfg.PasswordReset.TokenTTL = -time.Minute
+		defer func() { cfg.PasswordReset.TokenTTL = 30 * time.Minute }()
+
+		assert.Equal(t, customError.CodePasswordResetTokenInvalid, reset(forgot(), "rahasia789"))
+	})
+	t.Run("access token issued before reset revoked", func(t *testing.T) {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Get("/", middleware.UserAuthMiddleware(cfg, accountRepository, sessionRepository, logging.Discard()), func(ctx *fiber.Ctx) error {
+			return ctx.SendStatus(http.StatusOK)
+		})
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Add("Authorization", "Bearer "+login.Token)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+		body, _ := io.ReadAll(response.Body)
+		assert.Contains(t, string(body), "token revoked")
+
+		newLogin, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "rahasia123"})
+		assert.Nil(t, err)
+
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Add("Authorization", "Bearer "+newLogin.Token)
+		response, err = app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+	t.Run("known email not wait mail sent", func(t *testing.T) {
+		writer := &blockingWriter{release: make(chan struct{})}
+		slowService := service.NewPasswordService(txManager, validate, cfg, accountRepository, resetRepository, sessionRepository, helperPassword, mailer.NewLogMailer(writer, "noreply@coba-metrics.local", logging.Discard()), logging.Discard())
+
+		// request cancelled after response, mail still sent
+		requestCtx, cancel := context.WithCancel(ctx)
+		assert.Nil(t, slowService.Forgot(requestCtx, &dto.ForgotPasswordRequest{Email: "reoshby@gmail.com"}))
+		cancel()
+
+		close(writer.release)
+		slowService.(*service.PasswordService).Wait()
+		assert.Contains(t, writer.String(), "http://localhost:3000/reset-password?token=")
+	})
+	t.Run("mail not read request reused by next request", func(t *testing.T) {
+		writer := &blockingWriter{release: make(chan struct{})}
+		slowService := service.NewPasswordService(txManager, validate, cfg, accountRepository, resetRepository, sessionRepository, helperPassword, mailer.NewLogMailer(writer, "noreply@coba-metrics.local", logging.Discard()), logging.Discard())
+
+		requestCtx := &recycledContext{Context: ctx, values: map[any]any{i18n.LocaleKey: "id", logging.RequestIDKey: "req-1"}}
+		assert.Nil(t, slowService.Forgot(requestCtx, &dto.ForgotPasswordRequest{Email: "reoshby@gmail.com"}))
+
+		// request served next request before mail sent, run with -race to catch read of old request
+		requestCtx.values[i18n.LocaleKey] = "en"
+		requestCtx.values[logging.RequestIDKey] = "req-2"
+
+		close(writer.release)
+		slowService.(*service.PasswordService).Wait()
+		assert.Contains(t, writer.String(), "Subject: "+i18n.Translate("id", i18n.MessageMailPasswordResetSubject))
+	})
+}
+
+// recycledContext act like pooled fasthttp request, its value replaced when it serve next request
+type recycledContext struct {
+	context.Context
+	values map[any]any
+}
+
+func (r *recycledContext) Value(key any) any {
+	if value, ok := r.values[key]; ok {
+		return value
+	}
+
+	return r.Context.Value(key)
+}
+
+// blockingWriter hold every write until released, like slow smtp server
+type blockingWriter struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	<-b.release
+	return b.Buffer.Write(p)
+}
+
+// unit test format email message
+func TestMailerFormat(t *testing.T) {
+	date := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	message := string(mailer.Format("noreply@coba-metrics.local", mailer.Message{
+		To:      "reoshby@gmail.com",
+		Subject: "Atur ulang password",
+		Body:    "halo",
+	}, date))
+
+	assert.True(t, strings.HasPrefix(message, "From: noreply@coba-metrics.local\r\nTo: reoshby@gmail.com\r\n"))
+	assert.Contains(t, message, "Subject: Atur ulang password\r\n")
+	assert.Contains(t, message, "Date: Fri, 01 Mar 2024 10:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(message, "\r\n\r\nhalo\r\n"))
+
+	t.Run("unknown driver", func(t *testing.T) {
+		_, err := mailer.NewMailer(&config.Mail{Driver: "pigeon"}, logging.Discard())
+		assert.NotNil(t, err)
+	})
+}
+
+// unit test forgot and reset password handler
+func TestPasswordHandler(t *testing.T) {
+	newApp := func(passwordService *mockService.PasswordServiceMock) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		passwordHandler := handler.NewPasswordHandler(passwordService)
+		app.Post("/password/forgot", passwordHandler.Forgot)
+		app.Post("/password/reset", passwordHandler.Reset)
+		return app
+	}
+
+	send := func(app *fiber.App, path string, body string) (int, map[string]any) {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Add("Content-Type", "application/json")
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+
+		responseBody := map[string]any{}
+		bodyBytes, _ := io.ReadAll(response.Body)
+		json.Unmarshal(bodyBytes, &responseBody)
+		return response.StatusCode, responseBody
+	}
+
+	t.Run("forgot accepted", func(t *testing.T) {
+		passwordService := mockService.NewPasswordServiceMock()
+		passwordService.Mock.On("Forgot", mock.Anything, &dto.ForgotPasswordRequest{Email: "reoshby@gmail.com"}).Return(nil)
+
+		statusCode, body := send(newApp(passwordService), "/password/forgot", `{"email":"reoshby@gmail.com"}`)
+		assert.Equal(t, http.StatusAccepted, statusCode)
+		assert.Equal(t, http.StatusAccepted, int(body["status_code"].(float64)))
+		passwordService.Mock.AssertExpectations(t)
+	})
+	t.Run("forgot invalid body", func(t *testing.T) {
+		statusCode, _ := send(newApp(mockService.NewPasswordServiceMock()), "/password/forgot", `{`)
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+	})
+	t.Run("reset success", func(t *testing.T) {
+		passwordService := mockService.NewPasswordServiceMock()
+		passwordService.Mock.On("Reset", mock.Anything, &dto.ResetPasswordRequest{Token: "abc", Password: "rahasia123", ConfirmPassword: "rahasia123"}).Return(nil)
+
+		statusCode, _ := send(newApp(passwordService), "/password/reset", `{"token":"abc","password":"rahasia123","confirm_password":"rahasia123"}`)
+		assert.Equal(t, http.StatusOK, statusCode)
+		passwordService.Mock.AssertExpectations(t)
+	})
+	t.Run("reset invalid token", func(t *testing.T) {
+		passwordService := mockService.NewPasswordServiceMock()
+		passwordService.Mock.On("Reset", mock.Anything, mock.Anything).Return(customError.New(customError.CodePasswordResetTokenInvalid))
+
+		statusCode, body := send(newApp(passwordService), "/password/reset", `{"token":"abc","password":"rahasia123","confirm_password":"rahasia123"}`)
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "reset token not valid or expired", body["message"])
+	})
+}
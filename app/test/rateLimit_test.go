@@ -0,0 +1,171 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/ratelimit"
+	"cobaMetrics/metrics"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRateLimitApp create fiber app with login route limited by rule in config
+func newRateLimitApp(rateLimitConfig *config.RateLimit, now func() time.Time) (*fiber.App, *metrics.MetricsApp) {
+	store := ratelimit.NewMemoryStore()
+	store.Now = now
+	metricsApp := metrics.AddMetrics()
+	limiter := middleware.NewRateLimiter(rateLimitConfig, store, metricsApp, logging.Discard())
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: handler.NewErrorHandler(logging.Discard()),
+	})
+	app.Post("/login", limiter.For("login"), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("login")
+	})
+	app.Get("/accounts", func(ctx *fiber.Ctx) error {
+		ctx.Locals(logging.AccountIDKey, 7)
+		return ctx.Next()
+	}, limiter.For("get_accounts"), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("accounts")
+	})
+
+	return app, metricsApp
+}
+
+func newLoginRequest(email string) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"`+email+`","password":"rahasia"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return request
+}
+
+// unit test token bucket refill with fake clock
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := ratelimit.NewMemoryStore()
+	store.Now = func() time.Time { return now }
+	rule := ratelimit.Rule{Limit: 2, Period: time.Minute}
+
+	for i := 1; i >= 0; i-- {
+		result, err := store.Take(context.Background(), "key", rule)
+		assert.Nil(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "key", rule)
+	assert.Nil(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 2, result.Limit)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	// other key has own bucket
+	result, _ = store.Take(context.Background(), "other", rule)
+	assert.True(t, result.Allowed)
+
+	// one token refilled every 30 second
+	now = now.Add(30 * time.Second)
+	result, _ = store.Take(context.Background(), "key", rule)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// full bucket removed on sweep
+	now = now.Add(2 * time.Minute)
+	store.Take(context.Background(), "key", rule)
+	assert.Equal(t, 1, store.Len())
+}
+
+// unit test request rejected with 429, header and counter
+func TestRateLimitMiddleware(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rateLimitConfig := &config.RateLimit{
+		Enabled: true,
+		Routes: map[string][]*config.RateLimitRule{
+			"login": {
+				{Key: middleware.RateLimitKeyIP, Limit: 10, Period: time.Minute},
+				{Key: middleware.RateLimitKeyEmail, Limit: 2, Period: time.Minute},
+			},
+			"get_accounts": {
+				{Key: middleware.RateLimitKeySubject, Limit: 1, Period: time.Minute},
+			},
+		},
+	}
+
+	t.Run("reject after limit of email", func(t *testing.T) {
+		app, metricsApp := newRateLimitApp(rateLimitConfig, func() time.Time { return now })
+
+		for i := 1; i >= 0; i-- {
+			response, err := app.Test(newLoginRequest("reoshby@gmail.com"))
+			assert.Nil(t, err)
+			assert.Equal(t, fiber.StatusOK, response.StatusCode)
+			assert.Equal(t, "2", response.Header.Get(middleware.HeaderRateLimitLimit))
+			assert.Equal(t, strconv.Itoa(i), response.Header.Get(middleware.HeaderRateLimitRemaining))
+		}
+
+		// email is case insensitive
+		response, err := app.Test(newLoginRequest("ReoShby@gmail.com"))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusTooManyRequests, response.StatusCode)
+		assert.Equal(t, "30", response.Header.Get(fiber.HeaderRetryAfter))
+		assert.Equal(t, "0", response.Header.Get(middleware.HeaderRateLimitRemaining))
+		assert.Equal(t, "60", response.Header.Get(middleware.HeaderRateLimitReset))
+		assert.Equal(t, float64(1), testutil.ToFloat64(metricsApp.RateLimitHits.WithLabelValues("login", middleware.RateLimitKeyEmail)))
+
+		// other email still allowed
+		response, err = app.Test(newLoginRequest("other@gmail.com"))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+	})
+
+	t.Run("reject after limit of subject", func(t *testing.T) {
+		app, metricsApp := newRateLimitApp(rateLimitConfig, func() time.Time { return now })
+
+		response, err := app.Test(httptest.NewRequest(http.MethodGet, "/accounts", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+
+		response, err = app.Test(httptest.NewRequest(http.MethodGet, "/accounts", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusTooManyRequests, response.StatusCode)
+		assert.Equal(t, "60", response.Header.Get(fiber.HeaderRetryAfter))
+		assert.Equal(t, float64(1), testutil.ToFloat64(metricsApp.RateLimitHits.WithLabelValues("get_accounts", middleware.RateLimitKeySubject)))
+	})
+
+	t.Run("not limited when disabled", func(t *testing.T) {
+		app, _ := newRateLimitApp(&config.RateLimit{Enabled: false, Routes: rateLimitConfig.Routes}, func() time.Time { return now })
+
+		for i := 0; i < 3; i++ {
+			response, err := app.Test(newLoginRequest("reoshby@gmail.com"))
+			assert.Nil(t, err)
+			assert.Equal(t, fiber.StatusOK, response.StatusCode)
+			assert.Empty(t, response.Header.Get(middleware.HeaderRateLimitLimit))
+		}
+	})
+}
+
+// unit test rule that make token bucket divide by zero rejected
+func TestRateLimitConfigValidate(t *testing.T) {
+	valid := &config.RateLimitRule{Key: "ip", Limit: 5, Period: time.Minute}
+	assert.Nil(t, (&config.RateLimit{Routes: map[string][]*config.RateLimitRule{"login": {valid}}}).Validate())
+
+	for name, rule := range map[string]*config.RateLimitRule{
+		"zero period":    {Key: "ip", Limit: 5},
+		"zero limit":     {Key: "ip", Period: time.Minute},
+		"negative burst": {Key: "ip", Limit: 5, Period: time.Minute, Burst: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := (&config.RateLimit{Routes: map[string][]*config.RateLimitRule{"login": {valid, rule}}}).Validate()
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "rate_limit.routes.login[1]")
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	jwtModel "cobaMetrics/app/model/jwt"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mockService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// integration test login session, list and revoke with sqlite database
+func TestSessionSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.Session = &config.Session{TouchInterval: 0}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "hashed").Return(true)
+	helperPasswordMock.Mock.On("NeedsRehash", "hashed").Return(false)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	sessionRepository := repository.NewSessionRepository(cluster, sqliteDialect, logging.Discard())
+	sessionService := service.NewSessionService(cfg, sessionRepository, logging.Discard())
+	accountService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), cfg, accountRepository, helperPasswordMock, newVerificationMock(), sessionService, logging.Discard())
+	ctx := context.Background()
+
+	account, err := accountService.Add(ctx, &dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"})
+	assert.Nil(t, err)
+	_, err = accountService.Add(ctx, &dto.AddUserRequest{Email: "reo@gmail.com", Username: "reo", Password: "123456"})
+	assert.Nil(t, err)
+
+	login := func(email string, userAgent string) string {
+		response, err := accountService.Login(ctx, &dto.LoginRequest{
+			Email:    email,
+			Password: "123456",
+			Client:   dto.SessionClient{UserAgent: userAgent, IP: "10.0.0.1"},
+		})
+		assert.Nil(t, err)
+		return response.Token
+	}
+
+	sessionId := func(token string) string {
+		claims := jwtModel.Claims{}
+		_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(cfg.Jwt.SecretKey), nil
+		})
+		assert.Nil(t, err)
+		return claims.SessionId
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Get("/", middleware.UserAuthMiddleware(cfg, accountRepository, sessionRepository, logging.Discard()), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(http.StatusOK)
+	})
+	authorize := func(token string) int {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Add("Authorization", "Bearer "+token)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	laptop := login("reoshby@gmail.com", "Firefox")
+	phone := login("reoshby@gmail.com", "Android")
+	otherToken := login("reo@gmail.com", "Chrome")
+
+	t.Run("login recorded as session", func(t *testing.T) {
+		sessions, err := sessionService.GetAll(ctx, account.Id, sessionId(laptop))
+		assert.Nil(t, err)
+		assert.Len(t, sessions, 2)
+
+		current := map[string]bool{}
+		for _, session := range sessions {
+			assert.Equal(t, "10.0.0.1", session.IP)
+			current[session.UserAgent] = session.Current
+		}
+		assert.Equal(t, map[string]bool{"Firefox": true, "Android": false}, current)
+	})
+	t.Run("request touch last seen", func(t *testing.T) {
+		before, err := sessionRepository.GetById(ctx, sessionId(laptop))
+		assert.Nil(t, err)
+
+		time.Sleep(1100 * time.Millisecond)
+		assert.Equal(t, http.StatusOK, authorize(laptop))
+
+		after, err := sessionRepository.GetById(ctx, sessionId(laptop))
+		assert.Nil(t, err)
+		assert.True(t, after.LastSeenAt.After(before.LastSeenAt))
+	})
+	t.Run("revoke session of other account", func(t *testing.T) {
+		err := sessionService.Revoke(ctx, account.Id, sessionId(otherToken))
+		assert.Equal(t, customError.CodeSessionNotFound, customError.FromError(err).Code)
+		assert.Equal(t, http.StatusOK, authorize(otherToken))
+	})
+	t.Run("revoke one session", func(t *testing.T) {
+		assert.Nil(t, sessionService.Revoke(ctx, account.Id, sessionId(phone)))
+		assert.Equal(t, http.StatusUnauthorized, authorize(phone))
+		assert.Equal(t, http.StatusOK, authorize(laptop))
+
+		// revoked session not listed and can not revoked twice
+		sessions, err := sessionService.GetAll(ctx, account.Id, sessionId(laptop))
+		assert.Nil(t, err)
+		assert.Len(t, sessions, 1)
+
+		err = sessionService.Revoke(ctx, account.Id, sessionId(phone))
+		assert.Equal(t, customError.CodeSessionNotFound, customError.FromError(err).Code)
+	})
+	t.Run("log out everywhere", func(t *testing.T) {
+		tablet := login("reoshby@gmail.com", "iPad")
+		assert.Nil(t, sessionService.RevokeAll(ctx, account.Id))
+
+		assert.Equal(t, http.StatusUnauthorized, authorize(laptop))
+		assert.Equal(t, http.StatusUnauthorized, authorize(tablet))
+		assert.Equal(t, http.StatusOK, authorize(otherToken))
+
+		sessions, err := sessionService.GetAll(ctx, account.Id, "")
+		assert.Nil(t, err)
+		assert.Empty(t, sessions)
+	})
+	t.Run("login again after log out everywhere", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, authorize(login("reoshby@gmail.com", "Firefox")))
+	})
+}
+
+// unit test session handler
+func TestSessionHandler(t *testing.T) {
+	newApp := func(sessionService *mockService.SessionServiceMock, claims *jwtModel.Claims) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		sessionHandler := handler.NewSessionHandler(sessionService)
+
+		// set claims like AuthMiddleware
+		app.Use(func(ctx *fiber.Ctx) error {
+			if claims != nil {
+				ctx.Locals(middleware.ClaimsKey, claims)
+			}
+			return ctx.Next()
+		})
+		app.Get("/sessions", sessionHandler.GetAll)
+		app.Delete("/sessions", sessionHandler.RevokeAll)
+		app.Delete("/sessions/:id", sessionHandler.Revoke)
+		return app
+	}
+
+	send := func(app *fiber.App, method string, path string) int {
+		response, err := app.Test(httptest.NewRequest(method, path, nil))
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	claims := &jwtModel.Claims{Id: 7, SessionId: "current"}
+
+	t.Run("without claims", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, send(newApp(mockService.NewSessionServiceMock(), nil), http.MethodGet, "/sessions"))
+		assert.Equal(t, http.StatusUnauthorized, send(newApp(mockService.NewSessionServiceMock(), nil), http.MethodDelete, "/sessions"))
+	})
+	t.Run("get all mark current session", func(t *testing.T) {
+		sessionService := mockService.NewSessionServiceMock()
+		sessionService.Mock.On("GetAll", mock.Anything, 7, "current").Return([]dto.SessionResponse{{Id: "current", Current: true}}, nil)
+
+		assert.Equal(t, http.StatusOK, send(newApp(sessionService, claims), http.MethodGet, "/sessions"))
+		sessionService.Mock.AssertExpectations(t)
+	})
+	t.Run("revoke session not found", func(t *testing.T) {
+		sessionService := mockService.NewSessionServiceMock()
+		sessionService.Mock.On("Revoke", mock.Anything, 7, "lain").Return(customError.New(customError.CodeSessionNotFound))
+
+		assert.Equal(t, http.StatusNotFound, send(newApp(sessionService, claims), http.MethodDelete, "/sessions/lain"))
+	})
+	t.Run("revoke all", func(t *testing.T) {
+		sessionService := mockService.NewSessionServiceMock()
+		sessionService.Mock.On("RevokeAll", mock.Anything, 7).Return(nil)
+
+		assert.Equal(t, http.StatusOK, send(newApp(sessionService, claims), http.MethodDelete, "/sessions"))
+		sessionService.Mock.AssertExpectations(t)
+	})
+}
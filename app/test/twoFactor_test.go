@@ -0,0 +1,350 @@
+package test
+
+import (
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/model/dto"
+	jwtModel "cobaMetrics/app/model/jwt"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mockService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/app/totp"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// unit test totp with test vector of RFC 6238 appendix B, truncated to 6 digits
+func TestTotp(t *testing.T) {
+	// base32 of ascii "12345678901234567890"
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	for unix, expected := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		code, err := totp.Code(secret, totp.Step(time.Unix(unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+
+	t.Run("validate with skew", func(t *testing.T) {
+		now := time.Unix(1111111111, 0)
+		previous, _ := totp.Code(secret, totp.Step(now)-1)
+		tooOld, _ := totp.Code(secret, totp.Step(now)-2)
+
+		step, ok := totp.Validate(secret, previous, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now)-1, step)
+
+		_, ok = totp.Validate(secret, tooOld, now, 1)
+		assert.False(t, ok)
+
+		_, ok = totp.Validate(secret, "12345", now, 1)
+		assert.False(t, ok)
+	})
+	t.Run("otpauth uri", func(t *testing.T) {
+		uri, err := url.Parse(totp.URI("cobaMetrics", "reoshby@gmail.com", secret))
+		assert.Nil(t, err)
+		assert.Equal(t, "otpauth", uri.Scheme)
+		assert.Equal(t, "totp", uri.Host)
+		assert.Equal(t, "/cobaMetrics:reoshby@gmail.com", uri.Path)
+		assert.Equal(t, secret, uri.Query().Get("secret"))
+		assert.Equal(t, "cobaMetrics", uri.Query().Get("issuer"))
+		assert.Equal(t, "6", uri.Query().Get("digits"))
+		assert.Equal(t, "30", uri.Query().Get("period"))
+	})
+	t.Run("generated secret usable", func(t *testing.T) {
+		generated, err := totp.GenerateSecret()
+		assert.Nil(t, err)
+		assert.Len(t, generated, 32)
+
+		code, err := totp.Code(generated, totp.Step(time.Now()))
+		assert.Nil(t, err)
+		assert.True(t, totp.IsCode(code))
+	})
+	t.Run("recovery code", func(t *testing.T) {
+		codes, err := totp.GenerateRecoveryCodes(10)
+		assert.Nil(t, err)
+		assert.Len(t, codes, 10)
+
+		pattern := regexp.MustCompile(`^[2-9a-z]{5}-[2-9a-z]{5}$`)
+		unique := map[string]bool{}
+		for _, code := range codes {
+			assert.Regexp(t, pattern, code)
+			assert.False(t, totp.IsCode(code))
+			unique[code] = true
+		}
+		assert.Len(t, unique, 10)
+
+		assert.Equal(t, "abcde23456", totp.NormalizeRecoveryCode(" ABCDE-23456"))
+	})
+}
+
+// integration test enroll, login and disable two factor with sqlite database
+func TestTwoFactorSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.TwoFactor = &config.TwoFactor{Issuer: "cobaMetrics", Skew: 1, PendingTTL: 5 * time.Minute, RecoveryCodes: 3}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "hashed").Return(true)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "salah123", "hashed").Return(false)
+	helperPasswordMock.Mock.On("NeedsRehash", "hashed").Return(false)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	sessionRepository := repository.NewSessionRepository(cluster, sqliteDialect, logging.Discard())
+	sessionService := service.NewSessionService(cfg, sessionRepository, logging.Discard())
+	txManager := transaction.NewTxManager(db)
+	validate := helper.NewValidator()
+
+	accountService := service.NewAccountService(txManager, validate, cfg, accountRepository, helperPasswordMock, newVerificationMock(), sessionService, logging.Discard())
+	twoFactorService := service.NewTwoFactorService(txManager, validate, cfg, accountRepository, repository.NewRecoveryCodeRepository(cluster, sqliteDialect, logging.Discard()), helperPasswordMock, sessionService, logging.Discard())
+	ctx := context.Background()
+
+	account, err := accountService.Add(ctx, &dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"})
+	assert.Nil(t, err)
+
+	login := func() *dto.LoginResponse {
+		response, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		assert.Nil(t, err)
+		return response
+	}
+
+	loginTwoFactor := func(mfaToken string, code string) (*dto.LoginResponse, string) {
+		response, err := twoFactorService.Login(ctx, &dto.TwoFactorLoginRequest{MfaToken: mfaToken, Code: code})
+		if err != nil {
+			return nil, customError.FromError(err).Code
+		}
+
+		return response, ""
+	}
+
+	codeAt := func(secret string, offset int64) string {
+		code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+		assert.Nil(t, err)
+		return code
+	}
+
+	var secret string
+	var recoveryCodes []string
+
+	t.Run("confirm before enroll", func(t *testing.T) {
+		_, err := twoFactorService.Confirm(ctx, account.Id, &dto.TwoFactorCodeRequest{Code: "123456"})
+		assert.Equal(t, customError.CodeTwoFactorNotEnrolled, customError.FromError(err).Code)
+	})
+	t.Run("enroll", func(t *testing.T) {
+		enroll, err := twoFactorService.Enroll(ctx, account.Id)
+		assert.Nil(t, err)
+		assert.Contains(t, enroll.OtpauthURI, "otpauth://totp/cobaMetrics:reoshby@gmail.com?")
+		secret = enroll.Secret
+
+		// not active before confirmed
+		assert.False(t, login().MfaRequired)
+	})
+	t.Run("confirm with wrong code", func(t *testing.T) {
+		_, err := twoFactorService.Confirm(ctx, account.Id, &dto.TwoFactorCodeRequest{Code: codeAt(secret, -5)})
+		assert.Equal(t, customError.CodeTwoFactorCodeInvalid, customError.FromError(err).Code)
+	})
+	t.Run("confirm", func(t *testing.T) {
+		response, err := twoFactorService.Confirm(ctx, account.Id, &dto.TwoFactorCodeRequest{Code: codeAt(secret, 0)})
+		assert.Nil(t, err)
+		assert.Len(t, response.RecoveryCodes, 3)
+		recoveryCodes = response.RecoveryCodes
+
+		_, err = twoFactorService.Enroll(ctx, account.Id)
+		assert.Equal(t, customError.CodeTwoFactorAlreadyEnabled, customError.FromError(err).Code)
+	})
+	t.Run("login need two factor", func(t *testing.T) {
+		response := login()
+		assert.True(t, response.MfaRequired)
+		assert.Empty(t, response.Token)
+
+		// mfa token rejected by auth middleware
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Get("/", middleware.UserAuthMiddleware(cfg, accountRepository, sessionRepository, logging.Discard()), func(ctx *fiber.Ctx) error {
+			return ctx.SendStatus(http.StatusOK)
+		})
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Add("Authorization", "Bearer "+response.MfaToken)
+		httpResponse, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, httpResponse.StatusCode)
+
+		// code used on confirm can not be used again
+		_, code := loginTwoFactor(response.MfaToken, codeAt(secret, 0))
+		assert.Equal(t, customError.CodeTwoFactorCodeInvalid, code)
+
+		_, code = loginTwoFactor("bukan-token", codeAt(secret, 1))
+		assert.Equal(t, customError.CodeAuthTokenInvalid, code)
+
+		success, code := loginTwoFactor(response.MfaToken, codeAt(secret, 1))
+		assert.Empty(t, code)
+		assert.NotEmpty(t, success.Token)
+
+		// access token can not be used as mfa token
+		_, code = loginTwoFactor(success.Token, recoveryCodes[0])
+		assert.Equal(t, customError.CodeAuthTokenInvalid, code)
+	})
+	t.Run("login with recovery code once", func(t *testing.T) {
+		mfaToken := login().MfaToken
+
+		success, code := loginTwoFactor(mfaToken, strings.ToUpper(recoveryCodes[0]))
+		assert.Empty(t, code)
+		assert.NotEmpty(t, success.Token)
+
+		_, code = loginTwoFactor(mfaToken, recoveryCodes[0])
+		assert.Equal(t, customError.CodeTwoFactorCodeInvalid, code)
+	})
+	t.Run("mfa token rejected after max attempts", func(t *testing.T) {
+		cfg.TwoFactor.MaxAttempts = 3
+		defer func() { cfg.TwoFactor.MaxAttempts = 0 }()
+
+		// parallel guess can not pass the limit
+		mfaToken := login().MfaToken
+		codes := make(chan string, 10)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, code := loginTwoFactor(mfaToken, codeAt(secret, -5))
+				codes <- code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		count := map[string]int{}
+		for code := range codes {
+			count[code]++
+		}
+		assert.Equal(t, map[string]int{customError.CodeTwoFactorCodeInvalid: 3, customError.CodeAuthTokenRevoked: 7}, count)
+
+		// new token get its own attempts
+		_, code := loginTwoFactor(login().MfaToken, codeAt(secret, -5))
+		assert.Equal(t, customError.CodeTwoFactorCodeInvalid, code)
+	})
+	t.Run("wrong code count toward lockout", func(t *testing.T) {
+		assert.Nil(t, accountRepository.UpdateLoginState(ctx, account.Id, 0, nil))
+		cfg.Lockout = &config.Lockout{Enabled: true, Threshold: 3, Duration: time.Minute}
+		defer func() {
+			cfg.Lockout = nil
+			assert.Nil(t, accountRepository.UpdateLoginState(ctx, account.Id, 0, nil))
+		}()
+
+		// password login not reset failed code before code valid
+		var mfaToken string
+		for i := 0; i < 3; i++ {
+			mfaToken = login().MfaToken
+			_, code := loginTwoFactor(mfaToken, codeAt(secret, -5))
+			assert.Equal(t, customError.CodeTwoFactorCodeInvalid, code)
+		}
+
+		_, code := loginTwoFactor(mfaToken, codeAt(secret, -5))
+		assert.Equal(t, customError.CodeAccountLocked, code)
+
+		_, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		assert.Equal(t, customError.CodeAccountLocked, customError.FromError(err).Code)
+
+		locked, err := accountRepository.GetById(ctx, account.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, locked.FailedLoginCount)
+		assert.True(t, locked.IsLocked(time.Now()))
+	})
+	t.Run("disable need password and code", func(t *testing.T) {
+		err := twoFactorService.Disable(ctx, account.Id, &dto.TwoFactorDisableRequest{Password: "salah123", Code: recoveryCodes[1]})
+		assert.Equal(t, customError.CodeAccountPasswordMismatch, customError.FromError(err).Code)
+
+		err = twoFactorService.Disable(ctx, account.Id, &dto.TwoFactorDisableRequest{Password: "123456", Code: "aaaaa-aaaaa"})
+		assert.Equal(t, customError.CodeTwoFactorCodeInvalid, customError.FromError(err).Code)
+
+		mfaToken := login().MfaToken
+		assert.Nil(t, twoFactorService.Disable(ctx, account.Id, &dto.TwoFactorDisableRequest{Password: "123456", Code: recoveryCodes[1]}))
+
+		// mfa token issued before disable no longer valid
+		_, code := loginTwoFactor(mfaToken, recoveryCodes[2])
+		assert.Equal(t, customError.CodeAuthTokenRevoked, code)
+
+		response := login()
+		assert.False(t, response.MfaRequired)
+		assert.NotEmpty(t, response.Token)
+
+		err = twoFactorService.Disable(ctx, account.Id, &dto.TwoFactorDisableRequest{Password: "123456", Code: recoveryCodes[2]})
+		assert.Equal(t, customError.CodeTwoFactorNotEnabled, customError.FromError(err).Code)
+	})
+}
+
+// unit test two factor handler
+func TestTwoFactorHandler(t *testing.T) {
+	newApp := func(twoFactorService *mockService.TwoFactorServiceMock, claims *jwtModel.Claims) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+
+		// set claims like AuthMiddleware
+		app.Use(func(ctx *fiber.Ctx) error {
+			if claims != nil {
+				ctx.Locals(middleware.ClaimsKey, claims)
+			}
+			return ctx.Next()
+		})
+		app.Post("/account/2fa/enroll", twoFactorHandler.Enroll)
+		app.Post("/account/2fa/confirm", twoFactorHandler.Confirm)
+		app.Post("/login/2fa", twoFactorHandler.Login)
+		return app
+	}
+
+	post := func(app *fiber.App, path string, body string) int {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Add("Content-Type", "application/json")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	t.Run("enroll without claims", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, post(newApp(mockService.NewTwoFactorServiceMock(), nil), "/account/2fa/enroll", ""))
+	})
+	t.Run("enroll use account of token", func(t *testing.T) {
+		twoFactorService := mockService.NewTwoFactorServiceMock()
+		twoFactorService.Mock.On("Enroll", mock.Anything, 7).Return(&dto.TwoFactorEnrollResponse{Secret: "ABC", OtpauthURI: "otpauth://totp/x"}, nil)
+
+		assert.Equal(t, http.StatusOK, post(newApp(twoFactorService, &jwtModel.Claims{Id: 7}), "/account/2fa/enroll", ""))
+		twoFactorService.Mock.AssertExpectations(t)
+	})
+	t.Run("confirm invalid code", func(t *testing.T) {
+		twoFactorService := mockService.NewTwoFactorServiceMock()
+		twoFactorService.Mock.On("Confirm", mock.Anything, 7, &dto.TwoFactorCodeRequest{Code: "000000"}).Return(nil, customError.New(customError.CodeTwoFactorCodeInvalid))
+
+		assert.Equal(t, http.StatusUnauthorized, post(newApp(twoFactorService, &jwtModel.Claims{Id: 7}), "/account/2fa/confirm", `{"code":"000000"}`))
+	})
+	t.Run("login two factor", func(t *testing.T) {
+		twoFactorService := mockService.NewTwoFactorServiceMock()
+		twoFactorService.Mock.On("Login", mock.Anything, &dto.TwoFactorLoginRequest{MfaToken: "mfa", Code: "123456", Client: dto.SessionClient{IP: "0.0.0.0"}}).Return(&dto.LoginResponse{Token: "token"}, nil)
+
+		assert.Equal(t, http.StatusOK, post(newApp(twoFactorService, nil), "/login/2fa", `{"mfa_token":"mfa","code":"123456"}`))
+		assert.Equal(t, http.StatusBadRequest, post(newApp(twoFactorService, nil), "/login/2fa", `{`))
+	})
+}
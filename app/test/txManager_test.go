@@ -0,0 +1,125 @@
+package test
+
+import (
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/model/dto"
+	"cobaMetrics/app/service"
+	mckConfig "cobaMetrics/app/test/mock/config"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mck "cobaMetrics/app/test/mock/repository"
+	"cobaMetrics/database/transaction"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// unit test transaction manager
+func TestWithinTx(t *testing.T) {
+	t.Run("commit when success", func(t *testing.T) {
+		db, dbMock, _ := sqlmock.New()
+		dbMock.ExpectBegin()
+		dbMock.ExpectCommit()
+
+		err := transaction.NewTxManager(db).WithinTx(context.Background(), nil, func(ctx context.Context) error {
+			_, ok := transaction.FromContext(ctx)
+			assert.True(t, ok)
+			return nil
+		})
+
+		assert.Nil(t, err)
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("rollback when error", func(t *testing.T) {
+		db, dbMock, _ := sqlmock.New()
+		dbMock.ExpectBegin()
+		dbMock.ExpectRollback()
+
+		errMessage := "record not found"
+		err := transaction.NewTxManager(db).WithinTx(context.Background(), nil, func(ctx context.Context) error {
+			return customError.NewNotFoundError(errMessage)
+		})
+
+		_, ok := err.(*customError.NotFoundError)
+		assert.True(t, ok)
+		assert.Equal(t, errMessage, err.Error())
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("rollback and panic again when panic", func(t *testing.T) {
+		db, dbMock, _ := sqlmock.New()
+		dbMock.ExpectBegin()
+		dbMock.ExpectRollback()
+
+		assert.PanicsWithValue(t, "boom", func() {
+			transaction.NewTxManager(db).WithinTx(context.Background(), nil, func(ctx context.Context) error {
+				panic("boom")
+			})
+		})
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("nested call join existing transaction", func(t *testing.T) {
+		db, dbMock, _ := sqlmock.New()
+		dbMock.ExpectBegin()
+		dbMock.ExpectCommit()
+
+		txManager := transaction.NewTxManager(db)
+		err := txManager.WithinTx(context.Background(), nil, func(ctx context.Context) error {
+			outer, _ := transaction.FromContext(ctx)
+			return txManager.WithinTx(ctx, nil, func(ctx context.Context) error {
+				inner, _ := transaction.FromContext(ctx)
+				assert.Same(t, outer, inner)
+				return nil
+			})
+		})
+
+		assert.Nil(t, err)
+		assert.Nil(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("error begin transaction", func(t *testing.T) {
+		db, dbMock, _ := sqlmock.New()
+		dbMock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+
+		called := false
+		err := transaction.NewTxManager(db).WithinTx(context.Background(), &sql.TxOptions{ReadOnly: true}, func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+
+		_, ok := err.(*customError.InternalServerError)
+		assert.True(t, ok)
+		assert.False(t, called)
+	})
+	t.Run("executor outside transaction is db", func(t *testing.T) {
+		db, _, _ := sqlmock.New()
+		assert.Equal(t, db, transaction.GetExecutor(context.Background(), db))
+	})
+}
+
+// unit test service when database down, before this return nil pointer panic
+func TestAddUserServiceDatabaseDown(t *testing.T) {
+	db, dbMock, _ := sqlmock.New()
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	accountRepositoryMock := mck.NewAccountRepository()
+	accountService := service.NewAccountService(transaction.NewTxManager(db), helper.NewValidator(), mckConfig.NewConfigMock(), accountRepositoryMock, helperPasswordMock, newVerificationMock(), newSessionMock(), logging.Discard())
+
+	// mock
+	dbMock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("123456", nil)
+
+	// test
+	result, err := accountService.Add(context.Background(), &dto.AddUserRequest{
+		Email:    "reoshby@gmail.com",
+		Username: "rshby",
+		Password: "123456",
+	})
+
+	_, ok := err.(*customError.InternalServerError)
+	assert.Nil(t, result)
+	assert.True(t, ok)
+	accountRepositoryMock.Mock.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+}
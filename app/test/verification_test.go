@@ -0,0 +1,198 @@
+package test
+
+import (
+	"bytes"
+	"cobaMetrics/app/config"
+	"cobaMetrics/app/customError"
+	"cobaMetrics/app/handler"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/mailer"
+	"cobaMetrics/app/model/dto"
+	jwtModel "cobaMetrics/app/model/jwt"
+	"cobaMetrics/app/repository"
+	"cobaMetrics/app/service"
+	mckHelper "cobaMetrics/app/test/mock/helper"
+	mockService "cobaMetrics/app/test/mock/service"
+	"cobaMetrics/database"
+	"cobaMetrics/database/transaction"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// integration test email verification after signup with sqlite database
+func TestEmailVerificationSQLite(t *testing.T) {
+	cfg := newSQLiteConfig(t)
+	cfg.EmailVerification = &config.EmailVerification{TokenTTL: time.Hour, URL: "http://localhost:5005/api/v1/account/verify"}
+	db, sqliteDialect := newSQLiteDB(t, cfg)
+
+	helperPasswordMock := mckHelper.NewHelperPasswordMock()
+	helperPasswordMock.Mock.On("HashPassword", mock.Anything).Return("hashed", nil)
+	helperPasswordMock.Mock.On("CheckPasswordHash", "123456", "hashed").Return(true)
+	helperPasswordMock.Mock.On("NeedsRehash", "hashed").Return(false)
+
+	cluster := database.NewCluster(db, nil, database.PolicyRoundRobin, 1, 0, logging.Discard())
+	accountRepository := repository.NewAccountRepository(cluster, sqliteDialect, logging.Discard())
+	txManager := transaction.NewTxManager(db)
+	validate := helper.NewValidator()
+	mailBuffer := &bytes.Buffer{}
+
+	verificationService := service.NewVerificationService(txManager, validate, cfg, accountRepository, repository.NewEmailVerificationRepository(cluster, sqliteDialect, logging.Discard()), mailer.NewLogMailer(mailBuffer, "noreply@coba-metrics.local", logging.Discard()), logging.Discard())
+	accountService := service.NewAccountService(txManager, validate, cfg, accountRepository, helperPasswordMock, verificationService, newSQLiteSessionService(cfg, cluster, sqliteDialect), logging.Discard())
+	ctx := context.Background()
+
+	tokenPattern := regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+	mailedToken := func() string {
+		match := tokenPattern.FindStringSubmatch(mailBuffer.String())
+		assert.Len(t, match, 2)
+		mailBuffer.Reset()
+		return match[1]
+	}
+
+	verify := func(token string) string {
+		if err := verificationService.Verify(ctx, token); err != nil {
+			return customError.FromError(err).Code
+		}
+
+		return ""
+	}
+
+	// login return email_verified claims, or error code when login refused
+	login := func() (bool, string) {
+		response, err := accountService.Login(ctx, &dto.LoginRequest{Email: "reoshby@gmail.com", Password: "123456"})
+		if err != nil {
+			return false, customError.FromError(err).Code
+		}
+
+		var claims jwtModel.Claims
+		_, err = jwt.ParseWithClaims(response.Token, &claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(cfg.Jwt.SecretKey), nil
+		})
+		assert.Nil(t, err)
+		return claims.EmailVerified, ""
+	}
+
+	_, err := accountService.Add(ctx, &dto.AddUserRequest{Email: "reoshby@gmail.com", Username: "rshby", Password: "123456"})
+	assert.Nil(t, err)
+	signupToken := mailedToken()
+
+	t.Run("new account not verified", func(t *testing.T) {
+		account, err := accountRepository.GetByEmail(ctx, "reoshby@gmail.com")
+		assert.Nil(t, err)
+		assert.False(t, account.IsVerified())
+
+		verified, code := login()
+		assert.Empty(t, code)
+		assert.False(t, verified)
+	})
+	t.Run("login refused when verification required", func(t *testing.T) {
+		cfg.EmailVerification.RequireVerified = true
+		defer func() { cfg.EmailVerification.RequireVerified = false }()
+
+		_, code := login()
+		assert.Equal(t, customError.CodeAccountNotVerified, code)
+	})
+	t.Run("unknown email not send mail", func(t *testing.T) {
+		assert.Nil(t, verificationService.Resend(ctx, &dto.ResendVerificationRequest{Email: "notfound@gmail.com"}))
+		verificationService.(*service.VerificationService).Wait()
+		assert.Zero(t, mailBuffer.Len())
+	})
+	t.Run("unverified account not wait mail sent", func(t *testing.T) {
+		writer := &blockingWriter{release: make(chan struct{})}
+		slowService := service.NewVerificationService(txManager, validate, cfg, accountRepository, repository.NewEmailVerificationRepository(cluster, sqliteDialect, logging.Discard()), mailer.NewLogMailer(writer, "noreply@coba-metrics.local", logging.Discard()), logging.Discard())
+
+		// request of next user reuse context, mail read only value copied before
+		requestCtx := &recycledContext{Context: ctx, values: map[any]any{logging.RequestIDKey: "req-1"}}
+		assert.Nil(t, slowService.Resend(requestCtx, &dto.ResendVerificationRequest{Email: "reoshby@gmail.com"}))
+		requestCtx.values[logging.RequestIDKey] = "req-2"
+
+		close(writer.release)
+		slowService.(*service.VerificationService).Wait()
+		assert.Contains(t, writer.String(), "token=")
+	})
+	t.Run("resend invalidate previous token", func(t *testing.T) {
+		assert.Nil(t, verificationService.Resend(ctx, &dto.ResendVerificationRequest{Email: "reoshby@gmail.com"}))
+		verificationService.(*service.VerificationService).Wait()
+		token := mailedToken()
+
+		assert.Equal(t, customError.CodeVerificationTokenInvalid, verify(signupToken))
+		assert.Equal(t, customError.CodeVerificationTokenInvalid, verify(""))
+		assert.Equal(t, customError.CodeVerificationTokenInvalid, verify("tidak-ada"))
+
+		assert.Empty(t, verify(token))
+		assert.Equal(t, customError.CodeVerificationTokenInvalid, verify(token))
+	})
+	t.Run("verified account", func(t *testing.T) {
+		cfg.EmailVerification.RequireVerified = true
+		defer func() { cfg.EmailVerification.RequireVerified = false }()
+
+		verified, code := login()
+		assert.Empty(t, code)
+		assert.True(t, verified)
+
+		// verified account not get new link
+		assert.Nil(t, verificationService.Resend(ctx, &dto.ResendVerificationRequest{Email: "reoshby@gmail.com"}))
+		verificationService.(*service.VerificationService).Wait()
+		assert.Zero(t, mailBuffer.Len())
+	})
+}
+
+// unit test verify and resend verification handler
+func TestVerificationHandler(t *testing.T) {
+	newApp := func(verificationService *mockService.VerificationServiceMock) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		verificationHandler := handler.NewVerificationHandler(verificationService)
+		app.Get("/account/verify", verificationHandler.Verify)
+		app.Post("/account/verify/resend", verificationHandler.Resend)
+		return app
+	}
+
+	t.Run("verify success", func(t *testing.T) {
+		verificationService := mockService.NewVerificationServiceMock()
+		verificationService.Mock.On("Verify", mock.Anything, "abc").Return(nil)
+
+		response, err := newApp(verificationService).Test(httptest.NewRequest(http.MethodGet, "/account/verify?token=abc", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		verificationService.Mock.AssertExpectations(t)
+	})
+	t.Run("verify invalid token", func(t *testing.T) {
+		verificationService := mockService.NewVerificationServiceMock()
+		verificationService.Mock.On("Verify", mock.Anything, "").Return(customError.New(customError.CodeVerificationTokenInvalid))
+
+		response, err := newApp(verificationService).Test(httptest.NewRequest(http.MethodGet, "/account/verify", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		body, _ := io.ReadAll(response.Body)
+		assert.Contains(t, string(body), "verification token not valid or expired")
+	})
+	t.Run("resend accepted", func(t *testing.T) {
+		verificationService := mockService.NewVerificationServiceMock()
+		verificationService.Mock.On("Resend", mock.Anything, &dto.ResendVerificationRequest{Email: "reoshby@gmail.com"}).Return(nil)
+
+		request := httptest.NewRequest(http.MethodPost, "/account/verify/resend", strings.NewReader(`{"email":"reoshby@gmail.com"}`))
+		request.Header.Add("Content-Type", "application/json")
+		response, err := newApp(verificationService).Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusAccepted, response.StatusCode)
+		verificationService.Mock.AssertExpectations(t)
+	})
+	t.Run("resend invalid body", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/account/verify/resend", strings.NewReader(`{`))
+		request.Header.Add("Content-Type", "application/json")
+		response, err := newApp(mockService.NewVerificationServiceMock()).Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}
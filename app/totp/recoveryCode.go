@@ -0,0 +1,51 @@
+package totp
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// recoveryAlphabet exclude character that easy to misread, like 0, o, 1 and l
+const recoveryAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// recoveryCodeLength is number of character of recovery code without separator
+const recoveryCodeLength = 10
+
+// recoveryByteLimit is biggest multiple of alphabet length not above 256, random byte from it taken without modulo bias
+const recoveryByteLimit = 256 - 256%len(recoveryAlphabet)
+
+// GenerateRecoveryCodes return n recovery code formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	bytes := make([]byte, recoveryCodeLength)
+	for i := 0; i < n; i++ {
+		code := make([]byte, 0, recoveryCodeLength)
+		for len(code) < recoveryCodeLength {
+			if _, err := rand.Read(bytes); err != nil {
+				return nil, err
+			}
+
+			// byte from limit up discarded, otherwise first 256%31 character more likely chosen
+			for _, b := range bytes {
+				if int(b) < recoveryByteLimit && len(code) < recoveryCodeLength {
+					code = append(code, recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+				}
+			}
+		}
+
+		codes = append(codes, string(code[:recoveryCodeLength/2])+"-"+string(code[recoveryCodeLength/2:]))
+	}
+
+	return codes, nil
+}
+
+// NormalizeRecoveryCode remove separator and space and lower the case, so code typed by user match stored hash
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToLower(code))
+}
@@ -3,14 +3,15 @@ package tracing
 import (
 	"cobaMetrics/app/config"
 	"fmt"
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-client-go"
 	jaegerConfig "github.com/uber/jaeger-client-go/config"
 	"io"
+	"log/slog"
+	"os"
 )
 
-func NewJaegerTracing(config config.IConfig) (opentracing.Tracer, io.Closer) {
+func NewJaegerTracing(config config.IConfig, logger *slog.Logger) (opentracing.Tracer, io.Closer) {
 	jaegerCfg := jaegerConfig.Configuration{
 		ServiceName: config.Config().Jaeger.ServiceName,
 		Sampler: &jaegerConfig.SamplerConfig{
@@ -25,7 +26,8 @@ func NewJaegerTracing(config config.IConfig) (opentracing.Tracer, io.Closer) {
 
 	tracer, closer, err := jaegerCfg.NewTracer(jaegerConfig.Logger(jaeger.StdLogger))
 	if err != nil {
-		log.Fatalf("cant connect to jaeger : %v", err)
+		logger.Error("cant connect to jaeger", slog.String("error", err.Error()))
+		os.Exit(1)
 	}
 
 	return tracer, closer
@@ -0,0 +1,22 @@
+package tracing
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+type contextKey string
+
+// SpanKey is key of root span of request in fiber Locals
+const SpanKey contextKey = "request_span"
+
+// StartSpanFromRequest start span as child of root span of request, so every span of one request in the same trace
+func StartSpanFromRequest(ctx *fiber.Ctx, operationName string) (opentracing.Span, context.Context) {
+	if parent, ok := ctx.Locals(SpanKey).(opentracing.Span); ok {
+		span := opentracing.StartSpan(operationName, opentracing.ChildOf(parent.Context()))
+		return span, opentracing.ContextWithSpan(ctx.Context(), span)
+	}
+
+	return opentracing.StartSpanFromContext(ctx.Context(), operationName)
+}
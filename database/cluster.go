@@ -0,0 +1,267 @@
+package database
+
+import (
+	"cobaMetrics/app/config"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	PolicyRoundRobin       = "round_robin"
+	PolicyLeastConnections = "least_connections"
+)
+
+type contextKey string
+
+// CallerKey is key of caller identity in context, used to pin caller to primary after write
+const CallerKey contextKey = "database_caller"
+
+const primaryKey contextKey = "database_primary"
+
+// ReadPrimary return context that read from primary, for read that must not be stale like login state
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, true)
+}
+
+type replica struct {
+	DB       *sql.DB
+	Name     string
+	healthy  atomic.Bool
+	failures int
+}
+
+// Cluster route write and read in transaction to primary, other read to healthy replica
+type Cluster struct {
+	Primary     *sql.DB
+	replicas    []*replica
+	policy      string
+	maxFailures int
+	window      time.Duration
+	counter     atomic.Uint64
+	lastWrite   sync.Map
+	lastPrune   atomic.Int64
+	stop        chan struct{}
+	stopOnce    sync.Once
+	logger      *slog.Logger
+}
+
+// function provider
+func NewCluster(primary *sql.DB, replicas []*sql.DB, policy string, maxFailures int, readYourWritesWindow time.Duration, logger *slog.Logger) *Cluster {
+	cluster := &Cluster{
+		Primary:     primary,
+		logger:      logger,
+		policy:      policy,
+		maxFailures: maxFailures,
+		window:      readYourWritesWindow,
+		stop:        make(chan struct{}),
+	}
+
+	for i, db := range replicas {
+		node := &replica{DB: db, Name: fmt.Sprintf("replica-%v", i)}
+		node.healthy.Store(true)
+		cluster.replicas = append(cluster.replicas, node)
+	}
+
+	return cluster
+}
+
+// ConnectCluster connect to every replica in config, replica that not ready ejected until health check success
+func ConnectCluster(config config.IConfig, primary *sql.DB, logger *slog.Logger) *Cluster {
+	dbConfig := config.Config().Database
+	dbDialect, err := NewDialect(config)
+	if err != nil {
+		fatal(logger, "cant connect to replica", err)
+	}
+
+	var replicas []*sql.DB
+	for _, replicaConfig := range dbConfig.Replicas {
+		replicaDBConfig := *dbConfig
+		replicaDBConfig.Host = replicaConfig.Host
+		replicaDBConfig.Port = replicaConfig.Port
+		if replicaConfig.User != "" {
+			replicaDBConfig.User = replicaConfig.User
+			replicaDBConfig.Password = replicaConfig.Password
+		}
+
+		db, err := sql.Open(dbDialect.DriverName(), dbDialect.DSN(&replicaDBConfig))
+		if err != nil {
+			logger.Error("cant connect to replica",
+				slog.String("host", replicaConfig.Host),
+				slog.Int("port", replicaConfig.Port),
+				slog.String("error", err.Error()))
+			continue
+		}
+
+		db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+		db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+		db.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+		db.SetConnMaxIdleTime(dbConfig.ConnMaxIdleTime)
+
+		replicas = append(replicas, db)
+	}
+
+	cluster := NewCluster(primary, replicas, dbConfig.ReplicaPolicy, dbConfig.ReplicaMaxFailures, dbConfig.ReadYourWritesWindow, logger)
+	if len(replicas) > 0 {
+		cluster.CheckReady(context.Background(), dbConfig.PingTimeout)
+		go cluster.RunHealthCheck(dbConfig.ReplicaHealthCheckInterval, dbConfig.PingTimeout)
+		logger.Info("success connect database replica", slog.Int("replicas", len(replicas)))
+	}
+
+	return cluster
+}
+
+// Reader return db for read query, primary when caller pinned, context from ReadPrimary or no healthy replica
+func (c *Cluster) Reader(ctx context.Context) *sql.DB {
+	if primary, _ := ctx.Value(primaryKey).(bool); primary || len(c.replicas) == 0 || c.pinned(ctx) {
+		return c.Primary
+	}
+
+	var healthy []*replica
+	for _, node := range c.replicas {
+		if node.healthy.Load() {
+			healthy = append(healthy, node)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return c.Primary
+	}
+
+	if c.policy == PolicyLeastConnections {
+		selected := healthy[0]
+		for _, node := range healthy[1:] {
+			if node.DB.Stats().InUse < selected.DB.Stats().InUse {
+				selected = node
+			}
+		}
+
+		return selected.DB
+	}
+
+	index := c.counter.Add(1) - 1
+	return healthy[index%uint64(len(healthy))].DB
+}
+
+// Writer return primary db
+func (c *Cluster) Writer() *sql.DB {
+	return c.Primary
+}
+
+// MarkWrite pin caller in ctx to primary during read your writes window, nothing to pin without replica.
+// expired pin removed at most once per window, so map only hold caller that write in the last window
+func (c *Cluster) MarkWrite(ctx context.Context) {
+	caller, ok := ctx.Value(CallerKey).(string)
+	if !ok || caller == "" || c.window <= 0 || len(c.replicas) == 0 {
+		return
+	}
+
+	now := time.Now()
+	c.lastWrite.Store(caller, now)
+
+	last := c.lastPrune.Load()
+	if now.Sub(time.Unix(0, last)) > c.window && c.lastPrune.CompareAndSwap(last, now.UnixNano()) {
+		c.pruneExpired()
+	}
+}
+
+// pruneExpired remove pin older than read your writes window
+func (c *Cluster) pruneExpired() {
+	c.lastWrite.Range(func(key, value any) bool {
+		if time.Since(value.(time.Time)) > c.window {
+			c.lastWrite.Delete(key)
+		}
+		return true
+	})
+}
+
+func (c *Cluster) pinned(ctx context.Context) bool {
+	caller, ok := ctx.Value(CallerKey).(string)
+	if !ok || caller == "" {
+		return false
+	}
+
+	value, ok := c.lastWrite.Load(caller)
+	if !ok {
+		return false
+	}
+
+	if time.Since(value.(time.Time)) > c.window {
+		c.lastWrite.Delete(caller)
+		return false
+	}
+
+	return true
+}
+
+// CheckReady ping every replica once at startup, replica that not ready ejected immediately
+// so read not routed to it while waiting for max failures of health check
+func (c *Cluster) CheckReady(ctx context.Context, timeout time.Duration) {
+	for _, node := range c.replicas {
+		if err := ping(ctx, node.DB, timeout); err != nil {
+			c.logger.WarnContext(ctx, "replica not ready, ejected", slog.String("replica", node.Name), slog.String("error", err.Error()))
+			node.failures = c.maxFailures
+			node.healthy.Store(false)
+		}
+	}
+}
+
+// CheckHealth ping every replica, eject replica after max failures and bring back when ping success
+func (c *Cluster) CheckHealth(ctx context.Context, timeout time.Duration) {
+	for _, node := range c.replicas {
+		err := ping(ctx, node.DB, timeout)
+		if err == nil {
+			if !node.healthy.Load() {
+				c.logger.InfoContext(ctx, "replica healthy again", slog.String("replica", node.Name))
+			}
+
+			node.failures = 0
+			node.healthy.Store(true)
+			continue
+		}
+
+		node.failures++
+		if node.failures >= c.maxFailures && node.healthy.Load() {
+			c.logger.WarnContext(ctx, "replica ejected", slog.String("replica", node.Name), slog.String("error", err.Error()))
+			node.healthy.Store(false)
+		}
+	}
+
+	c.pruneExpired()
+}
+
+func ping(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return db.PingContext(pingCtx)
+}
+
+// RunHealthCheck run CheckHealth every interval until Close called
+func (c *Cluster) RunHealthCheck(interval time.Duration, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.CheckHealth(context.Background(), timeout)
+		}
+	}
+}
+
+// Close stop health check and close every replica connection
+func (c *Cluster) Close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	for _, node := range c.replicas {
+		node.DB.Close()
+	}
+
+	return nil
+}
@@ -2,30 +2,84 @@ package database
 
 import (
 	"cobaMetrics/app/config"
+	"cobaMetrics/database/dialect"
+	"context"
 	"database/sql"
 	"fmt"
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/gofiber/fiber/v2/log"
+	"log/slog"
+	"os"
 	"time"
 )
 
-func ConnectDB(config config.IConfig) *sql.DB {
+// ConnectDB open connection pool to primary database, exit when database not ready
+func ConnectDB(config config.IConfig, logger *slog.Logger) *sql.DB {
 	dbConfig := config.Config().Database
+	dbDialect, err := NewDialect(config)
+	if err != nil {
+		fatal(logger, "cant connect to db", err)
+	}
 
-	dsn := fmt.Sprintf("%v:%v@tcp(%v:%v)/%v?charset=utf8mb4&parseTime=True&loc=Local",
-		dbConfig.User, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Name)
-
-	db, err := sql.Open("mysql", dsn)
+	db, err := sql.Open(dbDialect.DriverName(), dbDialect.DSN(dbConfig))
 	if err != nil {
-		log.Fatalf("cant connect to db : %v", err)
+		fatal(logger, "cant connect to db", err)
 	}
 
-	db.SetMaxOpenConns(50)
-	db.SetMaxIdleConns(30)
-	db.SetConnMaxLifetime(1 * time.Hour)
-	db.SetConnMaxIdleTime(30 * time.Minute)
+	db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(dbConfig.ConnMaxIdleTime)
+
+	// sql.Open not create connection, make sure database is ready
+	if err = PingWithRetry(context.Background(), db, dbConfig, logger); err != nil {
+		fatal(logger, "cant connect to db", err)
+	}
 
-	log.Info("success connect database")
+	logger.Info("success connect database", slog.String("driver", dbDialect.Name()))
 
 	return db
 }
+
+// NewDialect return dialect of configured database driver
+func NewDialect(config config.IConfig) (dialect.Dialect, error) {
+	return dialect.New(config.Config().Database.Driver)
+}
+
+// minRetryWait is shortest wait between ping, wait 0 in config would ping database without pause
+const minRetryWait = 10 * time.Millisecond
+
+// PingWithRetry ping database until success, wait with exponential backoff between attempt
+func PingWithRetry(ctx context.Context, db *sql.DB, dbConfig *config.Database, logger *slog.Logger) error {
+	deadline := time.Now().Add(dbConfig.RetryMaxElapsedTime)
+	wait := max(dbConfig.RetryInitialWait, minRetryWait)
+
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, dbConfig.PingTimeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("database not ready after %v attempt : %w", attempt, err)
+		}
+
+		logger.WarnContext(ctx, "database not ready",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", wait),
+			slog.String("error", err.Error()))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		wait = max(min(wait*2, dbConfig.RetryMaxWait), minRetryWait)
+	}
+}
+
+func fatal(logger *slog.Logger, message string, err error) {
+	logger.Error(message, slog.String("error", err.Error()))
+	os.Exit(1)
+}
@@ -0,0 +1,80 @@
+package dialect
+
+import (
+	"cobaMetrics/app/config"
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+
+	// name of lock held while migration running
+	lockName = "schema_migrations"
+)
+
+// Dialect hold difference of sql between database engine
+type Dialect interface {
+	// Name return dialect name, also used as migration directory
+	Name() string
+	// DriverName return driver name registered in database/sql
+	DriverName() string
+	// DSN create data source name from config
+	DSN(dbConfig *config.Database) string
+	// Rebind convert ? placeholder into placeholder of this dialect
+	Rebind(query string) string
+	// SupportReturning is true when insert must use RETURNING to get generated id
+	SupportReturning() bool
+	// SupportFullText is true when accounts has FULLTEXT index for search, other dialect search with LIKE
+	SupportFullText() bool
+	// SupportTransactionalDDL is true when DDL rolled back with transaction, mysql commit every DDL implicitly
+	SupportTransactionalDDL() bool
+	// Time convert time into argument comparable with timestamp column filled by CURRENT_TIMESTAMP
+	Time(t time.Time) any
+	// Lock and Unlock hold migration lock in session of conn
+	Lock(ctx context.Context, conn *sql.Conn) error
+	Unlock(ctx context.Context, conn *sql.Conn) error
+}
+
+// New return dialect by name, default is mysql
+func New(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "", MySQL:
+		return &mysqlDialect{}, nil
+	case Postgres, "postgresql":
+		return &postgresDialect{}, nil
+	case SQLite, "sqlite3":
+		return &sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("database driver %v not supported", name)
+	}
+}
+
+// rebindDollar replace ? with $1, $2, ... except inside quoted string
+func rebindDollar(query string) string {
+	var builder strings.Builder
+	builder.Grow(len(query) + 10)
+
+	index := 0
+	inQuote := false
+	for _, char := range query {
+		switch {
+		case char == '\'':
+			inQuote = !inQuote
+			builder.WriteRune(char)
+		case char == '?' && !inQuote:
+			index++
+			builder.WriteString("$" + strconv.Itoa(index))
+		default:
+			builder.WriteRune(char)
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,80 @@
+package dialect
+
+import (
+	"cobaMetrics/app/config"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"time"
+)
+
+type mysqlDialect struct {
+}
+
+func (m *mysqlDialect) Name() string {
+	return MySQL
+}
+
+func (m *mysqlDialect) DriverName() string {
+	return "mysql"
+}
+
+func (m *mysqlDialect) DSN(dbConfig *config.Database) string {
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.User = dbConfig.User
+	mysqlConfig.Passwd = dbConfig.Password
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = fmt.Sprintf("%v:%v", dbConfig.Host, dbConfig.Port)
+	mysqlConfig.DBName = dbConfig.Name
+	mysqlConfig.Params = map[string]string{"charset": "utf8mb4"}
+	mysqlConfig.Collation = dbConfig.Collation
+	mysqlConfig.ParseTime = true
+	mysqlConfig.Loc = time.Local
+	mysqlConfig.TLSConfig = dbConfig.Tls
+	mysqlConfig.Timeout = dbConfig.Timeout
+	mysqlConfig.ReadTimeout = dbConfig.ReadTimeout
+	mysqlConfig.WriteTimeout = dbConfig.WriteTimeout
+
+	return mysqlConfig.FormatDSN()
+}
+
+func (m *mysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (m *mysqlDialect) SupportReturning() bool {
+	return false
+}
+
+func (m *mysqlDialect) SupportFullText() bool {
+	return true
+}
+
+func (m *mysqlDialect) SupportTransactionalDDL() bool {
+	return false
+}
+
+// driver format time in location of dsn, same as value read back
+func (m *mysqlDialect) Time(t time.Time) any {
+	return t
+}
+
+func (m *mysqlDialect) Lock(ctx context.Context, conn *sql.Conn) error {
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, 30).Scan(&locked); err != nil {
+		return err
+	}
+
+	if !locked.Valid || locked.Int64 != 1 {
+		return errors.New("failed to acquire migration lock, another migration is running")
+	}
+
+	return nil
+}
+
+func (m *mysqlDialect) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+	return err
+}
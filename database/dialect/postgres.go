@@ -0,0 +1,84 @@
+package dialect
+
+import (
+	"cobaMetrics/app/config"
+	"context"
+	"database/sql"
+	"fmt"
+	_ "github.com/lib/pq"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// key of advisory lock used by migration
+const postgresLockKey = 7_265_812_001
+
+type postgresDialect struct {
+}
+
+func (p *postgresDialect) Name() string {
+	return Postgres
+}
+
+func (p *postgresDialect) DriverName() string {
+	return "postgres"
+}
+
+func (p *postgresDialect) DSN(dbConfig *config.Database) string {
+	// tls config use mysql value, convert to postgres sslmode
+	sslMode := "disable"
+	switch dbConfig.Tls {
+	case "true":
+		sslMode = "verify-full"
+	case "skip-verify", "preferred":
+		sslMode = "require"
+	}
+
+	query := url.Values{}
+	query.Set("sslmode", sslMode)
+	if dbConfig.Timeout > 0 {
+		query.Set("connect_timeout", strconv.Itoa(int(dbConfig.Timeout.Seconds())))
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(dbConfig.User, dbConfig.Password),
+		Host:     fmt.Sprintf("%v:%v", dbConfig.Host, dbConfig.Port),
+		Path:     dbConfig.Name,
+		RawQuery: query.Encode(),
+	}
+
+	return dsn.String()
+}
+
+func (p *postgresDialect) Rebind(query string) string {
+	return rebindDollar(query)
+}
+
+func (p *postgresDialect) SupportReturning() bool {
+	return true
+}
+
+func (p *postgresDialect) SupportFullText() bool {
+	return false
+}
+
+func (p *postgresDialect) SupportTransactionalDDL() bool {
+	return true
+}
+
+func (p *postgresDialect) Time(t time.Time) any {
+	return t
+}
+
+// Lock wait until advisory lock acquired or ctx done
+func (p *postgresDialect) Lock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresLockKey)
+	return err
+}
+
+func (p *postgresDialect) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", postgresLockKey)
+	return err
+}
@@ -0,0 +1,68 @@
+package dialect
+
+import (
+	"cobaMetrics/app/config"
+	"context"
+	"database/sql"
+	"fmt"
+	_ "modernc.org/sqlite"
+	"strings"
+	"time"
+)
+
+type sqliteDialect struct {
+}
+
+func (s *sqliteDialect) Name() string {
+	return SQLite
+}
+
+func (s *sqliteDialect) DriverName() string {
+	return "sqlite"
+}
+
+// DSN use database name as file path, ":memory:" for in memory database
+func (s *sqliteDialect) DSN(dbConfig *config.Database) string {
+	path := dbConfig.Name
+	if path == ":memory:" {
+		path = "file::memory:?cache=shared"
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	busyTimeout := dbConfig.Timeout.Milliseconds()
+	return fmt.Sprintf("%v%v_pragma=foreign_keys(1)&_pragma=busy_timeout(%v)&_time_format=sqlite", path, separator, busyTimeout)
+}
+
+func (s *sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (s *sqliteDialect) SupportReturning() bool {
+	return false
+}
+
+func (s *sqliteDialect) SupportFullText() bool {
+	return false
+}
+
+func (s *sqliteDialect) SupportTransactionalDDL() bool {
+	return true
+}
+
+// CURRENT_TIMESTAMP stored as text in utc without fraction and zone, time compared as text
+func (s *sqliteDialect) Time(t time.Time) any {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// sqlite lock whole database file when write, migration lock not needed
+func (s *sqliteDialect) Lock(ctx context.Context, conn *sql.Conn) error {
+	return nil
+}
+
+func (s *sqliteDialect) Unlock(ctx context.Context, conn *sql.Conn) error {
+	return nil
+}
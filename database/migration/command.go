@@ -0,0 +1,98 @@
+package migration
+
+import (
+	"cobaMetrics/database/dialect"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+)
+
+// Dir is location of migration source file, relative from project root
+const Dir = "database/migration/sql"
+
+const usage = "usage : migrate up | down [n] | status | create <name>"
+
+// RunCommand execute migrate subcommand, connect only called when command need database
+func RunCommand(ctx context.Context, args []string, dbDialect dialect.Dialect, connect func() *sql.DB, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+
+		// every dialect has own migration file with same version
+		for _, dialectName := range []string{dialect.MySQL, dialect.Postgres, dialect.SQLite} {
+			files, err := Create(filepath.Join(Dir, dialectName), args[1])
+			if err != nil {
+				return err
+			}
+
+			for _, file := range files {
+				fmt.Fprintf(out, "created %v\n", file)
+			}
+		}
+
+		return nil
+	}
+
+	migrations, err := Embedded(dbDialect.Name())
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		migrator := NewMigrator(connect(), dbDialect, migrations)
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %04d_%v\n", migration.Version, migration.Name)
+		}
+
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migration")
+		}
+
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errors.New("step must be positive number")
+			}
+		}
+
+		migrator := NewMigrator(connect(), dbDialect, migrations)
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, migration := range rolledBack {
+			fmt.Fprintf(out, "rolled back %04d_%v\n", migration.Version, migration.Name)
+		}
+
+		return err
+	case "status":
+		migrator := NewMigrator(connect(), dbDialect, migrations)
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			appliedAt := "-"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(out, "%04d_%-40v %-10v %v\n", status.Version, status.Name, status.Status, appliedAt)
+		}
+
+		return nil
+	default:
+		return errors.New(usage)
+	}
+}
@@ -0,0 +1,39 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Create write new empty up and down migration file into dir with next version
+func Create(dir string, name string) ([]string, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if !fileNamePattern.MatchString(fmt.Sprintf("1_%v.up.sql", name)) {
+		return nil, fmt.Errorf("invalid migration name %v, use lowercase letter, number and underscore", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%04d_%v.%v.sql", version, name, direction))
+		content := fmt.Sprintf("-- %v migration %04d_%v\n", direction, version, name)
+		if err = os.WriteFile(file, []byte(content), 0644); err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,129 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed sql
+var migrationFS embed.FS
+
+// file name format : <version>_<name>.<up|down>.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Embedded return migrations of dialect embedded in binary
+func Embedded(dialectName string) ([]Migration, error) {
+	sub, err := fs.Sub(migrationFS, path.Join("sql", dialectName))
+	if err != nil {
+		return nil, err
+	}
+
+	return Load(sub)
+}
+
+// Load read all migration file in root of fsys, sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %v", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Clean(entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrations[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrations[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %v : %v and %v", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("migration %v_%v has no up file", migration.Version, migration.Name)
+		}
+
+		if strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migration %v_%v has no down file", migration.Version, migration.Name)
+		}
+
+		migration.Checksum = Checksum(migration.Up)
+		result = append(result, *migration)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// Checksum return sha256 of migration script, used to detect applied migration that has been edited
+func Checksum(script string) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(script, "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitStatements split script into single statements, driver not allowed multi statements in one exec
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(script, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var statements []string
+	script = strings.Join(lines, "\n")
+	for _, statement := range strings.Split(script, ";\n") {
+		statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+
+		statements = append(statements, statement)
+	}
+
+	return statements
+}
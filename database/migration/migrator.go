@@ -0,0 +1,335 @@
+package migration
+
+import (
+	"cobaMetrics/database/dialect"
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	StatusApplied    = "applied"
+	StatusPending    = "pending"
+	StatusModified   = "modified"
+	StatusMissingSQL = "missing"
+	StatusDirty      = "dirty"
+)
+
+// dirtyChecksum stored as checksum of migration that started but not finished on dialect without transactional DDL.
+// schema of it may be half applied, must be fixed by hand and the row deleted before migrate run again
+const dirtyChecksum = "dirty"
+
+// execer is connection or transaction that migration statement run in
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type Migrator struct {
+	DB         *sql.DB
+	Dialect    dialect.Dialect
+	Migrations []Migration
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Status    string
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// function provider
+func NewMigrator(db *sql.DB, dbDialect dialect.Dialect, migrations []Migration) *Migrator {
+	return &Migrator{
+		DB:         db,
+		Dialect:    dbDialect,
+		Migrations: migrations,
+	}
+}
+
+// Up apply all pending migration, return migration that applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		history, err := m.history(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err = m.verify(history); err != nil {
+			return err
+		}
+
+		for _, migration := range m.Migrations {
+			if _, ok := history[migration.Version]; ok {
+				continue
+			}
+
+			if err = m.apply(ctx, conn, migration); err != nil {
+				return fmt.Errorf("failed to apply migration %v_%v : %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rollback last n applied migration, return migration that rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		history, err := m.history(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err = m.checkDirty(history); err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(history))
+		for version := range history {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if len(rolledBack) >= steps {
+				break
+			}
+
+			migration, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("migration %v_%v applied but file not found", version, history[version].Name)
+			}
+
+			if err = m.rollback(ctx, conn, migration); err != nil {
+				return fmt.Errorf("failed to rollback migration %v_%v : %w", migration.Version, migration.Name, err)
+			}
+
+			rolledBack = append(rolledBack, migration)
+		}
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status return state of every known migration, include applied migration that file not found
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	history, err := m.history(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.Migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Status: StatusPending}
+		if applied, ok := history[migration.Version]; ok {
+			status.Status = StatusApplied
+			status.AppliedAt = &applied.AppliedAt
+			if applied.Checksum == dirtyChecksum {
+				status.Status = StatusDirty
+			} else if applied.Checksum != migration.Checksum {
+				status.Status = StatusModified
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for version, applied := range history {
+		if _, ok := m.find(version); !ok {
+			appliedAt := applied.AppliedAt
+			statuses = append(statuses, MigrationStatus{Version: version, Name: applied.Name, Status: StatusMissingSQL, AppliedAt: &appliedAt})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// withLock hold named lock in database during fn, prevent migration run concurrently
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err = m.Dialect.Lock(ctx, conn); err != nil {
+		return err
+	}
+	defer m.Dialect.Unlock(context.Background(), conn)
+
+	return fn(conn)
+}
+
+// history create schema_migrations if not exist then return applied migration by version
+func (m *Migrator) history(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := map[int64]appliedMigration{}
+	for rows.Next() {
+		var applied appliedMigration
+		if err = rows.Scan(&applied.Version, &applied.Name, &applied.Checksum, &applied.AppliedAt); err != nil {
+			return nil, err
+		}
+
+		history[applied.Version] = applied
+	}
+
+	return history, rows.Err()
+}
+
+// apply run up script of migration and record it in schema_migrations.
+// with transactional DDL both run in one transaction, failed migration leave nothing behind.
+// mysql commit each DDL implicitly, so migration recorded dirty before run and marked clean after,
+// failure in the middle keep the dirty row so half applied version visible in status
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	insert := m.Dialect.Rebind("INSERT INTO schema_migrations(version, name, checksum, applied_at) VALUES (?, ?, ?, ?)")
+	if m.Dialect.SupportTransactionalDDL() {
+		return m.withTx(ctx, conn, func(tx *sql.Tx) error {
+			if err := m.exec(ctx, tx, migration.Up); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, insert, migration.Version, migration.Name, migration.Checksum, time.Now())
+			return err
+		})
+	}
+
+	if _, err := conn.ExecContext(ctx, insert, migration.Version, migration.Name, dirtyChecksum, time.Now()); err != nil {
+		return err
+	}
+
+	if err := m.exec(ctx, conn, migration.Up); err != nil {
+		return fmt.Errorf("%w, version marked dirty in schema_migrations", err)
+	}
+
+	_, err := conn.ExecContext(ctx, m.Dialect.Rebind("UPDATE schema_migrations SET checksum = ? WHERE version = ?"), migration.Checksum, migration.Version)
+	return err
+}
+
+// rollback run down script of migration and remove it from schema_migrations, same transaction rule as apply
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	remove := m.Dialect.Rebind("DELETE FROM schema_migrations WHERE version = ?")
+	if m.Dialect.SupportTransactionalDDL() {
+		return m.withTx(ctx, conn, func(tx *sql.Tx) error {
+			if err := m.exec(ctx, tx, migration.Down); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, remove, migration.Version)
+			return err
+		})
+	}
+
+	if _, err := conn.ExecContext(ctx, m.Dialect.Rebind("UPDATE schema_migrations SET checksum = ? WHERE version = ?"), dirtyChecksum, migration.Version); err != nil {
+		return err
+	}
+
+	if err := m.exec(ctx, conn, migration.Down); err != nil {
+		return fmt.Errorf("%w, version marked dirty in schema_migrations", err)
+	}
+
+	_, err := conn.ExecContext(ctx, remove, migration.Version)
+	return err
+}
+
+func (m *Migrator) withTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkDirty refuse to migrate while migration left half applied
+func (m *Migrator) checkDirty(history map[int64]appliedMigration) error {
+	for version, applied := range history {
+		if applied.Checksum == dirtyChecksum {
+			return fmt.Errorf("migration %v_%v is dirty, it failed in the middle and schema may be half applied. fix schema by hand then delete version %v from schema_migrations", version, applied.Name, version)
+		}
+	}
+
+	return nil
+}
+
+// verify make sure no migration dirty and applied migration not edited after applied
+func (m *Migrator) verify(history map[int64]appliedMigration) error {
+	if err := m.checkDirty(history); err != nil {
+		return err
+	}
+
+	for _, migration := range m.Migrations {
+		applied, ok := history[migration.Version]
+		if ok && applied.Checksum != migration.Checksum {
+			return fmt.Errorf("checksum mismatch on migration %v_%v, applied migration must not be edited", migration.Version, migration.Name)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) exec(ctx context.Context, conn execer, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.Migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS password_resets;
+
+ALTER TABLE accounts DROP COLUMN token_version;
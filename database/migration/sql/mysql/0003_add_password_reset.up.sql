@@ -0,0 +1,12 @@
+ALTER TABLE accounts ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS password_resets (
+    id INT NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    account_id INT NOT NULL ,
+    token_hash VARCHAR(64) NOT NULL UNIQUE ,
+    expires_at TIMESTAMP NOT NULL ,
+    used_at TIMESTAMP NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_password_resets_account_id (account_id),
+    CONSTRAINT fk_password_resets_account FOREIGN KEY (account_id) REFERENCES accounts (id) ON DELETE CASCADE
+)engine = InnoDB;
@@ -0,0 +1,15 @@
+ALTER TABLE accounts ADD COLUMN verified_at TIMESTAMP NULL;
+
+-- existing account treated as verified, updated_at kept because it is sort key of account list
+UPDATE accounts SET verified_at = created_at, updated_at = updated_at;
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id INT NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    account_id INT NOT NULL ,
+    token_hash VARCHAR(64) NOT NULL UNIQUE ,
+    expires_at TIMESTAMP NOT NULL ,
+    used_at TIMESTAMP NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_email_verifications_account_id (account_id),
+    CONSTRAINT fk_email_verifications_account FOREIGN KEY (account_id) REFERENCES accounts (id) ON DELETE CASCADE
+)engine = InnoDB;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE accounts DROP COLUMN totp_last_step;
+
+ALTER TABLE accounts DROP COLUMN totp_enabled;
+
+ALTER TABLE accounts DROP COLUMN totp_secret;
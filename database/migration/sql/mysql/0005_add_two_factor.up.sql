@@ -0,0 +1,15 @@
+ALTER TABLE accounts ADD COLUMN totp_secret VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE accounts ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE accounts ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id INT NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    account_id INT NOT NULL ,
+    code_hash VARCHAR(64) NOT NULL ,
+    used_at TIMESTAMP NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_recovery_codes_account_id (account_id),
+    CONSTRAINT fk_recovery_codes_account FOREIGN KEY (account_id) REFERENCES accounts (id) ON DELETE CASCADE
+)engine = InnoDB;
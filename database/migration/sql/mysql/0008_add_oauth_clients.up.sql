@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    secret_hash VARCHAR(64) NOT NULL ,
+    name VARCHAR(100) NOT NULL ,
+    scopes VARCHAR(255) NOT NULL DEFAULT '' ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP NULL
+)engine = InnoDB;
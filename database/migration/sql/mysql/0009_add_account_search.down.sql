@@ -0,0 +1 @@
+ALTER TABLE accounts DROP INDEX ft_accounts_search;
@@ -0,0 +1 @@
+ALTER TABLE accounts ADD FULLTEXT INDEX ft_accounts_search (username, email);
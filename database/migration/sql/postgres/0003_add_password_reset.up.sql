@@ -0,0 +1,12 @@
+ALTER TABLE accounts ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS password_resets (
+    id SERIAL NOT NULL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE ,
+    token_hash VARCHAR(64) NOT NULL UNIQUE ,
+    expires_at TIMESTAMP NOT NULL ,
+    used_at TIMESTAMP NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_account_id ON password_resets (account_id);
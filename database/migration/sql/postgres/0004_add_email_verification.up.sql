@@ -0,0 +1,14 @@
+ALTER TABLE accounts ADD COLUMN verified_at TIMESTAMP NULL;
+
+UPDATE accounts SET verified_at = created_at;
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id SERIAL NOT NULL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE ,
+    token_hash VARCHAR(64) NOT NULL UNIQUE ,
+    expires_at TIMESTAMP NOT NULL ,
+    used_at TIMESTAMP NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verifications_account_id ON email_verifications (account_id);
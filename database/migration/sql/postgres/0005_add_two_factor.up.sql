@@ -0,0 +1,15 @@
+ALTER TABLE accounts ADD COLUMN totp_secret VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE accounts ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE accounts ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id SERIAL NOT NULL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE ,
+    code_hash VARCHAR(64) NOT NULL ,
+    used_at TIMESTAMP NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_account_id ON recovery_codes (account_id);
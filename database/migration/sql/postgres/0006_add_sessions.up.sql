@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE ,
+    user_agent VARCHAR(255) NOT NULL DEFAULT '' ,
+    ip VARCHAR(45) NOT NULL DEFAULT '' ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL ,
+    revoked_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_account_id ON sessions (account_id);
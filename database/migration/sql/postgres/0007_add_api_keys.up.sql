@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    prefix VARCHAR(16) NOT NULL ,
+    secret_hash VARCHAR(64) NOT NULL ,
+    name VARCHAR(100) NOT NULL ,
+    account_id INT NULL REFERENCES accounts (id) ON DELETE CASCADE ,
+    service VARCHAR(100) NOT NULL DEFAULT '' ,
+    scopes VARCHAR(255) NOT NULL DEFAULT '' ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NULL ,
+    last_used_at TIMESTAMP NULL ,
+    revoked_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_account_id ON api_keys (account_id);
@@ -0,0 +1 @@
+-- nothing to drop, see 0009_add_account_search.up.sql
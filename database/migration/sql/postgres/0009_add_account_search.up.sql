@@ -0,0 +1 @@
+-- postgres search account with LIKE, full text index only used by mysql
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    email VARCHAR(255) NOT NULL UNIQUE ,
+    username VARCHAR(255) NOT NULL UNIQUE ,
+    password VARCHAR(5000) NOT NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
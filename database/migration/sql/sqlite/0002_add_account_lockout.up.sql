@@ -0,0 +1,2 @@
+ALTER TABLE accounts ADD COLUMN failed_login_count INT NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN locked_until TIMESTAMP NULL;
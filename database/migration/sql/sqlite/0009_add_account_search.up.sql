@@ -0,0 +1 @@
+-- sqlite search account with LIKE, full text index only used by mysql
@@ -0,0 +1,69 @@
+package transaction
+
+import (
+	"cobaMetrics/app/customError"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type txKey struct{}
+
+type TxManager struct {
+	DB *sql.DB
+}
+
+// function provider
+func NewTxManager(db *sql.DB) ITxManager {
+	return &TxManager{DB: db}
+}
+
+// WithinTx run fn inside transaction that carried in ctx.
+// commit when fn success, rollback when fn return error or panic.
+// when ctx already in transaction, fn join the existing transaction
+func (t *TxManager) WithinTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	if _, ok := FromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return customError.NewInternalServerError(fmt.Sprintf("failed to begin transaction : %v", err))
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx.Rollback()
+			panic(recovered)
+		}
+
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customError.NewInternalServerError(fmt.Sprintf("failed to commit transaction : %v", err))
+	}
+
+	return nil
+}
+
+// FromContext return transaction carried in ctx
+func FromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// GetExecutor return transaction in ctx, or db when ctx not in transaction
+func GetExecutor(ctx context.Context, db *sql.DB) Executor {
+	if tx, ok := FromContext(ctx); ok {
+		return tx
+	}
+
+	return db
+}
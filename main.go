@@ -2,30 +2,106 @@ package main
 
 import (
 	config "cobaMetrics/app/config"
+	"cobaMetrics/app/helper"
+	"cobaMetrics/app/logging"
+	"cobaMetrics/app/mailer"
+	"cobaMetrics/app/passwordpolicy"
 	"cobaMetrics/app/tracing"
 	"cobaMetrics/database"
+	"cobaMetrics/database/migration"
 	"cobaMetrics/server"
-	"github.com/go-playground/validator/v10"
+	"context"
+	"database/sql"
 	"github.com/opentracing/opentracing-go"
+	"log/slog"
+	"os"
 )
 
 func main() {
 	// load config
 	config := config.NewConfigApp()
 
+	// create logger
+	logger := logging.NewLogger(config.Config().Log, os.Stdout)
+	slog.SetDefault(logger)
+
+	dbDialect, err := database.NewDialect(config)
+	if err != nil {
+		fatal(logger, "unknown database driver", err)
+	}
+
+	// subcommand migrate
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := migration.RunCommand(context.Background(), os.Args[2:], dbDialect, func() *sql.DB {
+			return database.ConnectDB(config, logger)
+		}, os.Stdout)
+		if err != nil {
+			fatal(logger, "cant run migration", err)
+		}
+
+		return
+	}
+
 	// start tracing
-	tracer, closer := tracing.NewJaegerTracing(config)
+	tracer, closer := tracing.NewJaegerTracing(config, logger)
 	defer closer.Close()
 
 	opentracing.SetGlobalTracer(tracer)
 
+	if err = config.Config().RateLimit.Validate(); err != nil {
+		fatal(logger, "invalid rate limit config", err)
+	}
+
 	// connect db
-	db := database.ConnectDB(config)
+	db := database.ConnectDB(config, logger)
+
+	// auto migrate
+	if config.Config().Database.AutoMigrate {
+		migrations, err := migration.Embedded(dbDialect.Name())
+		if err != nil {
+			fatal(logger, "cant load migration", err)
+		}
+
+		applied, err := migration.NewMigrator(db, dbDialect, migrations).Up(context.Background())
+		if err != nil {
+			fatal(logger, "cant migrate database", err)
+		}
+
+		logger.Info("success migrate database", slog.Int("applied", len(applied)))
+	}
 
-	validate := validator.New()
+	// connect read replica
+	cluster := database.ConnectCluster(config, db, logger)
+	defer cluster.Close()
+
+	validate := helper.NewValidator()
+
+	passwordPolicy, err := passwordpolicy.NewPolicy(config.Config().Password.Policy)
+	if err != nil {
+		fatal(logger, "cant load password policy", err)
+	}
+
+	if err = helper.RegisterPasswordPolicy(validate, passwordPolicy); err != nil {
+		fatal(logger, "cant register password policy", err)
+	}
+
+	helperPassword, err := helper.NewHelperPassword(config.Config().Password)
+	if err != nil {
+		fatal(logger, "invalid password config", err)
+	}
+
+	mailer, err := mailer.NewMailer(config.Config().Mail, logger)
+	if err != nil {
+		fatal(logger, "invalid mail config", err)
+	}
 
 	// run server
-	server := server.NewServerApp(config, db, validate)
+	server := server.NewServerApp(config, cluster, dbDialect, validate, helperPassword, mailer, logger)
 
 	server.RunServer()
 }
+
+func fatal(logger *slog.Logger, message string, err error) {
+	logger.Error(message, slog.String("error", err.Error()))
+	os.Exit(1)
+}
@@ -3,8 +3,11 @@ package metrics
 import "github.com/prometheus/client_golang/prometheus"
 
 type MetricsApp struct {
-	CounterReq  *prometheus.CounterVec
-	DurationReq *prometheus.HistogramVec
+	CounterReq    *prometheus.CounterVec
+	DurationReq   *prometheus.HistogramVec
+	RateLimitHits *prometheus.CounterVec
+	// SearchDuration is latency of account search, separate from DurationReq of endpoint
+	SearchDuration *prometheus.HistogramVec
 }
 
 func AddMetrics() *MetricsApp {
@@ -19,8 +22,21 @@ func AddMetrics() *MetricsApp {
 		Help: "durasi setiap enpoint diprocess",
 	}, []string{"path", "method"})
 
+	rateLimitHits := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_rate_limit_hits_total",
+		Help: "menghitung request yang ditolak karena rate limit",
+	}, []string{"route", "key"})
+
+	searchDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "account_search_duration_seconds",
+		Help:    "durasi query pencarian account",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"outcome"})
+
 	return &MetricsApp{
-		CounterReq:  countReq,
-		DurationReq: durationReq,
+		CounterReq:     countReq,
+		DurationReq:    durationReq,
+		RateLimitHits:  rateLimitHits,
+		SearchDuration: searchDuration,
 	}
 }
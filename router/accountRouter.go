@@ -2,12 +2,16 @@ package router
 
 import (
 	"cobaMetrics/app/handler"
+	"cobaMetrics/app/model/entity"
 	"github.com/gofiber/fiber/v2"
 )
 
-func GenerateAccountRouter(app fiber.Router, authMiddleware fiber.Handler, handler *handler.AccountHandler) {
-	app.Post("/account", handler.Add)
-	app.Get("/account", authMiddleware, handler.GetByEmail)
-	app.Post("/login", handler.Login)
-	app.Get("/accounts", authMiddleware, handler.GetAll)
+// scope return middleware that require scope when request use api key.
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GenerateAccountRouter(app fiber.Router, authMiddleware fiber.Handler, adminMiddleware fiber.Handler, scope func(scope string) fiber.Handler, rateLimit func(route string) fiber.Handler, handler *handler.AccountHandler) {
+	app.Post("/account", rateLimit("register"), handler.Add)
+	app.Get("/account", authMiddleware, scope(entity.ScopeAccountsRead), rateLimit("get_account"), handler.GetByEmail)
+	app.Post("/login", rateLimit("login"), handler.Login)
+	app.Get("/accounts", authMiddleware, scope(entity.ScopeAccountsRead), rateLimit("get_accounts"), handler.GetAll)
+	app.Post("/admin/account/unlock", authMiddleware, adminMiddleware, handler.Unlock)
 }
@@ -0,0 +1,11 @@
+package router
+
+import (
+	"cobaMetrics/app/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GenerateAccountSearchRouter(app fiber.Router, authMiddleware fiber.Handler, adminMiddleware fiber.Handler, rateLimit func(route string) fiber.Handler, handler *handler.AccountSearchHandler) {
+	app.Get("/accounts/search", authMiddleware, adminMiddleware, rateLimit("account_search"), handler.Search)
+}
@@ -0,0 +1,12 @@
+package router
+
+import (
+	"cobaMetrics/app/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GenerateAccountTransferRouter(app fiber.Router, authMiddleware fiber.Handler, adminMiddleware fiber.Handler, rateLimit func(route string) fiber.Handler, handler *handler.AccountTransferHandler) {
+	app.Post("/accounts/import", authMiddleware, adminMiddleware, rateLimit("account_import"), handler.Import)
+	app.Get("/accounts/export", authMiddleware, adminMiddleware, rateLimit("account_export"), handler.Export)
+}
@@ -0,0 +1,16 @@
+package router
+
+import (
+	"cobaMetrics/app/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GenerateApiKeyRouter(app fiber.Router, authMiddleware fiber.Handler, adminMiddleware fiber.Handler, rateLimit func(route string) fiber.Handler, handler *handler.ApiKeyHandler) {
+	app.Post("/api-keys", authMiddleware, rateLimit("api_keys"), handler.Create)
+	app.Get("/api-keys", authMiddleware, rateLimit("api_keys"), handler.GetAll)
+	app.Delete("/api-keys/:id", authMiddleware, rateLimit("api_keys"), handler.Revoke)
+	app.Post("/admin/api-keys", authMiddleware, adminMiddleware, handler.CreateService)
+	app.Get("/admin/api-keys", authMiddleware, adminMiddleware, handler.GetAllService)
+	app.Delete("/admin/api-keys/:id", authMiddleware, adminMiddleware, handler.RevokeService)
+}
@@ -0,0 +1,16 @@
+package router
+
+import (
+	"cobaMetrics/app/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// oauth is group of /oauth, standard path expected by oauth tooling. client registration stay in api group.
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GenerateOAuthRouter(oauth fiber.Router, api fiber.Router, authMiddleware fiber.Handler, adminMiddleware fiber.Handler, rateLimit func(route string) fiber.Handler, handler *handler.OAuthHandler) {
+	oauth.Post("/token", rateLimit("oauth_token"), handler.Token)
+	oauth.Post("/introspect", rateLimit("oauth_introspect"), handler.Introspect)
+	api.Post("/admin/oauth/clients", authMiddleware, adminMiddleware, handler.CreateClient)
+	api.Get("/admin/oauth/clients", authMiddleware, adminMiddleware, handler.GetAllClients)
+	api.Delete("/admin/oauth/clients/:id", authMiddleware, adminMiddleware, handler.RevokeClient)
+}
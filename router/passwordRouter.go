@@ -0,0 +1,12 @@
+package router
+
+import (
+	"cobaMetrics/app/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GeneratePasswordRouter(app fiber.Router, rateLimit func(route string) fiber.Handler, handler *handler.PasswordHandler) {
+	app.Post("/password/forgot", rateLimit("password_forgot"), handler.Forgot)
+	app.Post("/password/reset", rateLimit("password_reset"), handler.Reset)
+}
@@ -0,0 +1,13 @@
+package router
+
+import (
+	"cobaMetrics/app/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GenerateSessionRouter(app fiber.Router, authMiddleware fiber.Handler, rateLimit func(route string) fiber.Handler, handler *handler.SessionHandler) {
+	app.Get("/sessions", authMiddleware, rateLimit("sessions"), handler.GetAll)
+	app.Delete("/sessions", authMiddleware, rateLimit("sessions"), handler.RevokeAll)
+	app.Delete("/sessions/:id", authMiddleware, rateLimit("sessions"), handler.Revoke)
+}
@@ -0,0 +1,14 @@
+package router
+
+import (
+	"cobaMetrics/app/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GenerateTwoFactorRouter(app fiber.Router, authMiddleware fiber.Handler, rateLimit func(route string) fiber.Handler, handler *handler.TwoFactorHandler) {
+	app.Post("/account/2fa/enroll", authMiddleware, rateLimit("two_factor"), handler.Enroll)
+	app.Post("/account/2fa/confirm", authMiddleware, rateLimit("two_factor"), handler.Confirm)
+	app.Post("/account/2fa/disable", authMiddleware, rateLimit("two_factor"), handler.Disable)
+	app.Post("/login/2fa", rateLimit("login_2fa"), handler.Login)
+}
@@ -0,0 +1,12 @@
+package router
+
+import (
+	"cobaMetrics/app/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rateLimit return rate limit middleware of route name, see rate_limit.routes in config
+func GenerateVerificationRouter(app fiber.Router, rateLimit func(route string) fiber.Handler, handler *handler.VerificationHandler) {
+	app.Get("/account/verify", rateLimit("verify_email"), handler.Verify)
+	app.Post("/account/verify/resend", rateLimit("verify_email_resend"), handler.Resend)
+}
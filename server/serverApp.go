@@ -4,18 +4,24 @@ import (
 	"cobaMetrics/app/config"
 	"cobaMetrics/app/handler"
 	"cobaMetrics/app/helper"
+	"cobaMetrics/app/mailer"
 	"cobaMetrics/app/middleware"
+	"cobaMetrics/app/ratelimit"
 	"cobaMetrics/app/repository"
 	"cobaMetrics/app/service"
+	"cobaMetrics/database"
+	"cobaMetrics/database/dialect"
+	"cobaMetrics/database/transaction"
 	"cobaMetrics/metrics"
 	"cobaMetrics/router"
-	"database/sql"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log/slog"
+	"strings"
 )
 
 type ServerApp struct {
@@ -23,30 +29,90 @@ type ServerApp struct {
 	Port int
 }
 
-func NewServerApp(config config.IConfig, db *sql.DB, validate *validator.Validate) IServer {
+func NewServerApp(config config.IConfig, db *database.Cluster, dbDialect dialect.Dialect, validate *validator.Validate, helperPassword helper.IHelperPassword, mailer mailer.Mailer, logger *slog.Logger) IServer {
 	// add metrics
 	metrics := metrics.AddMetrics()
-	prometheus.MustRegister(metrics.CounterReq, metrics.DurationReq)
+	prometheus.MustRegister(metrics.CounterReq, metrics.DurationReq, metrics.RateLimitHits, metrics.SearchDuration)
 
 	// register repository
-	accountRepository := repository.NewAccountRepository()
+	accountRepository := repository.NewAccountRepository(db, dbDialect, logger)
+	passwordResetRepository := repository.NewPasswordResetRepository(db, dbDialect, logger)
+	emailVerificationRepository := repository.NewEmailVerificationRepository(db, dbDialect, logger)
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository(db, dbDialect, logger)
+	sessionRepository := repository.NewSessionRepository(db, dbDialect, logger)
+	apiKeyRepository := repository.NewApiKeyRepository(db, dbDialect, logger)
+	oauthClientRepository := repository.NewOAuthClientRepository(db, dbDialect, logger)
 
 	// register service
-	accountService := service.NewAccountService(db, validate, config, accountRepository, helper.NewHelperPassword())
+	txManager := transaction.NewTxManager(db.Writer())
+	sessionService := service.NewSessionService(config, sessionRepository, logger)
+	apiKeyService := service.NewApiKeyService(validate, apiKeyRepository, logger)
+	oauthService := service.NewOAuthService(validate, config, oauthClientRepository, accountRepository, sessionRepository, logger)
+	verificationService := service.NewVerificationService(txManager, validate, config, accountRepository, emailVerificationRepository, mailer, logger)
+	accountService := service.NewAccountService(txManager, validate, config, accountRepository, helperPassword, verificationService, sessionService, logger)
+	accountSearchService := service.NewAccountSearchService(config, accountRepository, metrics, logger)
+	accountTransferService := service.NewAccountTransferService(txManager, validate, config, accountRepository, helperPassword, logger)
+	twoFactorService := service.NewTwoFactorService(txManager, validate, config, accountRepository, recoveryCodeRepository, helperPassword, sessionService, logger)
+	passwordService := service.NewPasswordService(txManager, validate, config, accountRepository, passwordResetRepository, sessionRepository, helperPassword, mailer, logger)
 
 	// register handler
 	accountHandler := handler.NewAccountHandler(accountService)
+	accountSearchHandler := handler.NewAccountSearchHandler(accountSearchService)
+	accountTransferHandler := handler.NewAccountTransferHandler(accountTransferService)
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+	verificationHandler := handler.NewVerificationHandler(verificationService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	apiKeyHandler := handler.NewApiKeyHandler(apiKeyService)
+	oauthHandler := handler.NewOAuthHandler(oauthService)
 
 	// create instance fiber
-	app := fiber.New()
+	appConfig := config.Config().App
+	app := fiber.New(fiber.Config{
+		ErrorHandler: handler.NewErrorHandler(logger),
 
-	authMiddleware := middleware.AuthMiddleware(config)
+		// ctx.IP() read proxy header only from trusted proxy
+		ProxyHeader:             appConfig.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          appConfig.TrustedProxies,
+		EnableIPValidation:      true,
+
+		// body bigger than BodyLimit streamed instead of rejected, BodyLimitMiddleware keep the limit for route that not stream
+		BodyLimit:         fiber.DefaultBodyLimit,
+		StreamRequestBody: true,
+	})
+
+	app.Use(middleware.RequestIDMiddleware())
+	app.Use(middleware.AccessLogMiddleware(config.Config().AccessLog, logger))
+	app.Use(middleware.BodyLimitMiddleware(func(ctx *fiber.Ctx) bool {
+		// import read its body as stream, limited by account_transfer.import_max_bytes
+		return ctx.Method() == fiber.MethodPost && strings.EqualFold(strings.TrimSuffix(ctx.Path(), "/"), "/api/v1/accounts/import")
+	}))
+
+	authMiddleware := middleware.AuthMiddleware(config, accountRepository, sessionRepository, apiKeyRepository, oauthClientRepository, logger)
+	userAuthMiddleware := middleware.UserAuthMiddleware(config, accountRepository, sessionRepository, logger)
+	adminMiddleware := middleware.AdminMiddleware(config, logger)
+	rateLimiter := middleware.NewRateLimiter(config.Config().RateLimit, ratelimit.NewMemoryStore(), metrics, logger)
 
 	v1 := app.Group("/api/v1")
-	v1.Use(middleware.MetricsMiddleware(config, metrics))
+	oauth := app.Group("/oauth")
+	for _, group := range []fiber.Router{v1, oauth} {
+		group.Use(middleware.LogContextMiddleware())
+		group.Use(middleware.MetricsMiddleware(config, metrics, logger))
+		group.Use(middleware.DatabaseCallerMiddleware())
+		group.Use(middleware.LocaleMiddleware())
+	}
 
 	// router
-	router.GenerateAccountRouter(v1, authMiddleware, accountHandler)
+	router.GenerateAccountRouter(v1, authMiddleware, adminMiddleware, middleware.ScopeMiddleware(logger), rateLimiter.For, accountHandler)
+	router.GenerateAccountSearchRouter(v1, userAuthMiddleware, adminMiddleware, rateLimiter.For, accountSearchHandler)
+	router.GenerateAccountTransferRouter(v1, userAuthMiddleware, adminMiddleware, rateLimiter.For, accountTransferHandler)
+	router.GeneratePasswordRouter(v1, rateLimiter.For, passwordHandler)
+	router.GenerateVerificationRouter(v1, rateLimiter.For, verificationHandler)
+	router.GenerateTwoFactorRouter(v1, userAuthMiddleware, rateLimiter.For, twoFactorHandler)
+	router.GenerateSessionRouter(v1, userAuthMiddleware, rateLimiter.For, sessionHandler)
+	router.GenerateApiKeyRouter(v1, userAuthMiddleware, adminMiddleware, rateLimiter.For, apiKeyHandler)
+	router.GenerateOAuthRouter(oauth, v1, userAuthMiddleware, adminMiddleware, rateLimiter.For, oauthHandler)
 
 	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
 